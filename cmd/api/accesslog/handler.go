@@ -0,0 +1,63 @@
+package accesslog
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// maxLogBytes is the max size of an uploaded access log
+const maxLogBytes = 256 << 20
+
+type Handler interface {
+	// GetReport answers the proxy traffic report of the access log sent in the body
+	GetReport(w http.ResponseWriter, r *http.Request)
+}
+
+type handler struct {
+	gtw ipdata.Gateway
+}
+
+func NewHandler(gtw ipdata.Gateway) Handler {
+	return handler{gtw: gtw}
+}
+
+// GetReport reads the access log from the body, format, json_field and limit are optional query params
+func (h handler) GetReport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cfg := Config{ParserConfig: ParserConfig{
+		Format:    r.URL.Query().Get("format"),
+		JSONField: r.URL.Query().Get("json_field"),
+	}}
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit < 1 {
+			err = fmt.Errorf("param: limit must be a positive number %w", common.ErrorBadRequest)
+			common.HandlerErrorResponse(w, err)
+			return
+		}
+		cfg.TopLimit = limit
+	}
+	if err := cfg.ParserConfig.withDefaults().validate(); err != nil {
+		common.HandlerErrorResponse(w, fmt.Errorf("param: %s %w", err.Error(), common.ErrorBadRequest))
+		return
+	}
+
+	report, err := BuildReport(ctx, h.gtw, http.MaxBytesReader(w, r.Body, maxLogBytes), cfg)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	response, err := json.Marshal(report)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	w.Write(response)
+}
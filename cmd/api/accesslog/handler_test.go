@@ -0,0 +1,34 @@
+package accesslog
+
+import (
+	"DreamLabChallenge/cmd/api/ipdata"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestHandler_GetReport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw)
+
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(mockVPNIpData, nil)
+
+	req := httptest.NewRequest("POST", "/ipdata/reports/accesslog?format=json&limit=1", strings.NewReader(`{"remote_addr":"5.181.131.180"}`))
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(testHandler.GetReport).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"requests":1,"proxied_requests":1,"proxied_share":100`)
+
+	req = httptest.NewRequest("POST", "/ipdata/reports/accesslog?format=w3c", strings.NewReader(""))
+	rr = httptest.NewRecorder()
+	http.HandlerFunc(testHandler.GetReport).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "param: invalid format w3c bad request\n", rr.Body.String())
+}
@@ -0,0 +1,81 @@
+package accesslog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// formats of the access log lines
+const (
+	FormatAuto     = "auto"
+	FormatCombined = "combined"
+	FormatJSON     = "json"
+
+	DefaultJSONField = "remote_addr"
+)
+
+type ParserConfig struct {
+	// Format is FormatCombined (nginx and Apache common or combined), FormatJSON or FormatAuto to detect it per line
+	Format string
+	// JSONField is the field of the json lines holding the client ip
+	JSONField string
+}
+
+func (c ParserConfig) withDefaults() ParserConfig {
+	if c.Format == "" {
+		c.Format = FormatAuto
+	}
+	if c.JSONField == "" {
+		c.JSONField = DefaultJSONField
+	}
+	return c
+}
+
+func (c ParserConfig) validate() error {
+	if c.Format != FormatAuto && c.Format != FormatCombined && c.Format != FormatJSON {
+		return fmt.Errorf("invalid format %s", c.Format)
+	}
+	return nil
+}
+
+// ClientIP returns the client ip of the log line. In the combined format it is the first field, in the json format
+// the configured field. An ip with port or a comma separated list (X-Forwarded-For) gives its first ip
+func (c ParserConfig) ClientIP(line string) (netip.Addr, error) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return netip.Addr{}, fmt.Errorf("empty line")
+	}
+
+	var value string
+	if c.Format == FormatJSON || (c.Format == FormatAuto && strings.HasPrefix(line, "{")) {
+		fields := make(map[string]interface{})
+		err := json.Unmarshal([]byte(line), &fields)
+		if err != nil {
+			return netip.Addr{}, fmt.Errorf("invalid json line. %w", err)
+		}
+		fieldValue, found := fields[c.JSONField].(string)
+		if !found {
+			return netip.Addr{}, fmt.Errorf("field %s not found", c.JSONField)
+		}
+		value = fieldValue
+	} else {
+		value = strings.Fields(line)[0]
+	}
+
+	return parseClientIP(value)
+}
+
+func parseClientIP(value string) (netip.Addr, error) {
+	value = strings.TrimSpace(strings.Split(value, ",")[0])
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		addrPort, portErr := netip.ParseAddrPort(value)
+		if portErr != nil {
+			return netip.Addr{}, fmt.Errorf("invalid client ip %q", value)
+		}
+		addr = addrPort.Addr()
+	}
+	return addr.Unmap(), nil
+}
@@ -0,0 +1,58 @@
+package accesslog
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"github.com/stretchr/testify/assert"
+	"net/netip"
+	"testing"
+)
+
+func TestParserConfig_ClientIP(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Combined format", TestFn: testClientIPCombined},
+		{Scenario: "Json format", TestFn: testClientIPJSON},
+		{Scenario: "Json forwarded for list", TestFn: testClientIPJSONForwardedList},
+		{Scenario: "Invalid lines error", TestFn: testClientIPInvalidLinesError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func testClientIPCombined(t *testing.T) {
+	cfg := ParserConfig{}.withDefaults()
+	line := `5.181.131.180 - - [19/Oct/2026:10:00:00 +0000] "GET / HTTP/1.1" 200 612 "-" "curl/8.0"`
+
+	ip, err := cfg.ClientIP(line)
+
+	assert.Nil(t, err)
+	assert.Equal(t, netip.MustParseAddr("5.181.131.180"), ip)
+}
+
+func testClientIPJSON(t *testing.T) {
+	cfg := ParserConfig{JSONField: "client"}.withDefaults()
+
+	ip, err := cfg.ClientIP(`{"time":"2026-10-19T10:00:00Z","client":"[::ffff:5.181.131.180]:51234","status":200}`)
+
+	assert.Nil(t, err)
+	assert.Equal(t, netip.MustParseAddr("5.181.131.180"), ip)
+}
+
+func testClientIPJSONForwardedList(t *testing.T) {
+	cfg := ParserConfig{Format: FormatJSON, JSONField: "x_forwarded_for"}
+
+	ip, err := cfg.ClientIP(`{"x_forwarded_for":"5.181.131.180, 10.0.0.1"}`)
+
+	assert.Nil(t, err)
+	assert.Equal(t, netip.MustParseAddr("5.181.131.180"), ip)
+}
+
+func testClientIPInvalidLinesError(t *testing.T) {
+	cfg := ParserConfig{}.withDefaults()
+
+	for _, line := range []string{"", "- - - [19/Oct/2026:10:00:00 +0000]", `{"remote":"5.181.131.180"}`, `{"remote_addr":`} {
+		_, err := cfg.ClientIP(line)
+		assert.NotNil(t, err, line)
+	}
+}
@@ -0,0 +1,274 @@
+package accesslog
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"DreamLabChallenge/pkg/proxydetect"
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+const (
+	// NotProxied is the proxy type of the requests from ips not present in the dataset
+	NotProxied = "none"
+
+	DefaultTopLimit = 10
+	// maxLineSize is the longest log line accepted, longer lines are skipped
+	maxLineSize = 1 << 20
+	// maxCachedIps is the max number of ips kept by the lookup cache before it is cleared
+	maxCachedIps = 100000
+)
+
+type Config struct {
+	ParserConfig
+	// TopLimit is the max number of countries, ISPs and ranges of the report
+	TopLimit int
+}
+
+// Report is the proxy traffic breakdown of an access log
+type Report struct {
+	TotalLines      int64           `json:"total_lines"`
+	SkippedLines    int64           `json:"skipped_lines"`
+	Requests        int64           `json:"requests"`
+	ProxiedRequests int64           `json:"proxied_requests"`
+	ProxiedShare    float64         `json:"proxied_share"`
+	ByProxyType     []RequestShare  `json:"by_proxy_type"`
+	TopCountries    []RequestShare  `json:"top_countries"`
+	TopIsps         []RequestShare  `json:"top_isps"`
+	TopRanges       []RangeRequests `json:"top_ranges"`
+}
+
+// RequestShare is the number of requests of a proxy type, country or ISP. The share of the proxy types is over all
+// the requests, the share of the countries and ISPs is over the proxied requests
+type RequestShare struct {
+	Key      string  `json:"key"`
+	Name     string  `json:"name,omitempty"`
+	Requests int64   `json:"requests"`
+	Share    float64 `json:"share"`
+}
+
+// RangeRequests is a dataset range with the requests made from its ips
+type RangeRequests struct {
+	ipdata.IpRange
+	Requests  int64 `json:"requests"`
+	ClientIPs int   `json:"client_ips"`
+}
+
+// lookupCache keeps the data of the last looked up ips
+type lookupCache struct {
+	gtw  ipdata.Gateway
+	data map[netip.Addr]ipdata.IpData
+}
+
+func newLookupCache(gtw ipdata.Gateway) *lookupCache {
+	return &lookupCache{gtw: gtw, data: make(map[netip.Addr]ipdata.IpData)}
+}
+
+func (c *lookupCache) lookup(ctx context.Context, ip netip.Addr) (ipdata.IpData, error) {
+	if data, found := c.data[ip]; found {
+		return data, nil
+	}
+	data, err := proxydetect.Lookup(ctx, c.gtw, ip)
+	if err != nil {
+		return ipdata.IpData{}, err
+	}
+	if len(c.data) >= maxCachedIps {
+		c.data = make(map[netip.Addr]ipdata.IpData)
+	}
+	c.data[ip] = data
+	return data, nil
+}
+
+// BuildReport reads the access log and looks up every distinct client ip once
+func BuildReport(ctx context.Context, gtw ipdata.Gateway, log io.Reader, cfg Config) (Report, error) {
+	cfg, err := cfg.withDefaults()
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{}
+	clientIPs := make(map[netip.Addr]int64)
+	err = readLines(log, func(line string) error {
+		report.TotalLines++
+		ip, err := cfg.ClientIP(line)
+		if err != nil {
+			report.SkippedLines++
+			return nil
+		}
+		report.Requests++
+		clientIPs[ip]++
+		return nil
+	})
+	if err != nil {
+		return Report{}, fmt.Errorf("error reading the access log. %s %w", err.Error(), common.ErrorBadRequest)
+	}
+
+	err = report.aggregate(ctx, newLookupCache(gtw), clientIPs, cfg.TopLimit)
+	if err != nil {
+		return Report{}, err
+	}
+	return report, nil
+}
+
+// AnnotatedLine is a log line with the data of its client ip
+type AnnotatedLine struct {
+	Line     string        `json:"line"`
+	ClientIP string        `json:"client_ip,omitempty"`
+	Data     ipdata.IpData `json:"data"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Annotate writes a json line for every line of the access log with the data of its client ip
+func Annotate(ctx context.Context, gtw ipdata.Gateway, log io.Reader, w io.Writer, cfg Config) error {
+	cfg, err := cfg.withDefaults()
+	if err != nil {
+		return err
+	}
+
+	cache := newLookupCache(gtw)
+	out := bufio.NewWriter(w)
+	defer out.Flush()
+	encoder := json.NewEncoder(out)
+	return readLines(log, func(line string) error {
+		annotated := AnnotatedLine{Line: line}
+		ip, err := cfg.ClientIP(line)
+		if err != nil {
+			annotated.Error = err.Error()
+			return encoder.Encode(annotated)
+		}
+		annotated.ClientIP = ip.String()
+		annotated.Data, err = cache.lookup(ctx, ip)
+		if err != nil {
+			return err
+		}
+		return encoder.Encode(annotated)
+	})
+}
+
+func (c Config) withDefaults() (Config, error) {
+	c.ParserConfig = c.ParserConfig.withDefaults()
+	if c.TopLimit < 1 {
+		c.TopLimit = DefaultTopLimit
+	}
+	return c, c.ParserConfig.validate()
+}
+
+// aggregate looks up the client ips and fills the breakdowns of the report, clientIPs holds the requests of each ip
+func (r *Report) aggregate(ctx context.Context, cache *lookupCache, clientIPs map[netip.Addr]int64, topLimit int) error {
+	byProxyType := make(map[string]*RequestShare)
+	byCountry := make(map[string]*RequestShare)
+	byIsp := make(map[string]*RequestShare)
+	byRange := make(map[[2]int64]*RangeRequests)
+
+	for ip, requests := range clientIPs {
+		data, err := cache.lookup(ctx, ip)
+		if err != nil {
+			return err
+		}
+
+		proxyType := data.ProxyType
+		if !isProxied(data) {
+			proxyType = NotProxied
+		}
+		addShare(byProxyType, proxyType, "", requests)
+		if proxyType == NotProxied {
+			continue
+		}
+
+		r.ProxiedRequests += requests
+		addShare(byCountry, data.CountryCode, data.CountryName, requests)
+		addShare(byIsp, data.ISP, "", requests)
+		key := [2]int64{data.IpFrom, data.IpTo}
+		if _, found := byRange[key]; !found {
+			byRange[key] = &RangeRequests{
+				IpRange: ipdata.IpRange{IpFrom: data.IpFrom, IpTo: data.IpTo, ProxyType: data.ProxyType, CountryCode: data.CountryCode,
+					Isp: data.ISP},
+			}
+		}
+		byRange[key].Requests += requests
+		byRange[key].ClientIPs++
+	}
+
+	r.ProxiedShare = ipdata.PercentageOf(r.ProxiedRequests, r.Requests)
+	r.ByProxyType = sortedShares(byProxyType, r.Requests, len(byProxyType))
+	r.TopCountries = sortedShares(byCountry, r.ProxiedRequests, topLimit)
+	r.TopIsps = sortedShares(byIsp, r.ProxiedRequests, topLimit)
+
+	r.TopRanges = make([]RangeRequests, 0, len(byRange))
+	for _, ipRange := range byRange {
+		r.TopRanges = append(r.TopRanges, *ipRange)
+	}
+	sort.Slice(r.TopRanges, func(i, j int) bool {
+		if r.TopRanges[i].Requests != r.TopRanges[j].Requests {
+			return r.TopRanges[i].Requests > r.TopRanges[j].Requests
+		}
+		return r.TopRanges[i].IpFrom < r.TopRanges[j].IpFrom
+	})
+	if len(r.TopRanges) > topLimit {
+		r.TopRanges = r.TopRanges[:topLimit]
+	}
+	for i := range r.TopRanges {
+		r.TopRanges[i].IpRange = ipdata.EnrichRange(r.TopRanges[i].IpRange)
+	}
+	return nil
+}
+
+// isProxied is false for the ips not present in the dataset, the vendor marks the missing values with -
+func isProxied(data ipdata.IpData) bool {
+	return data.ProxyType != "" && data.ProxyType != "-"
+}
+
+func addShare(shares map[string]*RequestShare, key string, name string, requests int64) {
+	if _, found := shares[key]; !found {
+		shares[key] = &RequestShare{Key: key, Name: name}
+	}
+	shares[key].Requests += requests
+}
+
+// sortedShares returns the top (limit) shares by requests with their percentage of total
+func sortedShares(shares map[string]*RequestShare, total int64, limit int) []RequestShare {
+	sorted := make([]RequestShare, 0, len(shares))
+	for _, share := range shares {
+		share.Share = ipdata.PercentageOf(share.Requests, total)
+		sorted = append(sorted, *share)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Requests != sorted[j].Requests {
+			return sorted[i].Requests > sorted[j].Requests
+		}
+		return sorted[i].Key < sorted[j].Key
+	})
+	if len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+	return sorted
+}
+
+// readLines calls fn with every line of r, the lines longer than maxLineSize are passed empty
+func readLines(r io.Reader, fn func(line string) error) error {
+	reader := bufio.NewReaderSize(r, 64*1024)
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			if len(line) > maxLineSize {
+				line = ""
+			}
+			fnErr := fn(strings.TrimRight(line, "\r\n"))
+			if fnErr != nil {
+				return fnErr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package accesslog
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestReport_BuildReport(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testBuildReportNoError},
+		{Scenario: "Gateway error", TestFn: testBuildReportGtwError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestReport_Annotate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+
+	// the second line is answered by the cache
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(mockVPNIpData, nil).Times(1)
+
+	var out bytes.Buffer
+	err := Annotate(context.Background(), mockGtw, strings.NewReader("5.181.131.180 a\n5.181.131.180 b\ngarbage\n"), &out, Config{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, `{"line":"5.181.131.180 a","client_ip":"5.181.131.180","data":{"ip_from":95781810,"ip_to":95781817,"proxy_type":"VPN","country_code":"GB","county_name":"United Kingdom of Great Britain and Northern Ireland","isp":"IPXO Limited","ip_string":"5.181.131.180"}}`+"\n"+
+		`{"line":"5.181.131.180 b","client_ip":"5.181.131.180","data":{"ip_from":95781810,"ip_to":95781817,"proxy_type":"VPN","country_code":"GB","county_name":"United Kingdom of Great Britain and Northern Ireland","isp":"IPXO Limited","ip_string":"5.181.131.180"}}`+"\n"+
+		`{"line":"garbage","data":{},"error":"invalid client ip \"garbage\""}`+"\n", out.String())
+}
+
+func testBuildReportNoError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(mockVPNIpData, nil)
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.181")).Return(mockVPNIpData, nil)
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("1.1.1.1")).Return(ipdata.IpData{}, fmt.Errorf("error getting Ips Ip count.  %w", common.ErrorNotFound))
+
+	log := `5.181.131.180 - - [19/Oct/2026:10:00:00 +0000] "GET / HTTP/1.1" 200 612 "-" "curl/8.0"
+5.181.131.180 - - [19/Oct/2026:10:00:01 +0000] "GET /a HTTP/1.1" 200 612 "-" "curl/8.0"
+{"remote_addr":"5.181.131.181","request":"GET /b HTTP/1.1"}
+1.1.1.1 - - [19/Oct/2026:10:00:02 +0000] "GET / HTTP/1.1" 200 612 "-" "curl/8.0"
+not a log line
+`
+	report, err := BuildReport(context.Background(), mockGtw, strings.NewReader(log), Config{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, Report{
+		TotalLines:      5,
+		SkippedLines:    1,
+		Requests:        4,
+		ProxiedRequests: 3,
+		ProxiedShare:    75,
+		ByProxyType:     []RequestShare{{Key: "VPN", Requests: 3, Share: 75}, {Key: NotProxied, Requests: 1, Share: 25}},
+		TopCountries:    []RequestShare{{Key: "GB", Name: "United Kingdom of Great Britain and Northern Ireland", Requests: 3, Share: 100}},
+		TopIsps:         []RequestShare{{Key: "IPXO Limited", Requests: 3, Share: 100}},
+		TopRanges: []RangeRequests{{
+			IpRange: ipdata.IpRange{
+				IpFrom: 95781810, IpTo: 95781817, IpFromString: "5.181.131.178", IpToString: "5.181.131.185",
+				CIDRs:     []string{"5.181.131.178/31", "5.181.131.180/30", "5.181.131.184/31"},
+				ProxyType: "VPN", CountryCode: "GB", Isp: "IPXO Limited",
+			},
+			Requests:  3,
+			ClientIPs: 2,
+		}},
+	}, report)
+}
+
+func testBuildReportGtwError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(ipdata.IpData{}, common.ErrorInternalServer)
+
+	report, err := BuildReport(context.Background(), mockGtw, strings.NewReader("5.181.131.180 - -\n"), Config{})
+
+	assert.Equal(t, Report{}, report)
+	assert.ErrorIs(t, err, common.ErrorInternalServer)
+}
+
+// mock utils
+
+var mockVPNIpData = ipdata.IpData{
+	IpFrom:      95781810,
+	IpTo:        95781817,
+	ProxyType:   "VPN",
+	CountryCode: "GB",
+	CountryName: "United Kingdom of Great Britain and Northern Ireland",
+	ISP:         "IPXO Limited",
+	IpString:    "5.181.131.180",
+}
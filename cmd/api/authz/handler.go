@@ -0,0 +1,124 @@
+package authz
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"DreamLabChallenge/pkg/proxydetect"
+	"net/http"
+)
+
+const (
+	HeaderDecision = "X-Auth-Decision"
+	HeaderReason   = "X-Auth-Reason"
+	HeaderClientIP = "X-Auth-Client-Ip"
+
+	decisionAllow = "allow"
+	decisionDeny  = "deny"
+)
+
+// DefaultClientIPHeaders are the headers where nginx (X-Real-IP) and envoy (X-Envoy-External-Address,
+// X-Forwarded-For) usually leave the client ip, they are only read from the TrustedProxies
+var DefaultClientIPHeaders = []string{"X-Real-IP", "X-Envoy-External-Address", "X-Forwarded-For"}
+
+type Config struct {
+	// ClientIPHeaders are the request headers checked in order to get the client ip
+	ClientIPHeaders []string
+	// TrustedProxies are the edge proxies allowed to set the ClientIPHeaders, they are skipped when a header holds
+	// a chain of ips
+	TrustedProxies common.TrustedProxies
+	// Policy decides which clients are denied
+	Policy ipdata.Policy
+	// FailOpen allows the request when the lookup fails instead of answering with an error
+	FailOpen bool
+}
+
+type Handler interface {
+	// NginxAuthRequest answers nginx auth_request subrequests
+	NginxAuthRequest(w http.ResponseWriter, r *http.Request)
+	// EnvoyExtAuthz answers envoy ext_authz http service checks
+	EnvoyExtAuthz(w http.ResponseWriter, r *http.Request)
+}
+
+type handler struct {
+	gtw ipdata.Gateway
+	cfg Config
+}
+
+func NewHandler(gtw ipdata.Gateway, cfg Config) Handler {
+	if len(cfg.ClientIPHeaders) == 0 {
+		cfg.ClientIPHeaders = DefaultClientIPHeaders
+	}
+	return handler{gtw: gtw, cfg: cfg}
+}
+
+type decision struct {
+	allowed  bool
+	reason   string
+	clientIP string
+	ipData   ipdata.IpData
+}
+
+// NginxAuthRequest answers 200 when the client is allowed and 403 when not, nginx only honors those
+// status codes and ignores the body. The decision is in the headers so it can be read with auth_request_set.
+func (h handler) NginxAuthRequest(w http.ResponseWriter, r *http.Request) {
+	decision, err := h.decide(r)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	setDecisionHeaders(w.Header(), decision)
+	if !decision.allowed {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// EnvoyExtAuthz answers 200 when the client is allowed, envoy copies the headers listed in
+// allowed_upstream_headers to the upstream request. When denied the 403 and its body reach the client.
+func (h handler) EnvoyExtAuthz(w http.ResponseWriter, r *http.Request) {
+	decision, err := h.decide(r)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	setDecisionHeaders(w.Header(), decision)
+	if !decision.allowed {
+		http.Error(w, decision.reason, http.StatusForbidden)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h handler) decide(r *http.Request) (decision, error) {
+	clientIP, err := common.GetClientIPFromHeaders(r, h.cfg.ClientIPHeaders, h.cfg.TrustedProxies)
+	if err != nil {
+		return decision{}, err
+	}
+
+	ipData, err := proxydetect.Lookup(r.Context(), h.gtw, clientIP)
+	if err != nil {
+		if !h.cfg.FailOpen {
+			return decision{}, err
+		}
+		return decision{allowed: true, reason: "lookup failed", clientIP: clientIP.String()}, nil
+	}
+
+	allowed, reason := h.cfg.Policy.Evaluate(ipData)
+	return decision{allowed: allowed, reason: reason, clientIP: clientIP.String(), ipData: ipData}, nil
+}
+
+func setDecisionHeaders(header http.Header, decision decision) {
+	ipdata.SetEnrichmentHeaders(header, decision.ipData)
+	header.Set(HeaderClientIP, decision.clientIP)
+	if decision.allowed {
+		header.Set(HeaderDecision, decisionAllow)
+	} else {
+		header.Set(HeaderDecision, decisionDeny)
+	}
+	if decision.reason != "" {
+		header.Set(HeaderReason, decision.reason)
+	}
+}
@@ -0,0 +1,192 @@
+package authz
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestHandler_NginxAuthRequest(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Allowed", TestFn: testHandlerNginxAllowed},
+		{Scenario: "Denied", TestFn: testHandlerNginxDenied},
+		{Scenario: "No client ip header error", TestFn: testHandlerNginxNoClientIPHeader},
+		{Scenario: "Gateway error", TestFn: testHandlerNginxGtwError},
+		{Scenario: "Gateway error fail open", TestFn: testHandlerNginxGtwErrorFailOpen},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestHandler_EnvoyExtAuthz(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Allowed", TestFn: testHandlerEnvoyAllowed},
+		{Scenario: "Denied", TestFn: testHandlerEnvoyDenied},
+		{Scenario: "Trusted proxies skipped in forwarded for", TestFn: testHandlerEnvoyTrustedProxiesSkipped},
+		{Scenario: "Header of an untrusted peer ignored", TestFn: testHandlerEnvoyUntrustedPeerHeaderIgnored},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func testHandlerNginxAllowed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, Config{TrustedProxies: mockEdgeProxies, Policy: ipdata.NewPolicy([]string{"TOR"}, nil)})
+
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(mockAuthzIpData, nil)
+
+	rr := serveAuthz(testHandler.NginxAuthRequest, "/authz/nginx", map[string]string{"X-Real-IP": "5.181.131.180"})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "", rr.Body.String())
+	assert.Equal(t, "allow", rr.Header().Get(HeaderDecision))
+	assert.Equal(t, "5.181.131.180", rr.Header().Get(HeaderClientIP))
+	assert.Equal(t, "VPN", rr.Header().Get(ipdata.HeaderProxyType))
+	assert.Equal(t, "GB", rr.Header().Get(ipdata.HeaderCountryCode))
+}
+
+func testHandlerNginxDenied(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, Config{TrustedProxies: mockEdgeProxies, Policy: ipdata.NewPolicy([]string{"VPN"}, nil)})
+
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(mockAuthzIpData, nil)
+
+	rr := serveAuthz(testHandler.NginxAuthRequest, "/authz/nginx", map[string]string{"X-Real-IP": "5.181.131.180"})
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Equal(t, "deny", rr.Header().Get(HeaderDecision))
+	assert.Equal(t, "proxy_type VPN is blocked", rr.Header().Get(HeaderReason))
+}
+
+func testHandlerNginxNoClientIPHeader(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, Config{ClientIPHeaders: []string{"X-Client"}, TrustedProxies: mockEdgeProxies})
+
+	rr := serveAuthz(testHandler.NginxAuthRequest, "/authz/nginx", map[string]string{"X-Real-IP": "5.181.131.180"})
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "client ip header not found bad request\n", rr.Body.String())
+}
+
+func testHandlerNginxGtwError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, Config{TrustedProxies: mockEdgeProxies})
+
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(ipdata.IpData{}, common.ErrorInternalServer)
+
+	rr := serveAuthz(testHandler.NginxAuthRequest, "/authz/nginx", map[string]string{"X-Real-IP": "5.181.131.180"})
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
+func testHandlerNginxGtwErrorFailOpen(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, Config{TrustedProxies: mockEdgeProxies, FailOpen: true})
+
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(ipdata.IpData{}, common.ErrorInternalServer)
+
+	rr := serveAuthz(testHandler.NginxAuthRequest, "/authz/nginx", map[string]string{"X-Real-IP": "5.181.131.180"})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "lookup failed", rr.Header().Get(HeaderReason))
+}
+
+func testHandlerEnvoyAllowed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, Config{TrustedProxies: mockEdgeProxies})
+
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(ipdata.IpData{}, common.ErrorNotFound)
+
+	rr := serveAuthz(testHandler.EnvoyExtAuthz, "/authz/envoy/app/path", map[string]string{"X-Envoy-External-Address": "5.181.131.180"})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "allow", rr.Header().Get(HeaderDecision))
+	assert.Equal(t, "", rr.Header().Get(ipdata.HeaderProxyType))
+}
+
+func testHandlerEnvoyDenied(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, Config{TrustedProxies: mockEdgeProxies, Policy: ipdata.NewPolicy(nil, []string{"GB"})})
+
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(mockAuthzIpData, nil)
+
+	rr := serveAuthz(testHandler.EnvoyExtAuthz, "/authz/envoy/app/path", map[string]string{"X-Envoy-External-Address": "5.181.131.180"})
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Equal(t, "country_code GB is blocked\n", rr.Body.String())
+	assert.Equal(t, "deny", rr.Header().Get(HeaderDecision))
+}
+
+func testHandlerEnvoyTrustedProxiesSkipped(t *testing.T) {
+	trusted, _ := common.ParseTrustedProxies([]string{"10.0.0.0/8", "127.0.0.1"})
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, Config{ClientIPHeaders: []string{"X-Forwarded-For"}, TrustedProxies: trusted})
+
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(mockAuthzIpData, nil)
+
+	rr := serveAuthz(testHandler.EnvoyExtAuthz, "/authz/envoy/", map[string]string{"X-Forwarded-For": "1.1.1.1, 5.181.131.180, 10.1.1.1"})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "5.181.131.180", rr.Header().Get(HeaderClientIP))
+}
+
+func testHandlerEnvoyUntrustedPeerHeaderIgnored(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, Config{TrustedProxies: mockEdgeProxies, Policy: ipdata.NewPolicy([]string{"VPN"}, nil)})
+
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(mockAuthzIpData, nil)
+
+	req := httptest.NewRequest("GET", "/authz/envoy/", nil)
+	req.RemoteAddr = "5.181.131.180:52000"
+	req.Header.Set("X-Real-IP", "1.1.1.1")
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(testHandler.EnvoyExtAuthz).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Equal(t, "deny", rr.Header().Get(HeaderDecision))
+	assert.Equal(t, "5.181.131.180", rr.Header().Get(HeaderClientIP))
+}
+
+// mock utils
+
+// mockEdgeProxies is the peer of the requests of serveAuthz
+var mockEdgeProxies, _ = common.ParseTrustedProxies([]string{"127.0.0.1"})
+
+var mockAuthzIpData = ipdata.IpData{
+	IpFrom:      95781810,
+	IpTo:        95781817,
+	ProxyType:   "VPN",
+	CountryCode: "GB",
+	ISP:         "IPXO Limited",
+	IpString:    "5.181.131.180",
+}
+
+func serveAuthz(handlerFn http.HandlerFunc, url string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", url, nil)
+	req.RemoteAddr = "127.0.0.1:52000"
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	rr := httptest.NewRecorder()
+	handlerFn.ServeHTTP(rr, req)
+
+	return rr
+}
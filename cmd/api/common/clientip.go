@@ -0,0 +1,254 @@
+package common
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+const (
+	headerXForwardedFor = "X-Forwarded-For"
+	headerForwarded     = "Forwarded"
+)
+
+// TrustedProxies is the list of networks allowed to set the client ip through X-Forwarded-For
+type TrustedProxies []netip.Prefix
+
+// ParseTrustedProxies parses a list of CIDRs (or single ips) into TrustedProxies
+func ParseTrustedProxies(cidrs []string) (TrustedProxies, error) {
+	trusted := make(TrustedProxies, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		if !strings.Contains(cidr, "/") {
+			addr, err := netip.ParseAddr(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %s. %w", cidr, err)
+			}
+			trusted = append(trusted, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %s. %w", cidr, err)
+		}
+		trusted = append(trusted, prefix.Masked())
+	}
+
+	return trusted, nil
+}
+
+// Contains returns true if the given addr belongs to any of the trusted networks
+func (t TrustedProxies) Contains(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range t {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetClientIP returns the ip of the client that made the request.
+// header is the one the trusted proxies write the client ip to, X-Forwarded-For when empty: Forwarded is read as
+// RFC 7239, any other header as a X-Forwarded-For list. no other header is read, a proxy forwards the ones sent by
+// the client untouched. the header is only taken into account when the immediate peer is a trusted proxy, in
+// that case the chain is walked from right to left and the first untrusted hop is returned.
+// if RemoteAddr can't be parsed returns a common.ErrorBadRequest
+func GetClientIP(r *http.Request, trusted TrustedProxies, header string) (netip.Addr, error) {
+	peer, err := parseRemoteAddr(r.RemoteAddr)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	if header == "" {
+		header = headerXForwardedFor
+	}
+	if http.CanonicalHeaderKey(header) == headerForwarded {
+		return walkForwardedChain(peer, ForwardedForNodes(r.Header.Values(headerForwarded)), trusted), nil
+	}
+	return walkForwardedChain(peer, r.Header.Values(header), trusted), nil
+}
+
+// ForwardedElement is an element (a hop) of the Forwarded (RFC 7239) header, its values are unquoted
+type ForwardedElement struct {
+	For   string
+	By    string
+	Proto string
+	Host  string
+}
+
+// ParseForwarded returns the elements of the Forwarded (RFC 7239) header values in order. the commas and
+// semicolons of quoted values don't split them, the unknown parameters are ignored
+func ParseForwarded(headerValues []string) []ForwardedElement {
+	elements := make([]ForwardedElement, 0)
+	for _, value := range headerValues {
+		for _, rawElement := range splitUnquoted(value, ',') {
+			if strings.TrimSpace(rawElement) == "" {
+				continue
+			}
+			element := ForwardedElement{}
+			for _, pair := range splitUnquoted(rawElement, ';') {
+				key, val, found := strings.Cut(strings.TrimSpace(pair), "=")
+				if !found {
+					continue
+				}
+				val = unquote(strings.TrimSpace(val))
+				switch strings.ToLower(strings.TrimSpace(key)) {
+				case "for":
+					element.For = val
+				case "by":
+					element.By = val
+				case "proto":
+					element.Proto = val
+				case "host":
+					element.Host = val
+				}
+			}
+			elements = append(elements, element)
+		}
+	}
+	return elements
+}
+
+// ForwardedForNodes returns the "for" node of each element of the Forwarded (RFC 7239) header values,
+// in order and without quotes, brackets or ports. obfuscated and "unknown" nodes are kept as they are.
+func ForwardedForNodes(headerValues []string) []string {
+	nodes := make([]string, 0)
+	for _, element := range ParseForwarded(headerValues) {
+		if node := StripNodePort(element.For); node != "" {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// StripNodePort removes the brackets and the port of a RFC 7239 node ("[2001:db8::1]:4711", "192.0.2.1:80")
+func StripNodePort(node string) string {
+	if strings.HasPrefix(node, "[") {
+		if end := strings.Index(node, "]"); end > 0 {
+			return node[1:end]
+		}
+		return node
+	}
+	if strings.Count(node, ":") == 1 {
+		host, _, _ := strings.Cut(node, ":")
+		return host
+	}
+	return node
+}
+
+// splitUnquoted splits value by sep, the separators inside a quoted string are kept
+func splitUnquoted(value string, sep byte) []string {
+	parts := make([]string, 0)
+	quoted := false
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch {
+		case value[i] == '\\' && quoted:
+			i++
+		case value[i] == '"':
+			quoted = !quoted
+		case value[i] == sep && !quoted:
+			parts = append(parts, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, value[start:])
+}
+
+// unquote removes the quotes and the escapes of a RFC 7230 quoted string, other values are returned as they are
+func unquote(value string) string {
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return value
+	}
+	var unquoted strings.Builder
+	for i := 1; i < len(value)-1; i++ {
+		if value[i] == '\\' && i+1 < len(value)-1 {
+			i++
+		}
+		unquoted.WriteByte(value[i])
+	}
+	return unquoted.String()
+}
+
+// GetClientIPFromHeaders returns the client ip from the first of the given headers present in the request.
+// it's meant for requests coming from an edge proxy (nginx, envoy) that already set the client ip, the headers
+// are only read when the immediate peer is a trusted proxy, otherwise the peer is the client. list headers
+// (as X-Forwarded-For) are walked from right to left skipping the trusted proxies.
+// if a trusted peer sent none of the headers or an invalid ip returns a common.ErrorBadRequest
+func GetClientIPFromHeaders(r *http.Request, headers []string, trusted TrustedProxies) (netip.Addr, error) {
+	peer, err := parseRemoteAddr(r.RemoteAddr)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	if !trusted.Contains(peer) {
+		return peer, nil
+	}
+
+	for _, header := range headers {
+		hops := forwardedForHops(r.Header.Values(header))
+		if len(hops) == 0 {
+			continue
+		}
+		_, err := netip.ParseAddr(hops[len(hops)-1])
+		if err != nil {
+			return netip.Addr{}, fmt.Errorf("invalid client ip in header %s %w", header, ErrorBadRequest)
+		}
+		return walkForwardedChain(peer, hops, trusted), nil
+	}
+
+	return netip.Addr{}, fmt.Errorf("client ip header not found %w", ErrorBadRequest)
+}
+
+// walkForwardedChain returns the first untrusted address walking the hops from right to left,
+// starting from the given last hop.
+func walkForwardedChain(last netip.Addr, forwardedFor []string, trusted TrustedProxies) netip.Addr {
+	client := last
+	if !trusted.Contains(client) {
+		return client
+	}
+	hops := forwardedForHops(forwardedFor)
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(hops[i])
+		if err != nil {
+			// a malformed hop can't be trusted nor used, the last valid one is the client
+			return client
+		}
+		client = hop.Unmap()
+		if !trusted.Contains(client) {
+			return client
+		}
+	}
+
+	return client
+}
+
+func parseRemoteAddr(remoteAddr string) (netip.Addr, error) {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("invalid remote address %s %w", remoteAddr, ErrorBadRequest)
+	}
+
+	return addr.Unmap(), nil
+}
+
+func forwardedForHops(headerValues []string) []string {
+	hops := make([]string, 0)
+	for _, value := range headerValues {
+		for _, hop := range strings.Split(value, ",") {
+			hop = strings.TrimSpace(hop)
+			if hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	return hops
+}
@@ -12,6 +12,8 @@ var ParamNotFoundError = fmt.Errorf("requested parameter was not found %w", Erro
 
 var ErrorNotFound = errors.New("not found")
 var ErrorBadRequest = errors.New("bad request")
+var ErrorForbidden = errors.New("forbidden")
+var ErrorConflict = errors.New("conflict")
 var ErrorInternalServer = errors.New("internal server error")
 
 func HandlerErrorResponse(w http.ResponseWriter, err error) {
@@ -22,6 +24,12 @@ func HandlerErrorResponse(w http.ResponseWriter, err error) {
 	case errors.Is(err, ErrorBadRequest):
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
+	case errors.Is(err, ErrorForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	case errors.Is(err, ErrorConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
 	case errors.Is(err, ErrorInternalServer):
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
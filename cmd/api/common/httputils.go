@@ -1,8 +1,11 @@
 package common
 
 import (
+	"errors"
 	"github.com/gorilla/mux"
+	"log"
 	"net/http"
+	"time"
 )
 
 // GetParamFromRequest returns the requested URL param in a string format.
@@ -16,3 +19,16 @@ func GetParamFromRequest(r *http.Request, paramName string) (string, error) {
 
 	return param, nil
 }
+
+// ExtendDeadlines lets the handler read the request body and write the response for timeout from now, over the
+// ReadTimeout and WriteTimeout of the server. it's meant for the routes that upload, download or walk the whole
+// dataset, the writers that can't set deadlines (as the test recorders) have no timeouts to extend
+func ExtendDeadlines(w http.ResponseWriter, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	controller := http.NewResponseController(w)
+	for _, err := range []error{controller.SetReadDeadline(deadline), controller.SetWriteDeadline(deadline)} {
+		if err != nil && !errors.Is(err, http.ErrNotSupported) {
+			log.Printf("error extending the request deadlines: %s", err.Error())
+		}
+	}
+}
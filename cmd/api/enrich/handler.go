@@ -0,0 +1,143 @@
+package enrich
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultIpColumn = "ip"
+	// maxUploadBytes is the max size of an uploaded csv
+	maxUploadBytes  = 1 << 30
+	uploadFormField = "file"
+	// transferTimeout replaces the server timeouts for the uploads and the result downloads, 1 GiB at 1 MB/s
+	transferTimeout = 20 * time.Minute
+)
+
+type Handler interface {
+	// SubmitJob accepts a csv upload and answers 202 with the queued job
+	SubmitJob(w http.ResponseWriter, r *http.Request)
+	// GetJob returns the status of a job
+	GetJob(w http.ResponseWriter, r *http.Request)
+	// GetJobResult downloads the enriched csv of a done job
+	GetJobResult(w http.ResponseWriter, r *http.Request)
+}
+
+type handler struct {
+	manager Manager
+}
+
+func NewHandler(manager Manager) Handler {
+	return handler{manager: manager}
+}
+
+// SubmitJob reads the csv from the file field of a multipart form or from the body when it is sent as text/csv.
+// ip_column is an optional query param with the header of the ip column, ip by default
+func (h handler) SubmitJob(w http.ResponseWriter, r *http.Request) {
+	ipColumn := r.URL.Query().Get("ip_column")
+	if ipColumn == "" {
+		ipColumn = defaultIpColumn
+	}
+
+	common.ExtendDeadlines(w, transferTimeout)
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadBytes)
+	src, err := uploadedCSV(r)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	job, err := h.manager.Submit(src, ipColumn)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	response, err := json.Marshal(job)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/ipdata/jobs/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	w.Write(response)
+}
+
+func (h handler) GetJob(w http.ResponseWriter, r *http.Request) {
+	id, err := common.GetParamFromRequest(r, "id")
+	if err != nil {
+		err = fmt.Errorf("param: id %w", err)
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	job, err := h.manager.Get(id)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	response, err := json.Marshal(job)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	w.Write(response)
+}
+
+// GetJobResult answers 409 while the job is not done
+func (h handler) GetJobResult(w http.ResponseWriter, r *http.Request) {
+	id, err := common.GetParamFromRequest(r, "id")
+	if err != nil {
+		err = fmt.Errorf("param: id %w", err)
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	result, err := h.manager.OpenResult(id)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+	defer result.Close()
+
+	common.ExtendDeadlines(w, transferTimeout)
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", id+".csv"))
+	_, err = io.Copy(w, result)
+	if err != nil {
+		// the status is already sent, the client gets a truncated csv
+		log.Printf("error sending the result of enrich job %s: %s", id, err.Error())
+	}
+}
+
+// uploadedCSV returns the file part of a multipart form, or the body for any other content type.
+// the part is streamed so the upload is never held in memory
+func uploadedCSV(r *http.Request) (io.Reader, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return r.Body, nil
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, fmt.Errorf("invalid multipart form. %s %w", err.Error(), common.ErrorBadRequest)
+	}
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			return nil, fmt.Errorf("form field %s not found %w", uploadFormField, common.ErrorBadRequest)
+		}
+		if part.FormName() == uploadFormField {
+			return part, nil
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package enrich
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"bytes"
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler_SubmitJob(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Multipart upload", TestFn: testHandlerSubmitJobMultipart},
+		{Scenario: "Missing file field error", TestFn: testHandlerSubmitJobMissingFileError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestHandler_GetJob(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Not found error", TestFn: testHandlerGetJobNotFoundError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestHandler_GetJobResult(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Download longer than the server write timeout", TestFn: testHandlerGetJobResultOverWriteTimeout},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func testHandlerSubmitJobMultipart(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	m, err := NewManager(mockGtw, Config{Dir: t.TempDir()})
+	assert.Nil(t, err)
+	testHandler := NewHandler(m)
+
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(mockEnrichIpData, nil)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("comment", "incident 42")
+	file, _ := form.CreateFormFile("file", "ips.csv")
+	file.Write([]byte("addr\n5.181.131.180\n"))
+	form.Close()
+
+	req := httptest.NewRequest("POST", "/ipdata/jobs/enrich?ip_column=addr", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(testHandler.SubmitJob).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	job := Job{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &job))
+	assert.Equal(t, "addr", job.IpColumn)
+	assert.Equal(t, "/ipdata/jobs/"+job.ID, rr.Header().Get("Location"))
+
+	waitFinished(t, m, job.ID)
+
+	req = mux.SetURLVars(httptest.NewRequest("GET", "/ipdata/jobs/"+job.ID+"/result", nil), map[string]string{"id": job.ID})
+	rr = httptest.NewRecorder()
+	http.HandlerFunc(testHandler.GetJobResult).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/csv", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), "5.181.131.180,found,95781810,95781817,VPN,GB")
+}
+
+func testHandlerSubmitJobMissingFileError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	m, err := NewManager(mockGtw, Config{Dir: t.TempDir()})
+	assert.Nil(t, err)
+	testHandler := NewHandler(m)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("comment", "incident 42")
+	form.Close()
+
+	req := httptest.NewRequest("POST", "/ipdata/jobs/enrich", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(testHandler.SubmitJob).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "form field file not found bad request\n", rr.Body.String())
+}
+
+func testHandlerGetJobResultOverWriteTimeout(t *testing.T) {
+	testHandler := NewHandler(mockResultManager{result: "ip,status\n5.181.131.180,found\n", delay: 300 * time.Millisecond})
+	r := mux.NewRouter()
+	r.HandleFunc("/ipdata/jobs/{id}/result", testHandler.GetJobResult)
+	srv := httptest.NewUnstartedServer(r)
+	srv.Config.WriteTimeout = 100 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/ipdata/jobs/a1/result")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "ip,status\n5.181.131.180,found\n", string(body))
+}
+
+func testHandlerGetJobNotFoundError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	m, err := NewManager(mockGtw, Config{Dir: t.TempDir()})
+	assert.Nil(t, err)
+	testHandler := NewHandler(m)
+
+	req := mux.SetURLVars(httptest.NewRequest("GET", "/ipdata/jobs/missing", nil), map[string]string{"id": "missing"})
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(testHandler.GetJob).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, "job missing not found\n", rr.Body.String())
+}
+
+// mock utils
+
+// mockResultManager serves a done job whose result takes delay to be read
+type mockResultManager struct {
+	Manager
+	result string
+	delay  time.Duration
+}
+
+func (m mockResultManager) OpenResult(string) (io.ReadCloser, error) {
+	return io.NopCloser(slowReader{r: strings.NewReader(m.result), delay: m.delay}), nil
+}
+
+type slowReader struct {
+	r     io.Reader
+	delay time.Duration
+}
+
+func (s slowReader) Read(p []byte) (int, error) {
+	time.Sleep(s.delay)
+	return s.r.Read(p)
+}
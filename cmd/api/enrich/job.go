@@ -0,0 +1,51 @@
+package enrich
+
+import (
+	"time"
+)
+
+// statuses of a Job
+const (
+	StatusQueued  = "queued"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// lookup statuses of every enriched row, the special purpose addresses get their classification (ipdata.SpecialPrivate...)
+const (
+	LookupFound     = "found"
+	LookupNotFound  = "not_found"
+	LookupInvalidIP = "invalid_ip"
+	LookupError     = "error"
+)
+
+// EnrichedColumns are appended to the original columns of every row of the result
+var EnrichedColumns = []string{
+	"lookup_status",
+	"ip_from",
+	"ip_to",
+	"proxy_type",
+	"country_code",
+	"country_name",
+	"region_name",
+	"city_name",
+	"isp",
+}
+
+// Job is a CSV enrichment job, it is persisted next to its input and result files
+type Job struct {
+	ID            string    `json:"id"`
+	Status        string    `json:"status"`
+	IpColumn      string    `json:"ip_column"`
+	ProcessedRows int64     `json:"processed_rows"`
+	FoundRows     int64     `json:"found_rows"`
+	Error         string    `json:"error,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Finished returns true when the job won't change anymore
+func (j Job) Finished() bool {
+	return j.Status == StatusDone || j.Status == StatusFailed
+}
@@ -0,0 +1,363 @@
+package enrich
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultConcurrency = 8
+	// batchSize is the number of rows looked up concurrently before being written, the progress is saved after each
+	batchSize = 1000
+	// maxRunningJobs jobs are processed at the same time, the rest wait queued
+	maxRunningJobs = 1
+
+	utf8BOM = "\ufeff"
+)
+
+type Config struct {
+	// Dir is the local directory where the jobs, their input and result are persisted
+	Dir string
+	// Concurrency is the max number of lookups running at the same time for a job
+	Concurrency int
+}
+
+type Manager interface {
+	// Submit saves the csv and queues its enrichment, ipColumn is the header of the column holding the ips
+	Submit(src io.Reader, ipColumn string) (Job, error)
+	// Get returns the job with the given id
+	Get(id string) (Job, error)
+	// OpenResult returns the enriched csv of a done job
+	OpenResult(id string) (io.ReadCloser, error)
+}
+
+type manager struct {
+	gtw      ipdata.Gateway
+	store    store
+	cfg      Config
+	jobSlots chan struct{}
+
+	mu   sync.RWMutex
+	jobs map[string]Job
+}
+
+// NewManager loads the jobs persisted in cfg.Dir and resumes the ones that were not finished
+func NewManager(gtw ipdata.Gateway, cfg Config) (Manager, error) {
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = defaultConcurrency
+	}
+	jobStore, err := newStore(cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := jobStore.loadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &manager{
+		gtw:      gtw,
+		store:    jobStore,
+		cfg:      cfg,
+		jobSlots: make(chan struct{}, maxRunningJobs),
+		jobs:     make(map[string]Job, len(jobs)),
+	}
+	for _, job := range jobs {
+		m.jobs[job.ID] = job
+		if !job.Finished() {
+			// the result is written from scratch, the rows processed before the restart are looked up again
+			job.Status = StatusQueued
+			job.ProcessedRows = 0
+			job.FoundRows = 0
+			m.updateOrLog(job)
+			go m.run(job)
+		}
+	}
+
+	return m, nil
+}
+
+// Submit saves the csv and queues its enrichment, the csv must have a header with ipColumn
+func (m *manager) Submit(src io.Reader, ipColumn string) (Job, error) {
+	ipColumn = strings.TrimSpace(ipColumn)
+	if ipColumn == "" {
+		return Job{}, fmt.Errorf("ip_column can't be empty %w", common.ErrorBadRequest)
+	}
+	id, err := newJobID()
+	if err != nil {
+		return Job{}, fmt.Errorf("error creating job id. %s %w", err.Error(), common.ErrorInternalServer)
+	}
+
+	err = m.store.saveInput(id, src)
+	if err != nil {
+		m.store.remove(id)
+		return Job{}, fmt.Errorf("error saving the uploaded csv. %s %w", err.Error(), common.ErrorInternalServer)
+	}
+	err = m.checkInput(id, ipColumn)
+	if err != nil {
+		m.store.remove(id)
+		return Job{}, err
+	}
+
+	now := time.Now().UTC()
+	job := Job{ID: id, Status: StatusQueued, IpColumn: ipColumn, CreatedAt: now, UpdatedAt: now}
+	err = m.update(job)
+	if err != nil {
+		m.store.remove(id)
+		return Job{}, fmt.Errorf("error saving the job. %s %w", err.Error(), common.ErrorInternalServer)
+	}
+
+	go m.run(job)
+	return job, nil
+}
+
+// Get returns the job with the given id
+func (m *manager) Get(id string) (Job, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	job, found := m.jobs[id]
+	if !found {
+		return Job{}, fmt.Errorf("job %s %w", id, common.ErrorNotFound)
+	}
+	return job, nil
+}
+
+// OpenResult returns the enriched csv of a done job
+func (m *manager) OpenResult(id string) (io.ReadCloser, error) {
+	job, err := m.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != StatusDone {
+		return nil, fmt.Errorf("job %s is %s %w", id, job.Status, common.ErrorConflict)
+	}
+
+	file, err := os.Open(m.store.resultPath(id))
+	if err != nil {
+		return nil, fmt.Errorf("error opening job result. %s %w", err.Error(), common.ErrorInternalServer)
+	}
+	return file, nil
+}
+
+// update saves the job on disk and in memory
+func (m *manager) update(job Job) error {
+	job.UpdatedAt = time.Now().UTC()
+	m.mu.Lock()
+	m.jobs[job.ID] = job
+	m.mu.Unlock()
+	return m.store.save(job)
+}
+
+// updateOrLog updates the job from the background runs, where the error can only be logged. The job keeps its
+// last saved state on disk
+func (m *manager) updateOrLog(job Job) {
+	err := m.update(job)
+	if err != nil {
+		log.Printf("error saving enrich job %s: %s", job.ID, err.Error())
+	}
+}
+
+// checkInput verifies the header of the saved input has the ip column
+func (m *manager) checkInput(id string, ipColumn string) error {
+	file, err := os.Open(m.store.inputPath(id))
+	if err != nil {
+		return fmt.Errorf("error reading the uploaded csv. %s %w", err.Error(), common.ErrorInternalServer)
+	}
+	defer file.Close()
+
+	_, err = readHeader(newCSVReader(file), ipColumn)
+	return err
+}
+
+// run waits for a free slot and processes the job
+func (m *manager) run(job Job) {
+	m.jobSlots <- struct{}{}
+	defer func() { <-m.jobSlots }()
+
+	job.Status = StatusRunning
+	m.updateOrLog(job)
+
+	err := writeFileAtomic(m.store.resultPath(job.ID), func(w io.Writer) error {
+		return m.process(&job, w)
+	})
+	if err != nil {
+		log.Printf("enrich job %s failed: %s", job.ID, err.Error())
+		job.Status = StatusFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = StatusDone
+	}
+	m.updateOrLog(job)
+}
+
+// process writes the enriched rows of the job input to w, saving the progress after every batch
+func (m *manager) process(job *Job, w io.Writer) error {
+	file, err := os.Open(m.store.inputPath(job.ID))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := newCSVReader(file)
+	header, err := readHeader(reader, job.IpColumn)
+	if err != nil {
+		return err
+	}
+	ipIndex := indexOf(header, job.IpColumn)
+
+	writer := csv.NewWriter(w)
+	err = writer.Write(append(header, EnrichedColumns...))
+	if err != nil {
+		return err
+	}
+
+	for {
+		batch, readErr := readBatch(reader)
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return readErr
+		}
+
+		rows := m.enrichBatch(batch, ipIndex)
+		for _, row := range rows {
+			if row[len(row)-len(EnrichedColumns)] == LookupFound {
+				job.FoundRows++
+			}
+		}
+		err = writer.WriteAll(rows)
+		if err != nil {
+			return err
+		}
+		job.ProcessedRows += int64(len(rows))
+		m.updateOrLog(*job)
+
+		if errors.Is(readErr, io.EOF) {
+			return nil
+		}
+	}
+}
+
+// enrichBatch looks up the ips of the batch with at most cfg.Concurrency lookups at the same time,
+// the rows keep their order
+func (m *manager) enrichBatch(batch [][]string, ipIndex int) [][]string {
+	rows := make([][]string, len(batch))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < m.cfg.Concurrency; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				ip := ""
+				if ipIndex < len(batch[i]) {
+					ip = batch[i][ipIndex]
+				}
+				rows[i] = append(batch[i], m.lookup(ip)...)
+			}
+		}()
+	}
+	for i := range batch {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return rows
+}
+
+// lookup returns the EnrichedColumns values of the given ip
+func (m *manager) lookup(ip string) []string {
+	addr, err := ipdata.ParseIP(strings.TrimSpace(ip))
+	if err != nil || !addr.Is4() {
+		return enrichedValues(LookupInvalidIP, ipdata.IpData{})
+	}
+
+	data, err := m.gtw.GetDataFromIP(context.Background(), addr)
+	if err != nil {
+		if errors.Is(err, common.ErrorNotFound) {
+			return enrichedValues(LookupNotFound, ipdata.IpData{})
+		}
+		return enrichedValues(LookupError, ipdata.IpData{})
+	}
+	if data.SpecialPurpose != nil {
+		// private, cgnat, reserved...
+		return enrichedValues(data.SpecialPurpose.Classification, ipdata.IpData{})
+	}
+	return enrichedValues(LookupFound, data)
+}
+
+func enrichedValues(status string, data ipdata.IpData) []string {
+	values := []string{status, "", "", data.ProxyType, data.CountryCode, data.CountryName, data.RegionName, data.CityName, data.ISP}
+	if status == LookupFound {
+		values[1] = strconv.FormatInt(data.IpFrom, 10)
+		values[2] = strconv.FormatInt(data.IpTo, 10)
+	}
+	return values
+}
+
+// newCSVReader is the reader of the uploaded csv, shared by checkInput and process so a file accepted on submit
+// is read the same way when processed. the rows may have any number of fields
+func newCSVReader(r io.Reader) *csv.Reader {
+	reader := csv.NewReader(bufio.NewReader(r))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	return reader
+}
+
+// readHeader reads the first record and checks it has the ip column, an utf8 BOM is removed
+func readHeader(reader *csv.Reader, ipColumn string) ([]string, error) {
+	header, err := reader.Read()
+	if err != nil {
+		return []string{}, fmt.Errorf("invalid csv header. %s %w", err.Error(), common.ErrorBadRequest)
+	}
+	header[0] = strings.TrimPrefix(header[0], utf8BOM)
+	if indexOf(header, ipColumn) < 0 {
+		return []string{}, fmt.Errorf("ip_column %s not found in the csv header %w", ipColumn, common.ErrorBadRequest)
+	}
+	return header, nil
+}
+
+// readBatch reads up to batchSize records, returns io.EOF with the last records
+func readBatch(reader *csv.Reader) ([][]string, error) {
+	batch := make([][]string, 0, batchSize)
+	for len(batch) < batchSize {
+		record, err := reader.Read()
+		if err != nil {
+			return batch, err
+		}
+		batch = append(batch, record)
+	}
+	return batch, nil
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+func newJobID() (string, error) {
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
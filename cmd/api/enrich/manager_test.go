@@ -0,0 +1,174 @@
+package enrich
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"errors"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/netip"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestManager_Submit(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testManagerSubmitNoError},
+		{Scenario: "Missing ip column error", TestFn: testManagerSubmitMissingIpColumnError},
+		{Scenario: "Result not ready error", TestFn: testManagerSubmitResultNotReadyError},
+		{Scenario: "Header with a bare quote accepted", TestFn: testManagerSubmitBareQuoteHeader},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestManager_Resume(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Unfinished job resumed", TestFn: testManagerResumeUnfinishedJob},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func testManagerSubmitNoError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	m, err := NewManager(mockGtw, Config{Dir: t.TempDir(), Concurrency: 2})
+	assert.Nil(t, err)
+
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(mockEnrichIpData, nil)
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("1.1.1.1")).Return(ipdata.IpData{}, fmt.Errorf("error getting Ips Ip count.  %w", common.ErrorNotFound))
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("10.0.0.1")).
+		Return(ipdata.IpData{IpString: "10.0.0.1", SpecialPurpose: &ipdata.SpecialPurpose{Classification: ipdata.SpecialPrivate}}, nil)
+
+	input := "\ufeffcase,source_ip\n" +
+		"1,5.181.131.180\n" +
+		"2,1.1.1.1\n" +
+		"3,not an ip\n" +
+		"4,10.0.0.1\n"
+	job, err := m.Submit(strings.NewReader(input), "source_ip")
+	assert.Nil(t, err)
+	assert.Equal(t, StatusQueued, job.Status)
+
+	job = waitFinished(t, m, job.ID)
+	assert.Equal(t, StatusDone, job.Status)
+	assert.Equal(t, int64(4), job.ProcessedRows)
+	assert.Equal(t, int64(1), job.FoundRows)
+
+	result, err := m.OpenResult(job.ID)
+	assert.Nil(t, err)
+	defer result.Close()
+	output, _ := io.ReadAll(result)
+	assert.Equal(t, "case,source_ip,lookup_status,ip_from,ip_to,proxy_type,country_code,country_name,region_name,city_name,isp\n"+
+		"1,5.181.131.180,found,95781810,95781817,VPN,GB,United Kingdom of Great Britain and Northern Ireland,England,London,IPXO Limited\n"+
+		"2,1.1.1.1,not_found,,,,,,,,\n"+
+		"3,not an ip,invalid_ip,,,,,,,,\n"+
+		"4,10.0.0.1,private,,,,,,,,\n", string(output))
+}
+
+func testManagerSubmitMissingIpColumnError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	dir := t.TempDir()
+	m, err := NewManager(mockGtw, Config{Dir: dir})
+	assert.Nil(t, err)
+
+	job, err := m.Submit(strings.NewReader("case,source_ip\n1,5.181.131.180\n"), "ip")
+
+	assert.Equal(t, Job{}, job)
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+	files, _ := os.ReadDir(dir)
+	assert.Empty(t, files)
+}
+
+func testManagerSubmitResultNotReadyError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	dir := t.TempDir()
+	m, err := NewManager(mockGtw, Config{Dir: dir})
+	assert.Nil(t, err)
+
+	jobStore, _ := newStore(dir)
+	jobStore.save(Job{ID: "queued", Status: StatusQueued})
+	m.(*manager).jobs["queued"] = Job{ID: "queued", Status: StatusQueued}
+
+	result, err := m.OpenResult("queued")
+
+	assert.Nil(t, result)
+	assert.True(t, errors.Is(err, common.ErrorConflict))
+}
+
+func testManagerSubmitBareQuoteHeader(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	m, err := NewManager(mockGtw, Config{Dir: t.TempDir()})
+	assert.Nil(t, err)
+
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("1.1.1.1")).Return(ipdata.IpData{}, fmt.Errorf("error getting Ips Ip count.  %w", common.ErrorNotFound))
+
+	job, err := m.Submit(strings.NewReader("ip,the \"note\"\n1.1.1.1,a\n"), "ip")
+	assert.Nil(t, err)
+
+	job = waitFinished(t, m, job.ID)
+	assert.Equal(t, StatusDone, job.Status)
+	assert.Equal(t, int64(1), job.ProcessedRows)
+}
+
+func testManagerResumeUnfinishedJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	dir := t.TempDir()
+
+	// a job left running by a previous process
+	jobStore, _ := newStore(dir)
+	jobStore.saveInput("restarted", strings.NewReader("ip\n5.181.131.180\n"))
+	jobStore.save(Job{ID: "restarted", Status: StatusRunning, IpColumn: "ip", ProcessedRows: 1})
+
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(mockEnrichIpData, nil)
+
+	m, err := NewManager(mockGtw, Config{Dir: dir})
+	assert.Nil(t, err)
+
+	job := waitFinished(t, m, "restarted")
+	assert.Equal(t, StatusDone, job.Status)
+	assert.Equal(t, int64(1), job.ProcessedRows)
+	assert.Equal(t, int64(1), job.FoundRows)
+}
+
+// mock utils
+
+var mockEnrichIpData = ipdata.IpData{
+	IpFrom:      95781810,
+	IpTo:        95781817,
+	ProxyType:   "VPN",
+	CountryCode: "GB",
+	CountryName: "United Kingdom of Great Britain and Northern Ireland",
+	RegionName:  "England",
+	CityName:    "London",
+	ISP:         "IPXO Limited",
+	IpString:    "5.181.131.180",
+}
+
+func waitFinished(t *testing.T, m Manager, id string) Job {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := m.Get(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Finished() {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %s not finished", id)
+	return Job{}
+}
@@ -0,0 +1,110 @@
+package enrich
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	jobFileSuffix    = ".json"
+	inputFileSuffix  = ".input.csv"
+	resultFileSuffix = ".result.csv"
+	tmpFileSuffix    = ".tmp"
+)
+
+// store keeps every job in dir as <id>.json, <id>.input.csv and <id>.result.csv
+type store struct {
+	dir string
+}
+
+func newStore(dir string) (store, error) {
+	err := os.MkdirAll(dir, 0o750)
+	if err != nil {
+		return store{}, fmt.Errorf("error creating jobs dir %s. %w", dir, err)
+	}
+	return store{dir: dir}, nil
+}
+
+func (s store) jobPath(id string) string {
+	return filepath.Join(s.dir, id+jobFileSuffix)
+}
+
+func (s store) inputPath(id string) string {
+	return filepath.Join(s.dir, id+inputFileSuffix)
+}
+
+func (s store) resultPath(id string) string {
+	return filepath.Join(s.dir, id+resultFileSuffix)
+}
+
+// save writes the job replacing the previous version atomically
+func (s store) save(job Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.jobPath(job.ID), func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// saveInput copies src into the input file of the job
+func (s store) saveInput(id string, src io.Reader) error {
+	return writeFileAtomic(s.inputPath(id), func(w io.Writer) error {
+		_, err := io.Copy(w, src)
+		return err
+	})
+}
+
+// remove deletes every file of the job
+func (s store) remove(id string) {
+	for _, path := range []string{s.jobPath(id), s.inputPath(id), s.resultPath(id), s.resultPath(id) + tmpFileSuffix} {
+		os.Remove(path)
+	}
+}
+
+// loadAll reads every persisted job
+func (s store) loadAll() ([]Job, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*"+jobFileSuffix))
+	if err != nil {
+		return []Job{}, err
+	}
+
+	jobs := make([]Job, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return []Job{}, err
+		}
+		job := Job{}
+		err = json.Unmarshal(data, &job)
+		if err != nil {
+			return []Job{}, fmt.Errorf("error reading job %s. %w", strings.TrimSuffix(filepath.Base(path), jobFileSuffix), err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// writeFileAtomic writes path through a temporary file so readers never see it half written
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	tmpPath := path + tmpFileSuffix
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	err = write(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
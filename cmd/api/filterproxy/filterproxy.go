@@ -0,0 +1,69 @@
+package filterproxy
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"DreamLabChallenge/pkg/proxydetect"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+)
+
+type Config struct {
+	// Upstream is the url where the allowed requests are forwarded
+	Upstream *url.URL
+	// Policy decides which clients are rejected
+	Policy ipdata.Policy
+	// TrustedProxies are the peers allowed to set the client ip through X-Forwarded-For
+	TrustedProxies common.TrustedProxies
+	// ClientIPHeader is the header where the trusted proxies write the client ip, X-Forwarded-For when empty
+	ClientIPHeader string
+	// FailOpen forwards the request when the lookup fails instead of answering with an error
+	FailOpen bool
+}
+
+type filterProxy struct {
+	gtw          ipdata.Gateway
+	cfg          Config
+	reverseProxy *httputil.ReverseProxy
+}
+
+// NewFilterProxy returns a reverse proxy that looks up the client ip, applies the configured policy and
+// forwards the allowed requests to the upstream with the ipdata enrichment headers.
+func NewFilterProxy(gtw ipdata.Gateway, cfg Config) http.Handler {
+	return filterProxy{
+		gtw:          gtw,
+		cfg:          cfg,
+		reverseProxy: httputil.NewSingleHostReverseProxy(cfg.Upstream),
+	}
+}
+
+func (f filterProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	clientIP, err := common.GetClientIP(r, f.cfg.TrustedProxies, f.cfg.ClientIPHeader)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	ipData, err := proxydetect.Lookup(ctx, f.gtw, clientIP)
+	if err != nil {
+		if !f.cfg.FailOpen {
+			common.HandlerErrorResponse(w, err)
+			return
+		}
+		ipData = ipdata.IpData{}
+	}
+
+	allowed, reason := f.cfg.Policy.Evaluate(ipData)
+	if !allowed {
+		common.HandlerErrorResponse(w, fmt.Errorf("%s %w", reason, common.ErrorForbidden))
+		return
+	}
+
+	outReq := r.Clone(ctx)
+	ipdata.SetEnrichmentHeaders(outReq.Header, ipData)
+	f.reverseProxy.ServeHTTP(w, outReq)
+}
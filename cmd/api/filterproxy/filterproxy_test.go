@@ -0,0 +1,203 @@
+package filterproxy
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+)
+
+func TestFilterProxy_ServeHTTP(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Allowed proxy forwarded with enrichment headers", TestFn: testFilterProxyAllowedEnriched},
+		{Scenario: "Ip not in dataset forwarded without enrichment", TestFn: testFilterProxyNotFoundForwarded},
+		{Scenario: "Blocked proxy type rejected", TestFn: testFilterProxyBlockedProxyType},
+		{Scenario: "Blocked country rejected", TestFn: testFilterProxyBlockedCountry},
+		{Scenario: "Spoofed enrichment headers removed", TestFn: testFilterProxySpoofedHeadersRemoved},
+		{Scenario: "X-Forwarded-For from trusted proxy used", TestFn: testFilterProxyTrustedForwardedFor},
+		{Scenario: "X-Forwarded-For from untrusted peer ignored", TestFn: testFilterProxyUntrustedForwardedFor},
+		{Scenario: "Gateway error fails closed", TestFn: testFilterProxyGtwErrorFailClosed},
+		{Scenario: "Gateway error fails open", TestFn: testFilterProxyGtwErrorFailOpen},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func testFilterProxyAllowedEnriched(t *testing.T) {
+	upstream, received := newTestUpstream(t)
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	proxy := NewFilterProxy(mockGtw, Config{Upstream: upstream, Policy: ipdata.NewPolicy([]string{"TOR"}, nil)})
+
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(mockProxyData, nil)
+
+	rr := serveTestRequest(proxy, "5.181.131.180:5555", nil)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "upstream", rr.Body.String())
+	assert.Equal(t, "VPN", received.Get(ipdata.HeaderProxyType))
+	assert.Equal(t, "GB", received.Get(ipdata.HeaderCountryCode))
+	assert.Equal(t, "IPXO Limited", received.Get(ipdata.HeaderISP))
+}
+
+func testFilterProxyNotFoundForwarded(t *testing.T) {
+	upstream, received := newTestUpstream(t)
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	proxy := NewFilterProxy(mockGtw, Config{Upstream: upstream, Policy: ipdata.NewPolicy([]string{"VPN"}, nil)})
+
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(ipdata.IpData{}, common.ErrorNotFound)
+
+	rr := serveTestRequest(proxy, "5.181.131.180:5555", nil)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "", received.Get(ipdata.HeaderProxyType))
+}
+
+func testFilterProxyBlockedProxyType(t *testing.T) {
+	upstream, _ := newTestUpstream(t)
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	proxy := NewFilterProxy(mockGtw, Config{Upstream: upstream, Policy: ipdata.NewPolicy([]string{"vpn"}, nil)})
+
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(mockProxyData, nil)
+
+	rr := serveTestRequest(proxy, "5.181.131.180:5555", nil)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Equal(t, "proxy_type VPN is blocked forbidden\n", rr.Body.String())
+}
+
+func testFilterProxyBlockedCountry(t *testing.T) {
+	upstream, _ := newTestUpstream(t)
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	proxy := NewFilterProxy(mockGtw, Config{Upstream: upstream, Policy: ipdata.NewPolicy(nil, []string{"GB"})})
+
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(mockProxyData, nil)
+
+	rr := serveTestRequest(proxy, "5.181.131.180:5555", nil)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Equal(t, "country_code GB is blocked forbidden\n", rr.Body.String())
+}
+
+func testFilterProxySpoofedHeadersRemoved(t *testing.T) {
+	upstream, received := newTestUpstream(t)
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	proxy := NewFilterProxy(mockGtw, Config{Upstream: upstream})
+
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(ipdata.IpData{}, common.ErrorNotFound)
+
+	rr := serveTestRequest(proxy, "5.181.131.180:5555", map[string]string{ipdata.HeaderProxyType: "RES", ipdata.HeaderISP: "spoofed"})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "", received.Get(ipdata.HeaderProxyType))
+	assert.Equal(t, "", received.Get(ipdata.HeaderISP))
+}
+
+func testFilterProxyTrustedForwardedFor(t *testing.T) {
+	upstream, _ := newTestUpstream(t)
+	trusted, _ := common.ParseTrustedProxies([]string{"10.0.0.0/8"})
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	proxy := NewFilterProxy(mockGtw, Config{Upstream: upstream, TrustedProxies: trusted})
+
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(mockProxyData, nil)
+
+	rr := serveTestRequest(proxy, "10.0.0.2:5555", map[string]string{"X-Forwarded-For": "1.1.1.1, 5.181.131.180, 10.0.0.7"})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func testFilterProxyUntrustedForwardedFor(t *testing.T) {
+	upstream, _ := newTestUpstream(t)
+	trusted, _ := common.ParseTrustedProxies([]string{"10.0.0.0/8"})
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	proxy := NewFilterProxy(mockGtw, Config{Upstream: upstream, TrustedProxies: trusted})
+
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("8.8.8.8")).Return(ipdata.IpData{}, common.ErrorNotFound)
+
+	rr := serveTestRequest(proxy, "8.8.8.8:5555", map[string]string{"X-Forwarded-For": "5.181.131.180"})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func testFilterProxyGtwErrorFailClosed(t *testing.T) {
+	upstream, _ := newTestUpstream(t)
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	proxy := NewFilterProxy(mockGtw, Config{Upstream: upstream})
+
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(ipdata.IpData{}, common.ErrorInternalServer)
+
+	rr := serveTestRequest(proxy, "5.181.131.180:5555", nil)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
+func testFilterProxyGtwErrorFailOpen(t *testing.T) {
+	upstream, received := newTestUpstream(t)
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	proxy := NewFilterProxy(mockGtw, Config{Upstream: upstream, FailOpen: true})
+
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(ipdata.IpData{}, errors.New("connection error"))
+
+	rr := serveTestRequest(proxy, "5.181.131.180:5555", map[string]string{ipdata.HeaderProxyType: "RES"})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "", received.Get(ipdata.HeaderProxyType))
+}
+
+// mock utils
+
+var mockProxyData = ipdata.IpData{
+	IpFrom:      95781810,
+	IpTo:        95781817,
+	ProxyType:   "VPN",
+	CountryCode: "GB",
+	CountryName: "United Kingdom of Great Britain and Northern Ireland",
+	ISP:         "IPXO Limited",
+	IpString:    "5.181.131.180",
+}
+
+// newTestUpstream starts an upstream server that stores the headers of the last received request
+func newTestUpstream(t *testing.T) (*url.URL, http.Header) {
+	received := http.Header{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for key, values := range r.Header {
+			received[key] = values
+		}
+		w.Write([]byte("upstream"))
+	}))
+	t.Cleanup(server.Close)
+
+	upstream, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return upstream, received
+}
+
+func serveTestRequest(proxy http.Handler, remoteAddr string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/some/path", nil)
+	req.RemoteAddr = remoteAddr
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	rr := httptest.NewRecorder()
+	proxy.ServeHTTP(rr, req)
+
+	return rr
+}
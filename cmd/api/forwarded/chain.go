@@ -0,0 +1,328 @@
+package forwarded
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// sources of a hop
+const (
+	SourceForwarded     = "forwarded"
+	SourceXForwardedFor = "x_forwarded_for"
+	SourceRemoteAddr    = "remote_addr"
+)
+
+// statuses of a hop, found, not_found and skipped are the ones of ipdata.ExtractedIP
+const (
+	StatusInvalid    = "invalid"
+	StatusObfuscated = "obfuscated"
+	StatusUnknown    = "unknown"
+	StatusPseudonym  = "pseudonym"
+
+	// maxHops is the max number of hops of a chain
+	maxHops = 100
+)
+
+// Headers are the raw values of the headers of a request, RemoteAddr is the peer that sent it (optional)
+type Headers struct {
+	XForwardedFor string `json:"x_forwarded_for"`
+	Forwarded     string `json:"forwarded"`
+	Via           string `json:"via"`
+	RemoteAddr    string `json:"remote_addr"`
+}
+
+// Lookup is the data of a hop address, or the reason why it was not looked up
+type Lookup struct {
+	Status         string                 `json:"status"`
+	Reason         string                 `json:"reason,omitempty"`
+	SpecialPurpose *ipdata.SpecialPurpose `json:"special_purpose,omitempty"`
+	Data           *ipdata.IpData         `json:"data,omitempty"`
+}
+
+// Hop is a node of the forwarded chain, the first one is the claimed origin and the last one the closest to us
+type Hop struct {
+	Index  int    `json:"index"`
+	Node   string `json:"node"`
+	Ip     string `json:"ip,omitempty"`
+	Source string `json:"source"`
+	By     string `json:"by,omitempty"`
+	Proto  string `json:"proto,omitempty"`
+	Host   string `json:"host,omitempty"`
+	// Private is true for the special purpose addresses (private, loopback, cgnat...)
+	Private bool `json:"private"`
+	Trusted bool `json:"trusted"`
+	// Spoofable is true for the hops set by an untrusted party, they can't be relied on
+	Spoofable bool `json:"spoofable"`
+	Lookup
+}
+
+// ViaHop is a proxy of the Via header, in the order the request went through them
+type ViaHop struct {
+	Protocol   string `json:"protocol"`
+	ReceivedBy string `json:"received_by"`
+	Comment    string `json:"comment,omitempty"`
+	Ip         string `json:"ip,omitempty"`
+	Trusted    bool   `json:"trusted"`
+	Lookup
+}
+
+// Analysis is the assessment of every hop of a forwarded chain
+type Analysis struct {
+	// ChainSource is the header the chain was taken from, Forwarded is preferred over X-Forwarded-For
+	ChainSource string `json:"chain_source,omitempty"`
+	Hops        []Hop  `json:"hops"`
+	// ClientIndex is the index of the hop most likely to be the real client, -1 if there is none
+	ClientIndex int      `json:"client_index"`
+	Client      string   `json:"client,omitempty"`
+	Via         []ViaHop `json:"via"`
+	Warnings    []string `json:"warnings"`
+}
+
+// Analyze parses the chain of the headers, looks up every public hop and finds the real client walking the
+// chain from right to left skipping the trusted proxies, as common.GetClientIP does. Without RemoteAddr the
+// last hop of the chain is taken as written by a trusted edge proxy
+func Analyze(ctx context.Context, gtw ipdata.Gateway, headers Headers, trusted common.TrustedProxies) (Analysis, error) {
+	analysis := Analysis{Hops: []Hop{}, ClientIndex: -1, Via: []ViaHop{}, Warnings: []string{}}
+	forwardedHops := forwardedChain(headers.Forwarded)
+	xForwardedForHops := xForwardedForChain(headers.XForwardedFor)
+	via, err := parseVia(headers.Via)
+	if err != nil {
+		return Analysis{}, err
+	}
+	if len(forwardedHops) == 0 && len(xForwardedForHops) == 0 && len(via) == 0 {
+		return Analysis{}, fmt.Errorf("x_forwarded_for, forwarded or via is required %w", common.ErrorBadRequest)
+	}
+
+	switch {
+	case len(forwardedHops) > 0:
+		analysis.ChainSource = SourceForwarded
+		analysis.Hops = forwardedHops
+		if len(xForwardedForHops) > 0 && !sameNodes(forwardedHops, xForwardedForHops) {
+			analysis.Warnings = append(analysis.Warnings, "forwarded and x_forwarded_for disagree, forwarded is used")
+		}
+	case len(xForwardedForHops) > 0:
+		analysis.ChainSource = SourceXForwardedFor
+		analysis.Hops = xForwardedForHops
+	}
+	if headers.RemoteAddr != "" {
+		remoteAddr := newHop(common.StripNodePort(strings.TrimSpace(headers.RemoteAddr)), SourceRemoteAddr)
+		if remoteAddr.Status == StatusInvalid {
+			return Analysis{}, fmt.Errorf("invalid remote_addr %s %w", headers.RemoteAddr, common.ErrorBadRequest)
+		}
+		analysis.Hops = append(analysis.Hops, remoteAddr)
+	}
+	if len(analysis.Hops) > maxHops || len(via) > maxHops {
+		return Analysis{}, fmt.Errorf("the chain has more than %d hops %w", maxHops, common.ErrorBadRequest)
+	}
+
+	cache := make(map[netip.Addr]Lookup)
+	for i := range analysis.Hops {
+		hop := &analysis.Hops[i]
+		hop.Index = i
+		if hop.Ip == "" {
+			continue
+		}
+		addr := netip.MustParseAddr(hop.Ip)
+		hop.Trusted = trusted.Contains(addr)
+		hop.Lookup, err = lookup(ctx, gtw, cache, addr)
+		if err != nil {
+			return Analysis{}, err
+		}
+		hop.Private = hop.SpecialPurpose != nil
+	}
+	for i := range via {
+		if via[i].Ip == "" {
+			continue
+		}
+		addr := netip.MustParseAddr(via[i].Ip)
+		via[i].Trusted = trusted.Contains(addr)
+		via[i].Lookup, err = lookup(ctx, gtw, cache, addr)
+		if err != nil {
+			return Analysis{}, err
+		}
+	}
+	analysis.Via = via
+
+	analysis.findClient(headers.RemoteAddr != "", len(trusted) > 0)
+	return analysis, nil
+}
+
+// findClient walks the hops from right to left, the first untrusted one is the client and the ones on its left
+// are spoofable. a hop without a valid ip stops the walk, the last valid one is the client
+func (a *Analysis) findClient(hasRemoteAddr bool, hasTrusted bool) {
+	for i := len(a.Hops) - 1; i >= 0; i-- {
+		if a.Hops[i].Ip == "" {
+			break
+		}
+		a.ClientIndex = i
+		if !a.Hops[i].Trusted {
+			break
+		}
+	}
+	for i := 0; i < len(a.Hops) && (a.ClientIndex < 0 || i < a.ClientIndex); i++ {
+		a.Hops[i].Spoofable = true
+	}
+
+	if len(a.Hops) == 0 {
+		return
+	}
+	last := a.Hops[len(a.Hops)-1]
+	switch {
+	case a.ClientIndex < 0:
+		a.Warnings = append(a.Warnings, fmt.Sprintf("the last hop %s is not an ip, the client can't be found", last.Node))
+		return
+	case hasRemoteAddr && !last.Trusted && len(a.Hops) > 1:
+		a.Warnings = append(a.Warnings, "remote_addr is not a trusted proxy, the forwarded headers were set by the client")
+	case !hasTrusted && len(a.Hops) > 1:
+		a.Warnings = append(a.Warnings, "no trusted proxies are configured, only the last hop can be relied on")
+	}
+
+	client := a.Hops[a.ClientIndex]
+	a.Client = client.Ip
+	if client.Private {
+		a.Warnings = append(a.Warnings, fmt.Sprintf("the client %s is a %s address, a proxy may be missing from the trusted proxies", client.Ip, client.Reason))
+	}
+}
+
+func forwardedChain(value string) []Hop {
+	hops := make([]Hop, 0)
+	if strings.TrimSpace(value) == "" {
+		return hops
+	}
+	for _, element := range common.ParseForwarded([]string{value}) {
+		hop := newHop(common.StripNodePort(element.For), SourceForwarded)
+		hop.By = element.By
+		hop.Proto = element.Proto
+		hop.Host = element.Host
+		hops = append(hops, hop)
+	}
+	return hops
+}
+
+func xForwardedForChain(value string) []Hop {
+	hops := make([]Hop, 0)
+	for _, node := range strings.Split(value, ",") {
+		node = strings.TrimSpace(node)
+		if node != "" {
+			hops = append(hops, newHop(common.StripNodePort(node), SourceXForwardedFor))
+		}
+	}
+	return hops
+}
+
+// newHop classifies the node, an empty node (an element without "for") is invalid
+func newHop(node string, source string) Hop {
+	hop := Hop{Node: node, Source: source}
+	switch {
+	case strings.EqualFold(node, "unknown"):
+		hop.Status = StatusUnknown
+	case strings.HasPrefix(node, "_"):
+		hop.Status = StatusObfuscated
+	default:
+		addr, err := netip.ParseAddr(node)
+		if err != nil {
+			hop.Status = StatusInvalid
+			break
+		}
+		hop.Ip = addr.Unmap().String()
+	}
+	return hop
+}
+
+// parseVia parses the Via (RFC 9110) header, each element is "[protocol-name/]protocol-version received-by [comment]"
+func parseVia(value string) ([]ViaHop, error) {
+	hops := make([]ViaHop, 0)
+	for _, element := range splitOutsideComments(value) {
+		element = strings.TrimSpace(element)
+		if element == "" {
+			continue
+		}
+		comment := ""
+		if start := strings.Index(element, "("); start >= 0 {
+			comment = strings.TrimSuffix(strings.TrimSpace(element[start+1:]), ")")
+			element = element[:start]
+		}
+		fields := strings.Fields(element)
+		if len(fields) != 2 {
+			return []ViaHop{}, fmt.Errorf("invalid via element %s %w", element, common.ErrorBadRequest)
+		}
+
+		hop := ViaHop{Protocol: fields[0], ReceivedBy: fields[1], Comment: comment}
+		addr, err := netip.ParseAddr(common.StripNodePort(hop.ReceivedBy))
+		if err != nil {
+			hop.Status = StatusPseudonym
+		} else {
+			hop.Ip = addr.Unmap().String()
+		}
+		hops = append(hops, hop)
+	}
+	return hops, nil
+}
+
+// splitOutsideComments splits the Via value by the commas that are not inside a comment
+func splitOutsideComments(value string) []string {
+	elements := make([]string, 0)
+	depth := 0
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				elements = append(elements, value[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(elements, value[start:])
+}
+
+// lookup returns the data of the addr, the special purpose addresses are skipped
+func lookup(ctx context.Context, gtw ipdata.Gateway, cache map[netip.Addr]Lookup, addr netip.Addr) (Lookup, error) {
+	if cached, found := cache[addr]; found {
+		return cached, nil
+	}
+
+	result := Lookup{}
+	specialPurpose, special := ipdata.LookupSpecialPurpose(addr)
+	switch {
+	case special:
+		result = Lookup{Status: ipdata.ExtractSkipped, Reason: specialPurpose.Classification, SpecialPurpose: &specialPurpose}
+	case addr.Is6():
+		result = Lookup{Status: ipdata.ExtractSkipped, Reason: ipdata.ReasonIPv6}
+	default:
+		data, err := gtw.GetDataFromIP(ctx, addr)
+		switch {
+		case errors.Is(err, common.ErrorNotFound):
+			result = Lookup{Status: ipdata.ExtractNotFound}
+		case err != nil:
+			return Lookup{}, err
+		default:
+			result = Lookup{Status: ipdata.ExtractFound, Data: &data}
+		}
+	}
+	cache[addr] = result
+	return result, nil
+}
+
+func sameNodes(a []Hop, b []Hop) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Node != b[i].Node {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,155 @@
+package forwarded
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/netip"
+	"testing"
+)
+
+func TestChain_Analyze(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Trusted proxies skipped", TestFn: testAnalyzeTrustedProxiesSkipped},
+		{Scenario: "Untrusted remote addr", TestFn: testAnalyzeUntrustedRemoteAddr},
+		{Scenario: "Forwarded preferred over x-forwarded-for", TestFn: testAnalyzeForwardedPreferred},
+		{Scenario: "Obfuscated hop stops the walk", TestFn: testAnalyzeObfuscatedHop},
+		{Scenario: "Via hops", TestFn: testAnalyzeVia},
+		{Scenario: "No headers error", TestFn: testAnalyzeNoHeadersError},
+		{Scenario: "Gateway error", TestFn: testAnalyzeGatewayError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func testAnalyzeTrustedProxiesSkipped(t *testing.T) {
+	trusted, _ := common.ParseTrustedProxies([]string{"10.0.0.0/8", "185.220.101.7"})
+	headers := Headers{XForwardedFor: "81.2.69.160, 192.168.1.4, 185.220.101.7", RemoteAddr: "10.1.2.3:51234"}
+	vpn := ipdata.IpData{ProxyType: "VPN", CountryCode: "GB", IpString: "81.2.69.160"}
+	privateUse := ipdata.SpecialPurpose{Classification: ipdata.SpecialPrivate, Name: "Private-Use", Prefix: "192.168.0.0/16", RFC: "RFC 1918"}
+	privateUse10 := ipdata.SpecialPurpose{Classification: ipdata.SpecialPrivate, Name: "Private-Use", Prefix: "10.0.0.0/8", RFC: "RFC 1918"}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("81.2.69.160")).Return(vpn, nil)
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("185.220.101.7")).Return(ipdata.IpData{}, common.ErrorNotFound)
+
+	analysis, err := Analyze(context.Background(), mockGtw, headers, trusted)
+
+	assert.Nil(t, err)
+	assert.Equal(t, SourceXForwardedFor, analysis.ChainSource)
+	assert.Equal(t, 1, analysis.ClientIndex)
+	assert.Equal(t, "192.168.1.4", analysis.Client)
+	assert.Len(t, analysis.Hops, 4)
+
+	assert.Equal(t, Hop{Index: 0, Node: "81.2.69.160", Ip: "81.2.69.160", Source: SourceXForwardedFor, Spoofable: true,
+		Lookup: Lookup{Status: ipdata.ExtractFound, Data: &vpn}}, analysis.Hops[0])
+	assert.Equal(t, Hop{Index: 1, Node: "192.168.1.4", Ip: "192.168.1.4", Source: SourceXForwardedFor, Private: true,
+		Lookup: Lookup{Status: ipdata.ExtractSkipped, Reason: ipdata.SpecialPrivate, SpecialPurpose: &privateUse}}, analysis.Hops[1])
+	assert.Equal(t, Hop{Index: 2, Node: "185.220.101.7", Ip: "185.220.101.7", Source: SourceXForwardedFor, Trusted: true,
+		Lookup: Lookup{Status: ipdata.ExtractNotFound}}, analysis.Hops[2])
+	assert.Equal(t, Hop{Index: 3, Node: "10.1.2.3", Ip: "10.1.2.3", Source: SourceRemoteAddr, Private: true, Trusted: true,
+		Lookup: Lookup{Status: ipdata.ExtractSkipped, Reason: ipdata.SpecialPrivate, SpecialPurpose: &privateUse10}}, analysis.Hops[3])
+	assert.Equal(t, []string{"the client 192.168.1.4 is a private address, a proxy may be missing from the trusted proxies"}, analysis.Warnings)
+}
+
+func testAnalyzeUntrustedRemoteAddr(t *testing.T) {
+	headers := Headers{XForwardedFor: "81.2.69.160", RemoteAddr: "185.220.101.7"}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), gomock.Any()).Return(ipdata.IpData{}, common.ErrorNotFound).Times(2)
+
+	analysis, err := Analyze(context.Background(), mockGtw, headers, common.TrustedProxies{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, analysis.ClientIndex)
+	assert.Equal(t, "185.220.101.7", analysis.Client)
+	assert.True(t, analysis.Hops[0].Spoofable)
+	assert.False(t, analysis.Hops[1].Spoofable)
+	assert.Equal(t, []string{"remote_addr is not a trusted proxy, the forwarded headers were set by the client"}, analysis.Warnings)
+}
+
+func testAnalyzeForwardedPreferred(t *testing.T) {
+	trusted, _ := common.ParseTrustedProxies([]string{"185.220.101.0/24"})
+	headers := Headers{
+		Forwarded:     `for="[2a00:1450::1]:4711";proto=https;by=185.220.101.1, for=185.220.101.2;host="example.com"`,
+		XForwardedFor: "81.2.69.160, 185.220.101.2",
+	}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("185.220.101.2")).Return(ipdata.IpData{}, common.ErrorNotFound)
+
+	analysis, err := Analyze(context.Background(), mockGtw, headers, trusted)
+
+	assert.Nil(t, err)
+	assert.Equal(t, SourceForwarded, analysis.ChainSource)
+	assert.Equal(t, "2a00:1450::1", analysis.Client)
+	assert.Equal(t, Hop{Index: 0, Node: "2a00:1450::1", Ip: "2a00:1450::1", Source: SourceForwarded, By: "185.220.101.1", Proto: "https",
+		Lookup: Lookup{Status: ipdata.ExtractSkipped, Reason: ipdata.ReasonIPv6}}, analysis.Hops[0])
+	assert.Equal(t, "example.com", analysis.Hops[1].Host)
+	assert.Equal(t, []string{"forwarded and x_forwarded_for disagree, forwarded is used"}, analysis.Warnings)
+}
+
+func testAnalyzeObfuscatedHop(t *testing.T) {
+	trusted, _ := common.ParseTrustedProxies([]string{"185.220.101.0/24"})
+	headers := Headers{Forwarded: "for=81.2.69.160, for=_hidden, for=185.220.101.2"}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), gomock.Any()).Return(ipdata.IpData{}, common.ErrorNotFound).Times(2)
+
+	analysis, err := Analyze(context.Background(), mockGtw, headers, trusted)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, analysis.ClientIndex)
+	assert.Equal(t, StatusObfuscated, analysis.Hops[1].Status)
+	assert.True(t, analysis.Hops[0].Spoofable)
+	assert.True(t, analysis.Hops[1].Spoofable)
+}
+
+func testAnalyzeVia(t *testing.T) {
+	headers := Headers{Via: "1.1 vegur, HTTP/1.1 81.2.69.160:8080 (squid/6.1, cache miss)"}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("81.2.69.160")).Return(ipdata.IpData{}, common.ErrorNotFound)
+
+	analysis, err := Analyze(context.Background(), mockGtw, headers, common.TrustedProxies{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, -1, analysis.ClientIndex)
+	assert.Equal(t, []Hop{}, analysis.Hops)
+	assert.Equal(t, []ViaHop{
+		{Protocol: "1.1", ReceivedBy: "vegur", Lookup: Lookup{Status: StatusPseudonym}},
+		{Protocol: "HTTP/1.1", ReceivedBy: "81.2.69.160:8080", Comment: "squid/6.1, cache miss", Ip: "81.2.69.160",
+			Lookup: Lookup{Status: ipdata.ExtractNotFound}},
+	}, analysis.Via)
+}
+
+func testAnalyzeNoHeadersError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+
+	_, err := Analyze(context.Background(), mockGtw, Headers{RemoteAddr: "81.2.69.160"}, common.TrustedProxies{})
+
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+}
+
+func testAnalyzeGatewayError(t *testing.T) {
+	gtwErr := errors.New("connection error")
+
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("81.2.69.160")).Return(ipdata.IpData{}, gtwErr)
+
+	_, err := Analyze(context.Background(), mockGtw, Headers{XForwardedFor: "81.2.69.160"}, common.TrustedProxies{})
+
+	assert.True(t, errors.Is(err, gtwErr))
+}
@@ -0,0 +1,54 @@
+package forwarded
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// maxBodyBytes is the max size of the headers sent to AnalyzeChain
+const maxBodyBytes = 64 << 10
+
+type Handler interface {
+	// AnalyzeChain answers the Analysis of the forwarded headers sent in the body
+	AnalyzeChain(w http.ResponseWriter, r *http.Request)
+}
+
+type handler struct {
+	gtw     ipdata.Gateway
+	trusted common.TrustedProxies
+}
+
+// NewHandler builds the forwarded Handler, trusted are the proxies skipped when looking for the client
+func NewHandler(gtw ipdata.Gateway, trusted common.TrustedProxies) Handler {
+	return handler{gtw: gtw, trusted: trusted}
+}
+
+// AnalyzeChain reads the Headers json from the body
+func (h handler) AnalyzeChain(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	headers := Headers{}
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes)).Decode(&headers)
+	if err != nil {
+		err = fmt.Errorf("invalid body. %s %w", err.Error(), common.ErrorBadRequest)
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	analysis, err := Analyze(ctx, h.gtw, headers, h.trusted)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	response, err := json.Marshal(analysis)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	w.Write(response)
+}
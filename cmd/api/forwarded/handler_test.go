@@ -0,0 +1,63 @@
+package forwarded
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestHandler_AnalyzeChain(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testHandlerAnalyzeChainNoError},
+		{Scenario: "Invalid body error", TestFn: testHandlerAnalyzeChainInvalidBodyError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func testHandlerAnalyzeChainNoError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, common.TrustedProxies{})
+
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("81.2.69.160")).Return(ipdata.IpData{}, common.ErrorNotFound)
+
+	body := `{"x_forwarded_for":"81.2.69.160"}`
+	req, err := http.NewRequest("POST", "/ipdata/forwarded", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(testHandler.AnalyzeChain).ServeHTTP(rr, req)
+
+	expected := `{"chain_source":"x_forwarded_for","hops":[{"index":0,"node":"81.2.69.160","ip":"81.2.69.160",` +
+		`"source":"x_forwarded_for","private":false,"trusted":false,"spoofable":false,"status":"not_found"}],` +
+		`"client_index":0,"client":"81.2.69.160","via":[],"warnings":[]}`
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, expected, rr.Body.String())
+}
+
+func testHandlerAnalyzeChainInvalidBodyError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, common.TrustedProxies{})
+
+	req, err := http.NewRequest("POST", "/ipdata/forwarded", strings.NewReader("X-Forwarded-For: 81.2.69.160"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(testHandler.AnalyzeChain).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -0,0 +1,38 @@
+package ipdata
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"fmt"
+	"net/netip"
+)
+
+// maxIPv4Decimal is the numeric form of 255.255.255.255
+const maxIPv4Decimal = 1<<32 - 1
+
+// ParseIP parses an ipv4 in dotted decimal (without leading zeros) or an ipv6 without zone, the ipv4 mapped
+// ipv6 addresses are unmapped. an invalid ip is a common.ErrorBadRequest
+func ParseIP(ip string) (netip.Addr, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil || addr.Zone() != "" {
+		return netip.Addr{}, fmt.Errorf("%s is not a valid ip %w", ip, common.ErrorBadRequest)
+	}
+	return addr.Unmap(), nil
+}
+
+// AddrToDecimal returns the numeric form of the ip_from and ip_to of the dataset, false if addr is not an ipv4
+func AddrToDecimal(addr netip.Addr) (int64, bool) {
+	addr = addr.Unmap()
+	if !addr.Is4() {
+		return 0, false
+	}
+	bytes := addr.As4()
+	return int64(bytes[0])<<24 | int64(bytes[1])<<16 | int64(bytes[2])<<8 | int64(bytes[3]), true
+}
+
+// DecimalToAddr returns the ipv4 of the numeric form of the dataset, false if it is out of the ipv4 space
+func DecimalToAddr(ip int64) (netip.Addr, bool) {
+	if ip < 0 || ip > maxIPv4Decimal {
+		return netip.Addr{}, false
+	}
+	return netip.AddrFrom4([4]byte{byte(ip >> 24), byte(ip >> 16), byte(ip >> 8), byte(ip)}), true
+}
@@ -0,0 +1,77 @@
+package ipdata
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/netip"
+	"testing"
+)
+
+func TestAddr_ParseIP(t *testing.T) {
+	tests := []struct {
+		ip       string
+		expected string
+	}{
+		{"5.181.131.180", "5.181.131.180"},
+		{"::ffff:5.181.131.180", "5.181.131.180"},
+		{"2001:DB8::1", "2001:db8::1"},
+		{"2001:db8:0:0:0:0:0:1", "2001:db8::1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.ip, func(t *testing.T) {
+			addr, err := ParseIP(test.ip)
+
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, addr.String())
+		})
+	}
+}
+
+func TestAddr_ParseIPError(t *testing.T) {
+	for _, ip := range []string{"", "badIP", "010.0.0.1", "1.2.3", "256.0.0.1", "1.2.3.4:80", "fe80::1%eth0", " 1.2.3.4"} {
+		t.Run(ip, func(t *testing.T) {
+			_, err := ParseIP(ip)
+
+			assert.True(t, errors.Is(err, common.ErrorBadRequest))
+		})
+	}
+}
+
+func TestAddr_AddrToDecimal(t *testing.T) {
+	tests := []struct {
+		ip       string
+		expected int64
+	}{
+		{"0.0.0.0", 0},
+		{"127.0.0.1", 2130706433},
+		{"5.181.131.178", 95781810},
+		{"::ffff:5.181.131.178", 95781810},
+		{"255.255.255.255", maxIPv4Decimal},
+	}
+
+	for _, test := range tests {
+		t.Run(test.ip, func(t *testing.T) {
+			decimal, isIPv4 := AddrToDecimal(netip.MustParseAddr(test.ip))
+
+			assert.True(t, isIPv4)
+			assert.Equal(t, test.expected, decimal)
+
+			addr, valid := DecimalToAddr(decimal)
+			assert.True(t, valid)
+			assert.Equal(t, netip.MustParseAddr(test.ip).Unmap(), addr)
+		})
+	}
+}
+
+func TestAddr_ConversionErrors(t *testing.T) {
+	_, isIPv4 := AddrToDecimal(netip.MustParseAddr("2001:db8::1"))
+	assert.False(t, isIPv4)
+
+	_, valid := DecimalToAddr(-1)
+	assert.False(t, valid)
+
+	_, valid = DecimalToAddr(maxIPv4Decimal + 1)
+	assert.False(t, valid)
+}
@@ -0,0 +1,165 @@
+package ipdata
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// formats of the exported blocklists
+const (
+	BlocklistFormatPlain    = "plain"
+	BlocklistFormatNftables = "nftables"
+	BlocklistFormatIptables = "iptables"
+	BlocklistFormatNginx    = "nginx"
+	BlocklistFormatHAProxy  = "haproxy"
+
+	DefaultBlocklistName = "ipdata_blocklist"
+	// maxBlocklistNameLength is the max length of an iptables chain name
+	maxBlocklistNameLength = 28
+	// nftablesTableName is the table holding the exported nftables sets
+	nftablesTableName = "ipdata"
+)
+
+var blocklistFormats = map[string]struct{}{
+	BlocklistFormatPlain:    {},
+	BlocklistFormatNftables: {},
+	BlocklistFormatIptables: {},
+	BlocklistFormatNginx:    {},
+	BlocklistFormatHAProxy:  {},
+}
+
+// blocklistNameRegexp keeps the name safe to be placed as is in every format
+var blocklistNameRegexp = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// BlocklistFilter selects the ranges of a blocklist, empty fields don't filter. At least one field is required
+type BlocklistFilter struct {
+	ProxyTypes   []string
+	CountryCodes []string
+	Isp          string
+}
+
+// BlocklistOptions are the rendering options of a blocklist
+type BlocklistOptions struct {
+	// Format is one of the BlocklistFormat constants
+	Format string
+	// Name is the nftables set, iptables chain or HAProxy map value of the blocklist
+	Name string
+}
+
+// validate checks the filter selects something and only uses known proxy types and country codes
+func (f BlocklistFilter) validate() error {
+	if len(f.ProxyTypes) == 0 && len(f.CountryCodes) == 0 && strings.TrimSpace(f.Isp) == "" {
+		return fmt.Errorf("at least one of proxy_type, country_code or isp is required %w", common.ErrorBadRequest)
+	}
+	for _, proxyType := range f.ProxyTypes {
+		if !IsValidProxyType(proxyType) {
+			return fmt.Errorf("invalid proxy_type %s %w", proxyType, common.ErrorBadRequest)
+		}
+	}
+	for _, countryCode := range f.CountryCodes {
+		if !IsValidCountryCode(countryCode) {
+			return fmt.Errorf("invalid country_code %s %w", countryCode, common.ErrorBadRequest)
+		}
+	}
+	return nil
+}
+
+// WithDefaults fills the format and name if not set
+func (o BlocklistOptions) WithDefaults() BlocklistOptions {
+	if o.Format == "" {
+		o.Format = BlocklistFormatPlain
+	}
+	if o.Name == "" {
+		o.Name = DefaultBlocklistName
+	}
+	return o
+}
+
+// Validate checks the format is known and the name is safe to be rendered
+func (o BlocklistOptions) Validate() error {
+	if _, found := blocklistFormats[o.Format]; !found {
+		return fmt.Errorf("invalid format %s %w", o.Format, common.ErrorBadRequest)
+	}
+	if len(o.Name) > maxBlocklistNameLength || !blocklistNameRegexp.MatchString(o.Name) {
+		return fmt.Errorf("name must start with a letter and have at most %d letters, digits, _ or - %w",
+			maxBlocklistNameLength, common.ErrorBadRequest)
+	}
+	return nil
+}
+
+// RenderBlocklist writes the given cidrs in the format of the options
+func RenderBlocklist(w io.Writer, opts BlocklistOptions, cidrs []string) error {
+	var b strings.Builder
+	switch opts.Format {
+	case BlocklistFormatPlain:
+		for _, cidr := range cidrs {
+			b.WriteString(cidr + "\n")
+		}
+	case BlocklistFormatNftables:
+		// nft -f script, the set is flushed so the script can be loaded again on every export
+		b.WriteString(fmt.Sprintf("add table inet %s\n", nftablesTableName))
+		b.WriteString(fmt.Sprintf("add set inet %s %s { type ipv4_addr; flags interval; }\n", nftablesTableName, opts.Name))
+		b.WriteString(fmt.Sprintf("flush set inet %s %s\n", nftablesTableName, opts.Name))
+		if len(cidrs) > 0 {
+			b.WriteString(fmt.Sprintf("add element inet %s %s { %s }\n", nftablesTableName, opts.Name, strings.Join(cidrs, ", ")))
+		}
+	case BlocklistFormatIptables:
+		// iptables-restore --noflush input, declaring the chain flushes it
+		b.WriteString("*filter\n")
+		b.WriteString(fmt.Sprintf(":%s - [0:0]\n", opts.Name))
+		for _, cidr := range cidrs {
+			b.WriteString(fmt.Sprintf("-A %s -s %s -j DROP\n", opts.Name, cidr))
+		}
+		b.WriteString("COMMIT\n")
+	case BlocklistFormatNginx:
+		for _, cidr := range cidrs {
+			b.WriteString(fmt.Sprintf("deny %s;\n", cidr))
+		}
+	case BlocklistFormatHAProxy:
+		for _, cidr := range cidrs {
+			b.WriteString(fmt.Sprintf("%s %s\n", cidr, opts.Name))
+		}
+	default:
+		return fmt.Errorf("invalid format %s %w", opts.Format, common.ErrorBadRequest)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mergeRanges returns the given ranges sorted by ip_from with the overlapping and adjacent ones merged
+func mergeRanges(ranges []IpRange) []IpRange {
+	sorted := make([]IpRange, len(ranges))
+	copy(sorted, ranges)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].IpFrom < sorted[j].IpFrom })
+
+	merged := make([]IpRange, 0, len(sorted))
+	for _, ipRange := range sorted {
+		last := len(merged) - 1
+		if last >= 0 && ipRange.IpFrom <= merged[last].IpTo+1 {
+			if ipRange.IpTo > merged[last].IpTo {
+				merged[last].IpTo = ipRange.IpTo
+			}
+			continue
+		}
+		merged = append(merged, IpRange{IpFrom: ipRange.IpFrom, IpTo: ipRange.IpTo})
+	}
+	return merged
+}
+
+// rangesToCIDRs merges the given ranges and returns the minimal list of CIDR blocks covering them
+func rangesToCIDRs(ranges []IpRange) ([]string, error) {
+	cidrs := make([]string, 0)
+	for _, ipRange := range mergeRanges(ranges) {
+		rangeCIDRs, err := rangeToCIDRs(ipRange.IpFrom, ipRange.IpTo)
+		if err != nil {
+			return []string{}, fmt.Errorf("%s %w", err.Error(), common.ErrorInternalServer)
+		}
+		cidrs = append(cidrs, rangeCIDRs...)
+	}
+	return cidrs, nil
+}
@@ -0,0 +1,73 @@
+package ipdata
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBlocklist_MergeRanges(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Adjacent ranges", TestFn: testMergeRangesAdjacent},
+		{Scenario: "Overlapping unsorted ranges", TestFn: testMergeRangesOverlappingUnsorted},
+		{Scenario: "Disjoint ranges", TestFn: testMergeRangesDisjoint},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestBlocklist_RenderBlocklist(t *testing.T) {
+	cidrs := []string{"10.0.0.0/24", "10.0.1.0/32"}
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{BlocklistFormatPlain, "10.0.0.0/24\n10.0.1.0/32\n"},
+		{BlocklistFormatNftables, "add table inet ipdata\n" +
+			"add set inet ipdata vpn { type ipv4_addr; flags interval; }\n" +
+			"flush set inet ipdata vpn\n" +
+			"add element inet ipdata vpn { 10.0.0.0/24, 10.0.1.0/32 }\n"},
+		{BlocklistFormatIptables, "*filter\n:vpn - [0:0]\n-A vpn -s 10.0.0.0/24 -j DROP\n-A vpn -s 10.0.1.0/32 -j DROP\nCOMMIT\n"},
+		{BlocklistFormatNginx, "deny 10.0.0.0/24;\ndeny 10.0.1.0/32;\n"},
+		{BlocklistFormatHAProxy, "10.0.0.0/24 vpn\n10.0.1.0/32 vpn\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			var b bytes.Buffer
+			err := RenderBlocklist(&b, BlocklistOptions{Format: test.format, Name: "vpn"}, cidrs)
+
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, b.String())
+		})
+	}
+}
+
+func TestBlocklist_OptionsValidate(t *testing.T) {
+	assert.Nil(t, BlocklistOptions{}.WithDefaults().Validate())
+	assert.True(t, errors.Is(BlocklistOptions{Format: "pf", Name: "vpn"}.Validate(), common.ErrorBadRequest))
+	assert.True(t, errors.Is(BlocklistOptions{Format: BlocklistFormatPlain, Name: "vpn; flush ruleset"}.Validate(), common.ErrorBadRequest))
+	assert.True(t, errors.Is(BlocklistOptions{Format: BlocklistFormatPlain, Name: "a_name_longer_than_an_iptables_chain"}.Validate(), common.ErrorBadRequest))
+}
+
+func testMergeRangesAdjacent(t *testing.T) {
+	ranges := []IpRange{{IpFrom: 10, IpTo: 19}, {IpFrom: 20, IpTo: 29}}
+
+	assert.Equal(t, []IpRange{{IpFrom: 10, IpTo: 29}}, mergeRanges(ranges))
+}
+
+func testMergeRangesOverlappingUnsorted(t *testing.T) {
+	ranges := []IpRange{{IpFrom: 15, IpTo: 40}, {IpFrom: 10, IpTo: 20}, {IpFrom: 16, IpTo: 18}}
+
+	assert.Equal(t, []IpRange{{IpFrom: 10, IpTo: 40}}, mergeRanges(ranges))
+}
+
+func testMergeRangesDisjoint(t *testing.T) {
+	ranges := []IpRange{{IpFrom: 10, IpTo: 19, ProxyType: "VPN"}, {IpFrom: 21, IpTo: 29, ProxyType: "TOR"}}
+
+	assert.Equal(t, []IpRange{{IpFrom: 10, IpTo: 19}, {IpFrom: 21, IpTo: 29}}, mergeRanges(ranges))
+}
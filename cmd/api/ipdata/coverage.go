@@ -0,0 +1,259 @@
+package ipdata
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"container/heap"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// sections of the coverage rows
+const (
+	CoverageSectionTotal     = "total"
+	CoverageSectionSlash8    = "slash8"
+	CoverageSectionCountry   = "country"
+	CoverageSectionProxyType = "proxy_type"
+	CoverageSectionGap       = "gap"
+	CoverageSectionOverlap   = "overlap"
+
+	// ipv4SpaceSize is the number of ipv4 addresses
+	ipv4SpaceSize = maxIPv4Decimal + 1
+	slash8Size    = 1 << 24
+)
+
+// CoverageHeader is the header of the Coverage rows
+var CoverageHeader = []string{"section", "key", "ip_count", "percentage"}
+
+// CoverageSpan is a span of ips without ranges (gap) or held by more than one range (overlap)
+type CoverageSpan struct {
+	IpFrom       int64  `json:"ip_from"`
+	IpTo         int64  `json:"ip_to"`
+	IpFromString string `json:"ip_from_string"`
+	IpToString   string `json:"ip_to_string"`
+	IpCount      int64  `json:"ip_count"`
+}
+
+// CoverageCount is the number of covered ips of a /8, country or proxy type
+type CoverageCount struct {
+	Key        string  `json:"key"`
+	IpCount    int64   `json:"ip_count"`
+	Percentage float64 `json:"percentage"`
+}
+
+// Coverage tells which parts of the ipv4 space the dataset covers. The ips of an overlap are counted once, for
+// the range starting first, so the counts of each breakdown add up to CoveredIps
+type Coverage struct {
+	Ranges int64 `json:"ranges"`
+	// InvalidRanges are inverted (ip_from > ip_to) or out of the ipv4 space, they are skipped
+	InvalidRanges     int64   `json:"invalid_ranges"`
+	CoveredIps        int64   `json:"covered_ips"`
+	CoveredPercentage float64 `json:"covered_percentage"`
+	GapCount          int64   `json:"gap_count"`
+	GapIps            int64   `json:"gap_ips"`
+	// Gaps are the biggest gaps, by ip count
+	Gaps         []CoverageSpan `json:"gaps"`
+	OverlapCount int64          `json:"overlap_count"`
+	OverlapIps   int64          `json:"overlap_ips"`
+	// Overlaps are the first overlaps, by ip_from
+	Overlaps []CoverageSpan `json:"overlaps"`
+	// BySlash8 has the 256 /8 blocks, the percentage is of the block size
+	BySlash8 []CoverageCount `json:"by_slash8"`
+	// ByCountry and ByProxyType are ordered by ip count desc, the percentage is of CoveredIps
+	ByCountry   []CoverageCount `json:"by_country"`
+	ByProxyType []CoverageCount `json:"by_proxy_type"`
+}
+
+// Rows returns the coverage as section, key, ip_count and percentage rows, to be written as CSV
+func (c Coverage) Rows() [][]string {
+	rows := [][]string{
+		{CoverageSectionTotal, "ranges", strconv.FormatInt(c.Ranges, 10), ""},
+		{CoverageSectionTotal, "invalid_ranges", strconv.FormatInt(c.InvalidRanges, 10), ""},
+		{CoverageSectionTotal, "covered", strconv.FormatInt(c.CoveredIps, 10), formatPercentage(c.CoveredPercentage)},
+		{CoverageSectionTotal, "gaps", strconv.FormatInt(c.GapIps, 10), formatPercentage(PercentageOf(c.GapIps, ipv4SpaceSize))},
+		{CoverageSectionTotal, "overlaps", strconv.FormatInt(c.OverlapIps, 10), ""},
+	}
+	for _, section := range []struct {
+		name   string
+		counts []CoverageCount
+	}{
+		{CoverageSectionSlash8, c.BySlash8},
+		{CoverageSectionCountry, c.ByCountry},
+		{CoverageSectionProxyType, c.ByProxyType},
+	} {
+		for _, count := range section.counts {
+			rows = append(rows, []string{section.name, count.Key, strconv.FormatInt(count.IpCount, 10), formatPercentage(count.Percentage)})
+		}
+	}
+	for _, gap := range c.Gaps {
+		rows = append(rows, []string{CoverageSectionGap, gap.IpFromString + "-" + gap.IpToString, strconv.FormatInt(gap.IpCount, 10), ""})
+	}
+	for _, overlap := range c.Overlaps {
+		rows = append(rows, []string{CoverageSectionOverlap, overlap.IpFromString + "-" + overlap.IpToString, strconv.FormatInt(overlap.IpCount, 10), ""})
+	}
+	return rows
+}
+
+func formatPercentage(percentage float64) string {
+	return strconv.FormatFloat(percentage, 'f', 2, 64)
+}
+
+// coverageBuilder walks the ranges in ip_from order keeping the end of the covered space seen so far
+type coverageBuilder struct {
+	limit      int
+	coverage   Coverage
+	coveredTo  int64
+	lastFrom   int64
+	gaps       gapHeap
+	slash8     [256]int64
+	countries  map[string]int64
+	proxyTypes map[string]int64
+}
+
+// newCoverageBuilder lists up to limit gaps and overlaps
+func newCoverageBuilder(limit int) *coverageBuilder {
+	return &coverageBuilder{
+		limit:      limit,
+		coverage:   Coverage{Overlaps: []CoverageSpan{}},
+		coveredTo:  -1,
+		gaps:       make(gapHeap, 0, limit+1),
+		countries:  make(map[string]int64),
+		proxyTypes: make(map[string]int64),
+	}
+}
+
+// add must be called in ip_from order
+func (b *coverageBuilder) add(data IpData) error {
+	b.coverage.Ranges++
+	if data.IpFrom < 0 || data.IpTo > maxIPv4Decimal || data.IpFrom > data.IpTo {
+		b.coverage.InvalidRanges++
+		return nil
+	}
+	if data.IpFrom < b.lastFrom {
+		return fmt.Errorf("ranges are not ordered by ip_from, %d after %d %w", data.IpFrom, b.lastFrom, common.ErrorInternalServer)
+	}
+	b.lastFrom = data.IpFrom
+
+	if data.IpFrom > b.coveredTo+1 {
+		b.addGap(b.coveredTo+1, data.IpFrom-1)
+	}
+	if data.IpFrom <= b.coveredTo {
+		b.addOverlap(data.IpFrom, minInt64(data.IpTo, b.coveredTo))
+	}
+
+	from := b.coveredTo + 1
+	if data.IpFrom > from {
+		from = data.IpFrom
+	}
+	if data.IpTo < from {
+		return nil
+	}
+	covered := data.IpTo - from + 1
+	b.coverage.CoveredIps += covered
+	b.countries[data.CountryCode] += covered
+	b.proxyTypes[data.ProxyType] += covered
+	for from <= data.IpTo {
+		block := from / slash8Size
+		blockTo := minInt64(data.IpTo, (block+1)*slash8Size-1)
+		b.slash8[block] += blockTo - from + 1
+		from = blockTo + 1
+	}
+	b.coveredTo = data.IpTo
+	return nil
+}
+
+func (b *coverageBuilder) addGap(from int64, to int64) {
+	b.coverage.GapCount++
+	b.coverage.GapIps += to - from + 1
+	if b.limit == 0 {
+		return
+	}
+	// only the biggest limit gaps are kept, the smallest of them is dropped when a bigger one comes
+	if len(b.gaps) == b.limit {
+		if to-from+1 <= b.gaps[0].IpCount {
+			return
+		}
+		heap.Pop(&b.gaps)
+	}
+	heap.Push(&b.gaps, newCoverageSpan(from, to))
+}
+
+func (b *coverageBuilder) addOverlap(from int64, to int64) {
+	b.coverage.OverlapCount++
+	b.coverage.OverlapIps += to - from + 1
+	if len(b.coverage.Overlaps) < b.limit {
+		b.coverage.Overlaps = append(b.coverage.Overlaps, newCoverageSpan(from, to))
+	}
+}
+
+func (b *coverageBuilder) build() Coverage {
+	if b.coveredTo < maxIPv4Decimal {
+		b.addGap(b.coveredTo+1, maxIPv4Decimal)
+	}
+
+	coverage := b.coverage
+	coverage.CoveredPercentage = PercentageOf(coverage.CoveredIps, ipv4SpaceSize)
+	gaps := make(gapHeap, len(b.gaps))
+	copy(gaps, b.gaps)
+	sort.Slice(gaps, func(i, j int) bool { return gaps.Less(j, i) })
+	coverage.Gaps = gaps
+
+	coverage.BySlash8 = make([]CoverageCount, 0, len(b.slash8))
+	for block, ipCount := range b.slash8 {
+		coverage.BySlash8 = append(coverage.BySlash8, CoverageCount{
+			Key:        fmt.Sprintf("%d.0.0.0/8", block),
+			IpCount:    ipCount,
+			Percentage: PercentageOf(ipCount, slash8Size),
+		})
+	}
+	coverage.ByCountry = sortedCoverageCounts(b.countries, coverage.CoveredIps)
+	coverage.ByProxyType = sortedCoverageCounts(b.proxyTypes, coverage.CoveredIps)
+	return coverage
+}
+
+// gapHeap is a min heap of the gaps by ip count, the later gap is the smaller on ties so the first ones are kept
+type gapHeap []CoverageSpan
+
+func (h gapHeap) Len() int { return len(h) }
+func (h gapHeap) Less(i, j int) bool {
+	if h[i].IpCount != h[j].IpCount {
+		return h[i].IpCount < h[j].IpCount
+	}
+	return h[i].IpFrom > h[j].IpFrom
+}
+func (h gapHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *gapHeap) Push(x interface{}) { *h = append(*h, x.(CoverageSpan)) }
+func (h *gapHeap) Pop() interface{} {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return last
+}
+
+func newCoverageSpan(from int64, to int64) CoverageSpan {
+	fromAddr, _ := DecimalToAddr(from)
+	toAddr, _ := DecimalToAddr(to)
+	return CoverageSpan{IpFrom: from, IpTo: to, IpFromString: fromAddr.String(), IpToString: toAddr.String(), IpCount: to - from + 1}
+}
+
+// sortedCoverageCounts orders the counts by ip count desc and key asc
+func sortedCoverageCounts(counts map[string]int64, total int64) []CoverageCount {
+	sorted := make([]CoverageCount, 0, len(counts))
+	for key, ipCount := range counts {
+		sorted = append(sorted, CoverageCount{Key: key, IpCount: ipCount, Percentage: PercentageOf(ipCount, total)})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].IpCount != sorted[j].IpCount {
+			return sorted[i].IpCount > sorted[j].IpCount
+		}
+		return sorted[i].Key < sorted[j].Key
+	})
+	return sorted
+}
+
+func minInt64(a int64, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
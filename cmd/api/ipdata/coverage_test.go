@@ -0,0 +1,124 @@
+package ipdata
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCoverage_Builder(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Gaps, overlaps and breakdowns", TestFn: testCoverageBuilderBreakdowns},
+		{Scenario: "Empty dataset is a single gap", TestFn: testCoverageBuilderEmpty},
+		{Scenario: "Unordered ranges error", TestFn: testCoverageBuilderUnorderedError},
+		{Scenario: "Only the biggest gaps kept", TestFn: testCoverageBuilderBiggestGaps},
+		{Scenario: "Rows", TestFn: testCoverageRows},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func testCoverageBuilderBreakdowns(t *testing.T) {
+	builder := newCoverageBuilder(1)
+	for _, data := range mockCoverageRanges {
+		assert.Nil(t, builder.add(data))
+	}
+
+	coverage := builder.build()
+
+	assert.Equal(t, int64(4), coverage.Ranges)
+	assert.Equal(t, int64(1), coverage.InvalidRanges)
+	assert.Equal(t, int64(16777221), coverage.CoveredIps)
+	assert.Equal(t, 0.39, coverage.CoveredPercentage)
+	assert.Equal(t, int64(2), coverage.GapCount)
+	assert.Equal(t, int64(4278190075), coverage.GapIps)
+	assert.Equal(t, []CoverageSpan{{IpFrom: 16777226, IpTo: maxIPv4Decimal, IpFromString: "1.0.0.10",
+		IpToString: "255.255.255.255", IpCount: 4278190070}}, coverage.Gaps)
+	assert.Equal(t, int64(1), coverage.OverlapCount)
+	assert.Equal(t, []CoverageSpan{{IpFrom: 5, IpTo: 9, IpFromString: "0.0.0.5", IpToString: "0.0.0.9", IpCount: 5}}, coverage.Overlaps)
+	assert.Len(t, coverage.BySlash8, 256)
+	assert.Equal(t, CoverageCount{Key: "0.0.0.0/8", IpCount: 16777211, Percentage: 100}, coverage.BySlash8[0])
+	assert.Equal(t, CoverageCount{Key: "1.0.0.0/8", IpCount: 10, Percentage: 0}, coverage.BySlash8[1])
+	assert.Equal(t, []CoverageCount{{Key: "CH", IpCount: 16777216, Percentage: 100}, {Key: "DE", IpCount: 5, Percentage: 0}}, coverage.ByCountry)
+	assert.Equal(t, []CoverageCount{
+		{Key: "PUB", IpCount: 16777206, Percentage: 100},
+		{Key: "VPN", IpCount: 10, Percentage: 0},
+		{Key: "TOR", IpCount: 5, Percentage: 0},
+	}, coverage.ByProxyType)
+}
+
+func testCoverageBuilderEmpty(t *testing.T) {
+	coverage := newCoverageBuilder(10).build()
+
+	assert.Equal(t, int64(0), coverage.CoveredIps)
+	assert.Equal(t, []CoverageSpan{{IpFrom: 0, IpTo: maxIPv4Decimal, IpFromString: "0.0.0.0",
+		IpToString: "255.255.255.255", IpCount: ipv4SpaceSize}}, coverage.Gaps)
+	assert.Equal(t, []CoverageSpan{}, coverage.Overlaps)
+	assert.Equal(t, []CoverageCount{}, coverage.ByCountry)
+}
+
+func testCoverageBuilderUnorderedError(t *testing.T) {
+	builder := newCoverageBuilder(10)
+
+	assert.Nil(t, builder.add(IpData{IpFrom: 100, IpTo: 110}))
+	err := builder.add(IpData{IpFrom: 50, IpTo: 60})
+
+	assert.True(t, errors.Is(err, common.ErrorInternalServer))
+}
+
+func testCoverageBuilderBiggestGaps(t *testing.T) {
+	builder := newCoverageBuilder(3)
+	for _, ipFrom := range []int64{0, 4, 10, 16, 18} {
+		assert.Nil(t, builder.add(IpData{IpFrom: ipFrom, IpTo: ipFrom, ProxyType: "VPN"}))
+	}
+
+	coverage := builder.build()
+
+	assert.Equal(t, int64(5), coverage.GapCount)
+	assert.Equal(t, []CoverageSpan{
+		{IpFrom: 19, IpTo: maxIPv4Decimal, IpFromString: "0.0.0.19", IpToString: "255.255.255.255", IpCount: ipv4SpaceSize - 19},
+		{IpFrom: 5, IpTo: 9, IpFromString: "0.0.0.5", IpToString: "0.0.0.9", IpCount: 5},
+		{IpFrom: 11, IpTo: 15, IpFromString: "0.0.0.11", IpToString: "0.0.0.15", IpCount: 5},
+	}, coverage.Gaps)
+	assert.Len(t, builder.gaps, 3)
+}
+
+func testCoverageRows(t *testing.T) {
+	coverage := Coverage{
+		Ranges:            2,
+		CoveredIps:        20,
+		GapIps:            ipv4SpaceSize - 20,
+		Gaps:              []CoverageSpan{{IpFromString: "0.0.0.20", IpToString: "255.255.255.255", IpCount: ipv4SpaceSize - 20}},
+		Overlaps:          []CoverageSpan{},
+		BySlash8:          []CoverageCount{{Key: "0.0.0.0/8", IpCount: 20}},
+		ByCountry:         []CoverageCount{{Key: "CH", IpCount: 20, Percentage: 100}},
+		ByProxyType:       []CoverageCount{{Key: "VPN", IpCount: 20, Percentage: 100}},
+		CoveredPercentage: 0,
+	}
+
+	assert.Equal(t, [][]string{
+		{"total", "ranges", "2", ""},
+		{"total", "invalid_ranges", "0", ""},
+		{"total", "covered", "20", "0.00"},
+		{"total", "gaps", "4294967276", "100.00"},
+		{"total", "overlaps", "0", ""},
+		{"slash8", "0.0.0.0/8", "20", "0.00"},
+		{"country", "CH", "20", "100.00"},
+		{"proxy_type", "VPN", "20", "100.00"},
+		{"gap", "0.0.0.20-255.255.255.255", "4294967276", ""},
+	}, coverage.Rows())
+}
+
+// mock utils
+
+// mockCoverageRanges are ordered by ip_from: an overlap at 5-9, a gap at 15-19, a range crossing into 1.0.0.0/8
+// and an inverted range
+var mockCoverageRanges = []IpData{
+	{IpFrom: 0, IpTo: 9, ProxyType: "VPN", CountryCode: "CH"},
+	{IpFrom: 5, IpTo: 14, ProxyType: "TOR", CountryCode: "DE"},
+	{IpFrom: 20, IpTo: 16777225, ProxyType: "PUB", CountryCode: "CH"},
+	{IpFrom: 30, IpTo: 29, ProxyType: "VPN", CountryCode: "CH"},
+}
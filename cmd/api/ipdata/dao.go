@@ -6,23 +6,121 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	"net/netip"
+	"sort"
+	"strings"
 )
 
 const (
 	ipdataSchemaTableName = "proxydata.ip2location"
+	// DatasetTable is the table of the current dataset, read by the Dao
+	DatasetTable = ipdataSchemaTableName
 
 	getIPsPerCountryQuery  = "SELECT SUM(ip_to - ip_from + 1) FROM " + ipdataSchemaTableName + " WHERE country_name = $1 "
 	getTopIspByCountryCode = "SELECT isp, sum(ip_to-ip_from+1) as difference FROM " + ipdataSchemaTableName + " WHERE country_code = $1 GROUP BY isp order by difference DESC LIMIT $2"
-	selectByIPQuery        = "SELECT ip_from,ip_to,country_code,country_name,isp,region_name,city_name,proxy_type FROM " + ipdataSchemaTableName + " WHERE $1 BETWEEN ip_from AND ip_to"
+	// getDatasetVersionQuery reads the version the dataset table is tagged with, see the README
+	getDatasetVersionQuery          = "SELECT COALESCE(obj_description('" + ipdataSchemaTableName + "'::regclass, 'pg_class'), '')"
+	selectByIPQuery                 = "SELECT ip_from,ip_to,country_code,country_name,isp,region_name,city_name,proxy_type FROM " + ipdataSchemaTableName + " WHERE $1 BETWEEN ip_from AND ip_to"
+	getTopCountriesQuery            = "SELECT country_code, SUM(ip_to - ip_from + 1) as ip_count, SUM(SUM(ip_to - ip_from + 1)) OVER () as total FROM " + ipdataSchemaTableName + " GROUP BY country_code ORDER BY ip_count DESC LIMIT $1"
+	getTopCountriesByProxyTypeQuery = "SELECT country_code, SUM(ip_to - ip_from + 1) as ip_count, SUM(SUM(ip_to - ip_from + 1)) OVER () as total FROM " + ipdataSchemaTableName + " WHERE proxy_type = $2 GROUP BY country_code ORDER BY ip_count DESC LIMIT $1"
+
+	// searchIspQuery ranks the prefix matches first, then the substrings and last the trigram (pg_trgm) similar ones,
+	// the most similar first. $1 is the searched text with the LIKE wildcards escaped and $5 the raw one
+	searchIspQuery = "SELECT isp, SUM(ip_to - ip_from + 1) as ip_count, " +
+		"CASE WHEN isp ILIKE $1 || '%' THEN 0 WHEN isp ILIKE '%' || $1 || '%' THEN 1 ELSE 2 END as match_rank " +
+		"FROM " + ipdataSchemaTableName + " WHERE ($2 = '' OR country_code = $2) AND (isp ILIKE '%' || $1 || '%' OR similarity(isp, $5) > $3) " +
+		"GROUP BY isp ORDER BY match_rank, CASE WHEN isp ILIKE '%' || $1 || '%' THEN 0 ELSE similarity(isp, $5) END DESC, ip_count DESC LIMIT $4"
+	// autocompleteQuery is only filled with the autocompleteFields, see buildAutocompleteQueries
+	autocompleteQuery = "SELECT %[1]s, SUM(ip_to - ip_from + 1) as ip_count FROM " + ipdataSchemaTableName +
+		" WHERE ($2 = '' OR country_code = $2) AND %[1]s ILIKE $1 || '%%' GROUP BY %[1]s ORDER BY ip_count DESC LIMIT $3"
+	getRangesByIspQuery = "SELECT ip_from, ip_to, proxy_type, country_code FROM " + ipdataSchemaTableName +
+		" WHERE isp = $1 AND ($2 = '' OR country_code = $2) ORDER BY ip_from LIMIT $3 OFFSET $4"
+	// walkTableQuery is only filled with validated table names, see NewTableWalker
+	walkTableQuery = "SELECT ip_from,ip_to,country_code,country_name,isp,region_name,city_name,proxy_type FROM %s ORDER BY ip_from, ip_to"
+	// getPreviousRangesQuery and getNextRangesQuery walk the ip_from index from the ip, backward and forward
+	getPreviousRangesQuery = "SELECT ip_from, ip_to, proxy_type, country_code, isp FROM " + ipdataSchemaTableName +
+		" WHERE ip_from <= $1 ORDER BY ip_from DESC LIMIT $2"
+	getNextRangesQuery = "SELECT ip_from, ip_to, proxy_type, country_code, isp FROM " + ipdataSchemaTableName +
+		" WHERE ip_from > $1 ORDER BY ip_from LIMIT $2"
+	// getBlocklistRangesQuery empty arrays and isp don't filter
+	getBlocklistRangesQuery = "SELECT ip_from, ip_to FROM " + ipdataSchemaTableName +
+		" WHERE (cardinality($1::text[]) = 0 OR proxy_type = ANY($1)) AND (cardinality($2::text[]) = 0 OR country_code = ANY($2))" +
+		" AND ($3 = '' OR isp = $3) ORDER BY ip_from"
+
+	// trigramSimilarityThreshold is the pg_trgm default similarity threshold
+	trigramSimilarityThreshold = 0.3
+
+	// getIPsPerCountryGroupedQuery is only filled with the whitelisted groupDimensions, see buildCountryGroupedQueries
+	getIPsPerCountryGroupedQuery = "SELECT %[1]s, SUM(ip_to - ip_from + 1) as ip_count FROM " + ipdataSchemaTableName + " WHERE country_name = $1 GROUP BY %[1]s ORDER BY ip_count DESC"
 )
 
+// query builder parts, only whitelisted queryColumns are placed in the query, values always go as parameters
+const (
+	runQueryCount  = "COALESCE(SUM(ip_to - ip_from + 1), 0) as ip_count, COALESCE(SUM(SUM(ip_to - ip_from + 1)) OVER (), 0) as total"
+	runQueryFrom   = " FROM " + ipdataSchemaTableName
+	runQueryWhere  = " WHERE "
+	runQueryAnd    = " AND "
+	runQueryGroup  = " GROUP BY "
+	runQueryOrder  = " ORDER BY "
+	runQueryLimit  = " LIMIT "
+	orderCountDesc = "ip_count DESC"
+	orderCountAsc  = "ip_count ASC"
+)
+
+var walkRangesQuery = fmt.Sprintf(walkTableQuery, ipdataSchemaTableName)
+
+var autocompleteQueries = buildAutocompleteQueries()
+
+func buildAutocompleteQueries() map[string]string {
+	queries := make(map[string]string)
+	for _, field := range []string{AutocompleteCountryName, AutocompleteRegionName, AutocompleteCityName} {
+		queries[field] = fmt.Sprintf(autocompleteQuery, field)
+	}
+	return queries
+}
+
+// countryGroupedQueries holds a query for each valid combination of dimensions, keyed by the comma joined dimensions
+var countryGroupedQueries = buildCountryGroupedQueries()
+
+func buildCountryGroupedQueries() map[string]string {
+	queries := make(map[string]string)
+	for _, first := range groupDimensions {
+		queries[first] = fmt.Sprintf(getIPsPerCountryGroupedQuery, first)
+		for _, second := range groupDimensions {
+			if first == second {
+				continue
+			}
+			columns := first + "," + second
+			queries[columns] = fmt.Sprintf(getIPsPerCountryGroupedQuery, columns)
+		}
+	}
+	return queries
+}
+
 //go:generate mockgen -destination=mock_dao.go -package=ipdata -source=dao.go Dao
 
 type Dao interface {
-	GetByIp(ctx context.Context, ip int64) (IpData, error)
+	// Backend is the name of the storage answering the queries
+	Backend() string
+	// DatasetVersion is the version of the dataset answering the queries, empty when unknown
+	DatasetVersion(ctx context.Context) (string, error)
+	GetByIp(ctx context.Context, ip netip.Addr) (IpData, error)
 	GetIpSumByCountry(ctx context.Context, countryName string) (int64, error)
 	GetTopIspByCountryCode(ctx context.Context, countryCode string, limit int) ([]IspIpCount, error)
+	GetIpSumByCountryGrouped(ctx context.Context, countryName string, groupBy []string) ([]GroupedIpCount, error)
+	GetTopCountries(ctx context.Context, proxyType string, limit int) ([]CountryIpCount, int64, error)
+	RunQuery(ctx context.Context, query Query) ([]GroupedIpCount, int64, error)
+	SearchIsp(ctx context.Context, text string, countryCode string, limit int) ([]IspMatch, error)
+	Autocomplete(ctx context.Context, field string, prefix string, countryCode string, limit int) ([]Suggestion, error)
+	GetRangesByIsp(ctx context.Context, isp string, countryCode string, limit int, offset int) ([]IpRange, error)
+	GetBlocklistRanges(ctx context.Context, filter BlocklistFilter) ([]IpRange, error)
+	// GetPreviousRanges gets the (limit) ranges starting at or before the ip, ordered by ip_from desc
+	GetPreviousRanges(ctx context.Context, ip netip.Addr, limit int) ([]IpRange, error)
+	// GetNextRanges gets the (limit) ranges starting after the ip, ordered by ip_from
+	GetNextRanges(ctx context.Context, ip netip.Addr, limit int) ([]IpRange, error)
+	// WalkRanges calls fn with every range ordered by ip_from, stopping at the first error
+	WalkRanges(ctx context.Context, fn func(data IpData) error) error
 }
 
 func NewDao(dbConnection *sql.DB) Dao {
@@ -63,9 +161,27 @@ func (d dao) GetTopIspByCountryCode(ctx context.Context, countryCode string, lim
 	return ipData, nil
 }
 
-// GetByIp gets all the data of the given ip in decimal format
-func (d dao) GetByIp(ctx context.Context, ip int64) (IpData, error) {
-	row := d.db.QueryRowContext(ctx, selectByIPQuery, ip)
+func (d dao) Backend() string {
+	return BackendPostgres
+}
+
+// DatasetVersion gets the comment of the dataset table, the release imported in it
+func (d dao) DatasetVersion(ctx context.Context) (string, error) {
+	var version string
+	err := d.db.QueryRowContext(ctx, getDatasetVersionQuery).Scan(&version)
+	if err != nil {
+		return "", fmt.Errorf("error getting the dataset version. %s %w", err.Error(), common.ErrorInternalServer)
+	}
+	return version, nil
+}
+
+// GetByIp gets all the data of the given ipv4
+func (d dao) GetByIp(ctx context.Context, ip netip.Addr) (IpData, error) {
+	decimalIp, isIPv4 := AddrToDecimal(ip)
+	if !isIPv4 {
+		return IpData{}, fmt.Errorf("ip %s is not an ipv4 %w", ip, common.ErrorBadRequest)
+	}
+	row := d.db.QueryRowContext(ctx, selectByIPQuery, decimalIp)
 
 	ipData := IpData{}
 	err := row.Scan(&ipData.IpFrom,
@@ -105,3 +221,382 @@ func (d dao) GetIpSumByCountry(ctx context.Context, countryName string) (int64,
 
 	return ipSum, nil
 }
+
+// GetIpSumByCountryGrouped gets the number of Ips of the given countryName grouped by the given dimensions
+func (d dao) GetIpSumByCountryGrouped(ctx context.Context, countryName string, groupBy []string) ([]GroupedIpCount, error) {
+	query, found := countryGroupedQueries[strings.Join(groupBy, ",")]
+	if !found {
+		return []GroupedIpCount{}, fmt.Errorf("invalid group_by %v %w", groupBy, common.ErrorBadRequest)
+	}
+
+	rows, err := d.db.QueryContext(ctx, query, countryName)
+	if err != nil {
+		return []GroupedIpCount{}, err
+	}
+	defer rows.Close()
+
+	groupedCounts := make([]GroupedIpCount, 0)
+	for rows.Next() {
+		values := make([]string, len(groupBy))
+		data := GroupedIpCount{Group: make(map[string]string, len(groupBy))}
+		dest := make([]interface{}, 0, len(groupBy)+1)
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		dest = append(dest, &data.IpCount)
+
+		err := rows.Scan(dest...)
+		if err != nil {
+			return groupedCounts, err
+		}
+		for i, dimension := range groupBy {
+			data.Group[dimension] = values[i]
+		}
+		groupedCounts = append(groupedCounts, data)
+	}
+	err = rows.Err()
+	if err != nil {
+		err = fmt.Errorf("error with get query while scanning rows. %s %w", err.Error(), common.ErrorInternalServer)
+		return []GroupedIpCount{}, err
+	}
+
+	return groupedCounts, nil
+}
+
+// GetTopCountries gets the top (limit) countries by ip count, only counting the given proxyType if not empty.
+// also returns the total ip count of all the countries
+func (d dao) GetTopCountries(ctx context.Context, proxyType string, limit int) ([]CountryIpCount, int64, error) {
+	var rows *sql.Rows
+	var err error
+	if proxyType == "" {
+		rows, err = d.db.QueryContext(ctx, getTopCountriesQuery, limit)
+	} else {
+		rows, err = d.db.QueryContext(ctx, getTopCountriesByProxyTypeQuery, limit, proxyType)
+	}
+	if err != nil {
+		return []CountryIpCount{}, 0, err
+	}
+	defer rows.Close()
+
+	var total int64
+	countryCounts := make([]CountryIpCount, 0)
+	for rows.Next() {
+		data := CountryIpCount{}
+		err := rows.Scan(&data.CountryCode, &data.IpCount, &total)
+		if err != nil {
+			return countryCounts, 0, err
+		}
+		countryCounts = append(countryCounts, data)
+	}
+	err = rows.Err()
+	if err != nil {
+		err = fmt.Errorf("error with get query while scanning rows. %s %w", err.Error(), common.ErrorInternalServer)
+		return []CountryIpCount{}, 0, err
+	}
+
+	return countryCounts, total, nil
+}
+
+// RunQuery compiles the given query into parameterized sql and returns its groups and the total ip count of the
+// filtered rows
+func (d dao) RunQuery(ctx context.Context, query Query) ([]GroupedIpCount, int64, error) {
+	sqlQuery, args, err := buildQuerySQL(query)
+	if err != nil {
+		return []GroupedIpCount{}, 0, err
+	}
+
+	rows, err := d.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return []GroupedIpCount{}, 0, err
+	}
+	defer rows.Close()
+
+	var total int64
+	groupedCounts := make([]GroupedIpCount, 0)
+	for rows.Next() {
+		values := make([]string, len(query.GroupBy))
+		data := GroupedIpCount{Group: make(map[string]string, len(query.GroupBy))}
+		dest := make([]interface{}, 0, len(query.GroupBy)+2)
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		dest = append(dest, &data.IpCount, &total)
+
+		err := rows.Scan(dest...)
+		if err != nil {
+			return groupedCounts, 0, err
+		}
+		for i, dimension := range query.GroupBy {
+			data.Group[dimension] = values[i]
+		}
+		groupedCounts = append(groupedCounts, data)
+	}
+	err = rows.Err()
+	if err != nil {
+		err = fmt.Errorf("error with get query while scanning rows. %s %w", err.Error(), common.ErrorInternalServer)
+		return []GroupedIpCount{}, 0, err
+	}
+
+	return groupedCounts, total, nil
+}
+
+// buildQuerySQL returns the sql and its arguments for the given query. Any field not present in queryColumns is
+// rejected, so no caller provided string ends up in the sql text.
+func buildQuerySQL(query Query) (string, []interface{}, error) {
+	groupColumns := make([]string, 0, len(query.GroupBy))
+	for _, dimension := range query.GroupBy {
+		column, found := queryColumns[dimension]
+		if !found {
+			return "", nil, fmt.Errorf("invalid group_by dimension %s %w", dimension, common.ErrorBadRequest)
+		}
+		groupColumns = append(groupColumns, column)
+	}
+
+	filterFields := make([]string, 0, len(query.Filters))
+	for field := range query.Filters {
+		filterFields = append(filterFields, field)
+	}
+	sort.Strings(filterFields)
+
+	args := make([]interface{}, 0, len(filterFields)+1)
+	conditions := make([]string, 0, len(filterFields))
+	for _, field := range filterFields {
+		column, found := queryColumns[field]
+		if !found {
+			return "", nil, fmt.Errorf("invalid filter field %s %w", field, common.ErrorBadRequest)
+		}
+		args = append(args, query.Filters[field])
+		conditions = append(conditions, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	var b strings.Builder
+	b.WriteString("SELECT ")
+	for _, column := range groupColumns {
+		b.WriteString(column + ", ")
+	}
+	b.WriteString(runQueryCount + runQueryFrom)
+	if len(conditions) > 0 {
+		b.WriteString(runQueryWhere + strings.Join(conditions, runQueryAnd))
+	}
+	if len(groupColumns) > 0 {
+		b.WriteString(runQueryGroup + strings.Join(groupColumns, ", "))
+		b.WriteString(runQueryOrder)
+		switch query.Order {
+		case OrderIpCountAsc:
+			b.WriteString(orderCountAsc + ", " + strings.Join(groupColumns, ", "))
+		case OrderGroupAsc:
+			b.WriteString(strings.Join(groupColumns, ", "))
+		default:
+			b.WriteString(orderCountDesc + ", " + strings.Join(groupColumns, ", "))
+		}
+	}
+	args = append(args, query.Limit)
+	b.WriteString(fmt.Sprintf(runQueryLimit+"$%d", len(args)))
+
+	return b.String(), args, nil
+}
+
+// SearchIsp gets the ISPs matching the given text by prefix, substring or trigram similarity, filtered by
+// countryCode if not empty
+func (d dao) SearchIsp(ctx context.Context, text string, countryCode string, limit int) ([]IspMatch, error) {
+	rows, err := d.db.QueryContext(ctx, searchIspQuery, escapeLike(text), countryCode, trigramSimilarityThreshold, limit, text)
+	if err != nil {
+		return []IspMatch{}, err
+	}
+	defer rows.Close()
+
+	matches := make([]IspMatch, 0)
+	for rows.Next() {
+		data := IspMatch{}
+		var matchRank int
+		err := rows.Scan(&data.Isp, &data.IpCount, &matchRank)
+		if err != nil {
+			return matches, err
+		}
+		data.Match = matchesByRank[matchRank]
+		matches = append(matches, data)
+	}
+	err = rows.Err()
+	if err != nil {
+		err = fmt.Errorf("error with get query while scanning rows. %s %w", err.Error(), common.ErrorInternalServer)
+		return []IspMatch{}, err
+	}
+
+	return matches, nil
+}
+
+// Autocomplete gets the values of the given field starting with prefix, filtered by countryCode if not empty
+func (d dao) Autocomplete(ctx context.Context, field string, prefix string, countryCode string, limit int) ([]Suggestion, error) {
+	query, found := autocompleteQueries[field]
+	if !found {
+		return []Suggestion{}, fmt.Errorf("invalid autocomplete field %s %w", field, common.ErrorBadRequest)
+	}
+
+	rows, err := d.db.QueryContext(ctx, query, escapeLike(prefix), countryCode, limit)
+	if err != nil {
+		return []Suggestion{}, err
+	}
+	defer rows.Close()
+
+	suggestions := make([]Suggestion, 0)
+	for rows.Next() {
+		data := Suggestion{}
+		err := rows.Scan(&data.Value, &data.IpCount)
+		if err != nil {
+			return suggestions, err
+		}
+		suggestions = append(suggestions, data)
+	}
+	err = rows.Err()
+	if err != nil {
+		err = fmt.Errorf("error with get query while scanning rows. %s %w", err.Error(), common.ErrorInternalServer)
+		return []Suggestion{}, err
+	}
+
+	return suggestions, nil
+}
+
+// GetRangesByIsp gets the ranges of the given isp ordered by ip_from, filtered by countryCode if not empty
+func (d dao) GetRangesByIsp(ctx context.Context, isp string, countryCode string, limit int, offset int) ([]IpRange, error) {
+	rows, err := d.db.QueryContext(ctx, getRangesByIspQuery, isp, countryCode, limit, offset)
+	if err != nil {
+		return []IpRange{}, err
+	}
+	defer rows.Close()
+
+	ranges := make([]IpRange, 0)
+	for rows.Next() {
+		data := IpRange{}
+		err := rows.Scan(&data.IpFrom, &data.IpTo, &data.ProxyType, &data.CountryCode)
+		if err != nil {
+			return ranges, err
+		}
+		ranges = append(ranges, data)
+	}
+	err = rows.Err()
+	if err != nil {
+		err = fmt.Errorf("error with get query while scanning rows. %s %w", err.Error(), common.ErrorInternalServer)
+		return []IpRange{}, err
+	}
+
+	return ranges, nil
+}
+
+// GetPreviousRanges gets the (limit) ranges starting at or before the given ipv4, ordered by ip_from desc
+func (d dao) GetPreviousRanges(ctx context.Context, addr netip.Addr, limit int) ([]IpRange, error) {
+	return d.getRangesAround(ctx, getPreviousRangesQuery, addr, limit)
+}
+
+// GetNextRanges gets the (limit) ranges starting after the given ipv4, ordered by ip_from
+func (d dao) GetNextRanges(ctx context.Context, addr netip.Addr, limit int) ([]IpRange, error) {
+	return d.getRangesAround(ctx, getNextRangesQuery, addr, limit)
+}
+
+func (d dao) getRangesAround(ctx context.Context, query string, addr netip.Addr, limit int) ([]IpRange, error) {
+	ip, isIPv4 := AddrToDecimal(addr)
+	if !isIPv4 {
+		return []IpRange{}, fmt.Errorf("ip %s is not an ipv4 %w", addr, common.ErrorBadRequest)
+	}
+	rows, err := d.db.QueryContext(ctx, query, ip, limit)
+	if err != nil {
+		return []IpRange{}, err
+	}
+	defer rows.Close()
+
+	ranges := make([]IpRange, 0)
+	for rows.Next() {
+		data := IpRange{}
+		err := rows.Scan(&data.IpFrom, &data.IpTo, &data.ProxyType, &data.CountryCode, &data.Isp)
+		if err != nil {
+			return ranges, err
+		}
+		ranges = append(ranges, data)
+	}
+	err = rows.Err()
+	if err != nil {
+		err = fmt.Errorf("error with get query while scanning rows. %s %w", err.Error(), common.ErrorInternalServer)
+		return []IpRange{}, err
+	}
+
+	return ranges, nil
+}
+
+// WalkRanges calls fn with every range ordered by ip_from and ip_to, the rows are streamed and not held in memory
+func (d dao) WalkRanges(ctx context.Context, fn func(data IpData) error) error {
+	return walkRows(ctx, d.db, walkRangesQuery, fn)
+}
+
+func walkRows(ctx context.Context, db *sql.DB, query string, fn func(data IpData) error) error {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		data := IpData{}
+		err := rows.Scan(&data.IpFrom,
+			&data.IpTo,
+			&data.CountryCode,
+			&data.CountryName,
+			&data.ISP,
+			&data.RegionName,
+			&data.CityName,
+			&data.ProxyType)
+		if err != nil {
+			return err
+		}
+		err = fn(data)
+		if err != nil {
+			return err
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		return fmt.Errorf("error with get query while scanning rows. %s %w", err.Error(), common.ErrorInternalServer)
+	}
+
+	return nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// escapeLike escapes the LIKE wildcards so the text is matched literally
+func escapeLike(text string) string {
+	return likeEscaper.Replace(text)
+}
+
+// GetBlocklistRanges gets the ip_from and ip_to of all the ranges selected by the given filter, ordered by ip_from
+func (d dao) GetBlocklistRanges(ctx context.Context, filter BlocklistFilter) ([]IpRange, error) {
+	proxyTypes := filter.ProxyTypes
+	if proxyTypes == nil {
+		proxyTypes = []string{}
+	}
+	countryCodes := filter.CountryCodes
+	if countryCodes == nil {
+		countryCodes = []string{}
+	}
+
+	rows, err := d.db.QueryContext(ctx, getBlocklistRangesQuery, pq.Array(proxyTypes), pq.Array(countryCodes), filter.Isp)
+	if err != nil {
+		return []IpRange{}, err
+	}
+	defer rows.Close()
+
+	ranges := make([]IpRange, 0)
+	for rows.Next() {
+		data := IpRange{}
+		err := rows.Scan(&data.IpFrom, &data.IpTo)
+		if err != nil {
+			return ranges, err
+		}
+		ranges = append(ranges, data)
+	}
+	err = rows.Err()
+	if err != nil {
+		err = fmt.Errorf("error with get query while scanning rows. %s %w", err.Error(), common.ErrorInternalServer)
+		return []IpRange{}, err
+	}
+
+	return ranges, nil
+}
@@ -7,13 +7,15 @@ import (
 	"database/sql"
 	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
+	"net/netip"
 	"regexp"
 	"testing"
 )
 
 /*
-	GetByIp(ctx context.Context, ip int64) (IpData, error)
+	GetByIp(ctx context.Context, ip netip.Addr) (IpData, error)
 	GetIpSumByCountry(ctx context.Context, countryName string) (int64, error)
 	GetTopIspByCountryCode(ctx context.Context, countryCode string, limit int) ([]IspIpCount, error)
 */
@@ -30,6 +32,17 @@ func TestDao_GetByIp(t *testing.T) {
 	}
 }
 
+func TestDao_DatasetVersion(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testDaoDatasetVersionNoError},
+		{Scenario: "Connection error", TestFn: testDaoDatasetVersionConnectionError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
 func TestDao_GetIpSumByCountry(t *testing.T) {
 	tests := []common.TestCase{
 		{Scenario: "No error", TestFn: testDaoGetIpSumByCountryNoError},
@@ -56,21 +69,136 @@ func TestDao_GetTopIspByCountryCode(t *testing.T) {
 
 }
 
+func TestDao_GetIpSumByCountryGrouped(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testDaoGetIpSumByCountryGroupedNoError},
+		{Scenario: "Invalid group by error", TestFn: testDaoGetIpSumByCountryGroupedInvalidGroupByError},
+		{Scenario: "Connection error", TestFn: testDaoGetIpSumByCountryGroupedConnectionError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+
+}
+
+func TestDao_GetTopCountries(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testDaoGetTopCountriesNoError},
+		{Scenario: "No error filtered by proxy type", TestFn: testDaoGetTopCountriesByProxyTypeNoError},
+		{Scenario: "Connection error", TestFn: testDaoGetTopCountriesConnectionError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+
+}
+
+func TestDao_RunQuery(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error grouped", TestFn: testDaoRunQueryGroupedNoError},
+		{Scenario: "No error not grouped", TestFn: testDaoRunQueryNotGroupedNoError},
+		{Scenario: "Not whitelisted field error", TestFn: testDaoRunQueryNotWhitelistedError},
+		{Scenario: "Connection error", TestFn: testDaoRunQueryConnectionError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+
+}
+
+func TestDao_SearchIsp(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testDaoSearchIspNoError},
+		{Scenario: "Connection error", TestFn: testDaoSearchIspConnectionError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+
+}
+
+func TestDao_Autocomplete(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testDaoAutocompleteNoError},
+		{Scenario: "Invalid field error", TestFn: testDaoAutocompleteInvalidFieldError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+
+}
+
+func TestDao_GetRangesByIsp(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testDaoGetRangesByIspNoError},
+		{Scenario: "Connection error", TestFn: testDaoGetRangesByIspConnectionError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+
+}
+
+func TestDao_GetBlocklistRanges(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testDaoGetBlocklistRangesNoError},
+		{Scenario: "Connection error", TestFn: testDaoGetBlocklistRangesConnectionError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+
+}
+
+func TestDao_GetNeighborRanges(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Previous ranges no error", TestFn: testDaoGetPreviousRangesNoError},
+		{Scenario: "Next ranges no error", TestFn: testDaoGetNextRangesNoError},
+		{Scenario: "Connection error", TestFn: testDaoGetNextRangesConnectionError},
+		{Scenario: "Ipv6 error", TestFn: testDaoGetPreviousRangesIpv6Error},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+
+}
+
+func TestDao_WalkRanges(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testDaoWalkRangesNoError},
+		{Scenario: "Callback error stops the walk", TestFn: testDaoWalkRangesCallbackError},
+		{Scenario: "Connection error", TestFn: testDaoWalkRangesConnectionError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+
+}
+
 // GetByIp
 
 func testDaoGetByIpNoError(t *testing.T) {
 	type test struct {
-		ip     int64
+		ip     netip.Addr
 		rows   *sqlmock.Rows
 		output IpData
 		err    error
 	}
 
-	testData := test{ip: 2130706433, rows: getIpDataRows(), output: mockIpDataDao, err: nil}
+	testData := test{ip: netip.MustParseAddr("127.0.0.1"), rows: getIpDataRows(), output: mockIpDataDao, err: nil}
 	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
 	mockDao := NewDao(mockDB)
 
-	mockHandler.ExpectQuery(regexp.QuoteMeta(selectByIPQuery)).WithArgs(testData.ip).WillReturnRows(testData.rows)
+	mockHandler.ExpectQuery(regexp.QuoteMeta(selectByIPQuery)).WithArgs(int64(2130706433)).WillReturnRows(testData.rows)
 
 	output, err := mockDao.GetByIp(context.Background(), testData.ip)
 	assert.Equal(t, testData.output, output)
@@ -79,18 +207,18 @@ func testDaoGetByIpNoError(t *testing.T) {
 
 func testDaoGetByIpRowNotFoundError(t *testing.T) {
 	type test struct {
-		ip     int64
+		ip     netip.Addr
 		rows   *sqlmock.Rows
 		output IpData
 		err    error
 	}
 
 	rowsWithError := getIpDataRows().RowError(0, sql.ErrNoRows)
-	testData := test{ip: 2130706433, rows: rowsWithError, output: IpData{}, err: common.ErrorNotFound}
+	testData := test{ip: netip.MustParseAddr("127.0.0.1"), rows: rowsWithError, output: IpData{}, err: common.ErrorNotFound}
 	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
 	mockDao := NewDao(mockDB)
 
-	mockHandler.ExpectQuery(regexp.QuoteMeta(selectByIPQuery)).WithArgs(testData.ip).WillReturnRows(testData.rows)
+	mockHandler.ExpectQuery(regexp.QuoteMeta(selectByIPQuery)).WithArgs(int64(2130706433)).WillReturnRows(testData.rows)
 
 	output, err := mockDao.GetByIp(context.Background(), testData.ip)
 	assert.Equal(t, testData.output, output)
@@ -98,20 +226,42 @@ func testDaoGetByIpRowNotFoundError(t *testing.T) {
 
 }
 
+func testDaoDatasetVersionNoError(t *testing.T) {
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta(getDatasetVersionQuery)).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow("2026-04-01"))
+
+	output, err := mockDao.DatasetVersion(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "2026-04-01", output)
+}
+
+func testDaoDatasetVersionConnectionError(t *testing.T) {
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta(getDatasetVersionQuery)).WillReturnError(errors.New("connection error"))
+
+	output, err := mockDao.DatasetVersion(context.Background())
+	assert.Equal(t, "", output)
+	assert.True(t, errors.Is(err, common.ErrorInternalServer))
+}
+
 func testDaoGetByIpRowConnectionError(t *testing.T) {
 	type test struct {
-		ip     int64
+		ip     netip.Addr
 		rows   *sqlmock.Rows
 		output IpData
 		err    error
 	}
 
 	rowsWithError := getIpDataRows().RowError(0, errors.New("connectionError"))
-	testData := test{ip: 2130706433, rows: rowsWithError, output: IpData{}, err: common.ErrorInternalServer}
+	testData := test{ip: netip.MustParseAddr("127.0.0.1"), rows: rowsWithError, output: IpData{}, err: common.ErrorInternalServer}
 	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
 	mockDao := NewDao(mockDB)
 
-	mockHandler.ExpectQuery(regexp.QuoteMeta(selectByIPQuery)).WithArgs(testData.ip).WillReturnRows(testData.rows)
+	mockHandler.ExpectQuery(regexp.QuoteMeta(selectByIPQuery)).WithArgs(int64(2130706433)).WillReturnRows(testData.rows)
 
 	output, err := mockDao.GetByIp(context.Background(), testData.ip)
 	assert.Equal(t, testData.output, output)
@@ -249,8 +399,579 @@ func testDaoGetTopIspByCountryConnectionError(t *testing.T) {
 
 }
 
+// GetIpSumByCountryGrouped
+
+func testDaoGetIpSumByCountryGroupedNoError(t *testing.T) {
+	type test struct {
+		countryName string
+		groupBy     []string
+		rows        *sqlmock.Rows
+		output      []GroupedIpCount
+		err         error
+	}
+
+	testData := test{countryName: "Ireland", groupBy: []string{"proxy_type", "city_name"}, rows: getIpSumByCountryGroupedRows(), output: mockGroupedIpCountDao, err: nil}
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	expectedQuery := "SELECT proxy_type,city_name, SUM(ip_to - ip_from + 1) as ip_count FROM " + ipdataSchemaTableName + " WHERE country_name = $1 GROUP BY proxy_type,city_name ORDER BY ip_count DESC"
+	mockHandler.ExpectQuery(regexp.QuoteMeta(expectedQuery)).WithArgs(testData.countryName).WillReturnRows(testData.rows)
+
+	output, err := mockDao.GetIpSumByCountryGrouped(context.Background(), testData.countryName, testData.groupBy)
+	assert.Equal(t, testData.output, output)
+	assert.True(t, errors.Is(err, testData.err))
+
+}
+
+func testDaoGetIpSumByCountryGroupedInvalidGroupByError(t *testing.T) {
+	type test struct {
+		countryName string
+		groupBy     []string
+		output      []GroupedIpCount
+		err         error
+	}
+
+	testData := test{countryName: "Ireland", groupBy: []string{"ip_from; DROP TABLE x"}, output: []GroupedIpCount{}, err: common.ErrorBadRequest}
+	mockDB, _ := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	output, err := mockDao.GetIpSumByCountryGrouped(context.Background(), testData.countryName, testData.groupBy)
+	assert.Equal(t, testData.output, output)
+	assert.True(t, errors.Is(err, testData.err))
+
+}
+
+func testDaoGetIpSumByCountryGroupedConnectionError(t *testing.T) {
+	type test struct {
+		countryName string
+		groupBy     []string
+		rows        *sqlmock.Rows
+		output      []GroupedIpCount
+		err         error
+	}
+
+	rowsWithError := getIpSumByCountryGroupedRows().RowError(0, errors.New("connection error"))
+	testData := test{countryName: "Ireland", groupBy: []string{"proxy_type", "city_name"}, rows: rowsWithError, output: []GroupedIpCount{}, err: common.ErrorInternalServer}
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta(countryGroupedQueries["proxy_type,city_name"])).WithArgs(testData.countryName).WillReturnRows(testData.rows)
+
+	output, err := mockDao.GetIpSumByCountryGrouped(context.Background(), testData.countryName, testData.groupBy)
+	assert.Equal(t, testData.output, output)
+	assert.True(t, errors.Is(err, testData.err))
+
+}
+
+// GetTopCountries
+
+func testDaoGetTopCountriesNoError(t *testing.T) {
+	type test struct {
+		limit  int
+		rows   *sqlmock.Rows
+		output []CountryIpCount
+		total  int64
+		err    error
+	}
+
+	testData := test{limit: 2, rows: getTopCountriesRows(), output: mockCountryIpCountDao, total: 100, err: nil}
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta(getTopCountriesQuery)).WithArgs(testData.limit).WillReturnRows(testData.rows)
+
+	output, total, err := mockDao.GetTopCountries(context.Background(), "", testData.limit)
+	assert.Equal(t, testData.output, output)
+	assert.Equal(t, testData.total, total)
+	assert.True(t, errors.Is(err, testData.err))
+
+}
+
+func testDaoGetTopCountriesByProxyTypeNoError(t *testing.T) {
+	type test struct {
+		proxyType string
+		limit     int
+		rows      *sqlmock.Rows
+		output    []CountryIpCount
+		total     int64
+		err       error
+	}
+
+	testData := test{proxyType: "VPN", limit: 2, rows: getTopCountriesRows(), output: mockCountryIpCountDao, total: 100, err: nil}
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta(getTopCountriesByProxyTypeQuery)).WithArgs(testData.limit, testData.proxyType).WillReturnRows(testData.rows)
+
+	output, total, err := mockDao.GetTopCountries(context.Background(), testData.proxyType, testData.limit)
+	assert.Equal(t, testData.output, output)
+	assert.Equal(t, testData.total, total)
+	assert.True(t, errors.Is(err, testData.err))
+
+}
+
+func testDaoGetTopCountriesConnectionError(t *testing.T) {
+	type test struct {
+		limit  int
+		rows   *sqlmock.Rows
+		output []CountryIpCount
+		err    error
+	}
+
+	rowsWithError := getTopCountriesRows().RowError(0, errors.New("connection error"))
+	testData := test{limit: 2, rows: rowsWithError, output: []CountryIpCount{}, err: common.ErrorInternalServer}
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta(getTopCountriesQuery)).WithArgs(testData.limit).WillReturnRows(testData.rows)
+
+	output, _, err := mockDao.GetTopCountries(context.Background(), "", testData.limit)
+	assert.Equal(t, testData.output, output)
+	assert.True(t, errors.Is(err, testData.err))
+
+}
+
+// RunQuery
+
+func testDaoRunQueryGroupedNoError(t *testing.T) {
+	type test struct {
+		query       Query
+		expectedSQL string
+		rows        *sqlmock.Rows
+		output      []GroupedIpCount
+		total       int64
+		err         error
+	}
+
+	testData := test{
+		query: Query{Filters: map[string]string{"proxy_type": "VPN", "country_code": "CH"}, GroupBy: []string{"isp"}, Order: OrderIpCountDesc, Limit: 20},
+		expectedSQL: "SELECT isp, COALESCE(SUM(ip_to - ip_from + 1), 0) as ip_count, COALESCE(SUM(SUM(ip_to - ip_from + 1)) OVER (), 0) as total FROM " + ipdataSchemaTableName +
+			" WHERE country_code = $1 AND proxy_type = $2 GROUP BY isp ORDER BY ip_count DESC, isp LIMIT $3",
+		rows:   sqlmock.NewRows([]string{"isp", "ip_count", "total"}).AddRow("Swisscom", 30, 40).AddRow("Sunrise", 10, 40),
+		output: []GroupedIpCount{{Group: map[string]string{"isp": "Swisscom"}, IpCount: 30}, {Group: map[string]string{"isp": "Sunrise"}, IpCount: 10}},
+		total:  40,
+		err:    nil,
+	}
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta(testData.expectedSQL)).WithArgs("CH", "VPN", 20).WillReturnRows(testData.rows)
+
+	output, total, err := mockDao.RunQuery(context.Background(), testData.query)
+	assert.Equal(t, testData.output, output)
+	assert.Equal(t, testData.total, total)
+	assert.True(t, errors.Is(err, testData.err))
+
+}
+
+func testDaoRunQueryNotGroupedNoError(t *testing.T) {
+	type test struct {
+		query       Query
+		expectedSQL string
+		rows        *sqlmock.Rows
+		output      []GroupedIpCount
+		total       int64
+		err         error
+	}
+
+	testData := test{
+		query: Query{Filters: map[string]string{"proxy_type": "TOR"}, Order: OrderIpCountDesc, Limit: 10},
+		expectedSQL: "SELECT COALESCE(SUM(ip_to - ip_from + 1), 0) as ip_count, COALESCE(SUM(SUM(ip_to - ip_from + 1)) OVER (), 0) as total FROM " + ipdataSchemaTableName +
+			" WHERE proxy_type = $1 LIMIT $2",
+		rows:   sqlmock.NewRows([]string{"ip_count", "total"}).AddRow(7, 7),
+		output: []GroupedIpCount{{Group: map[string]string{}, IpCount: 7}},
+		total:  7,
+		err:    nil,
+	}
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta(testData.expectedSQL)).WithArgs("TOR", 10).WillReturnRows(testData.rows)
+
+	output, total, err := mockDao.RunQuery(context.Background(), testData.query)
+	assert.Equal(t, testData.output, output)
+	assert.Equal(t, testData.total, total)
+	assert.True(t, errors.Is(err, testData.err))
+
+}
+
+func testDaoRunQueryNotWhitelistedError(t *testing.T) {
+	type test struct {
+		query  Query
+		output []GroupedIpCount
+		err    error
+	}
+
+	testData := test{
+		query:  Query{Filters: map[string]string{"1=1; --": "x"}, Limit: 10},
+		output: []GroupedIpCount{},
+		err:    common.ErrorBadRequest,
+	}
+	mockDB, _ := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	output, _, err := mockDao.RunQuery(context.Background(), testData.query)
+	assert.Equal(t, testData.output, output)
+	assert.True(t, errors.Is(err, testData.err))
+
+}
+
+func testDaoRunQueryConnectionError(t *testing.T) {
+	type test struct {
+		query  Query
+		rows   *sqlmock.Rows
+		output []GroupedIpCount
+		err    error
+	}
+
+	rowsWithError := sqlmock.NewRows([]string{"proxy_type", "ip_count", "total"}).AddRow("VPN", 1, 1).RowError(0, errors.New("connection error"))
+	testData := test{
+		query:  Query{GroupBy: []string{"proxy_type"}, Order: OrderGroupAsc, Limit: 10},
+		rows:   rowsWithError,
+		output: []GroupedIpCount{},
+		err:    common.ErrorInternalServer,
+	}
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta("GROUP BY proxy_type ORDER BY proxy_type LIMIT $1")).WithArgs(10).WillReturnRows(testData.rows)
+
+	output, _, err := mockDao.RunQuery(context.Background(), testData.query)
+	assert.Equal(t, testData.output, output)
+	assert.True(t, errors.Is(err, testData.err))
+
+}
+
+// SearchIsp
+
+func testDaoSearchIspNoError(t *testing.T) {
+	type test struct {
+		text        string
+		escaped     string
+		countryCode string
+		limit       int
+		rows        *sqlmock.Rows
+		output      []IspMatch
+		err         error
+	}
+
+	testData := test{
+		text:        "swiss_com",
+		escaped:     `swiss\_com`,
+		countryCode: "CH",
+		limit:       10,
+		rows:        sqlmock.NewRows([]string{"isp", "ip_count", "match_rank"}).AddRow("Swiss_com AG", 40, 0).AddRow("Swisscom", 10, 2),
+		output:      []IspMatch{{Isp: "Swiss_com AG", IpCount: 40, Match: MatchPrefix}, {Isp: "Swisscom", IpCount: 10, Match: MatchFuzzy}},
+		err:         nil,
+	}
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta(searchIspQuery)).WithArgs(testData.escaped, testData.countryCode, trigramSimilarityThreshold, testData.limit, testData.text).WillReturnRows(testData.rows)
+
+	output, err := mockDao.SearchIsp(context.Background(), testData.text, testData.countryCode, testData.limit)
+	assert.Equal(t, testData.output, output)
+	assert.True(t, errors.Is(err, testData.err))
+
+}
+
+func testDaoSearchIspConnectionError(t *testing.T) {
+	type test struct {
+		text   string
+		limit  int
+		rows   *sqlmock.Rows
+		output []IspMatch
+		err    error
+	}
+
+	rowsWithError := sqlmock.NewRows([]string{"isp", "ip_count", "match_rank"}).AddRow("Swisscom", 10, 1).RowError(0, errors.New("connection error"))
+	testData := test{text: "swiss", limit: 10, rows: rowsWithError, output: []IspMatch{}, err: common.ErrorInternalServer}
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta(searchIspQuery)).WithArgs(testData.text, "", trigramSimilarityThreshold, testData.limit, testData.text).WillReturnRows(testData.rows)
+
+	output, err := mockDao.SearchIsp(context.Background(), testData.text, "", testData.limit)
+	assert.Equal(t, testData.output, output)
+	assert.True(t, errors.Is(err, testData.err))
+
+}
+
+// Autocomplete
+
+func testDaoAutocompleteNoError(t *testing.T) {
+	type test struct {
+		field       string
+		prefix      string
+		countryCode string
+		limit       int
+		rows        *sqlmock.Rows
+		output      []Suggestion
+		err         error
+	}
+
+	testData := test{
+		field:       AutocompleteCityName,
+		prefix:      "Zu",
+		countryCode: "CH",
+		limit:       5,
+		rows:        sqlmock.NewRows([]string{"city_name", "ip_count"}).AddRow("Zurich", 300).AddRow("Zug", 20),
+		output:      []Suggestion{{Value: "Zurich", IpCount: 300}, {Value: "Zug", IpCount: 20}},
+		err:         nil,
+	}
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	expectedQuery := "SELECT city_name, SUM(ip_to - ip_from + 1) as ip_count FROM " + ipdataSchemaTableName +
+		" WHERE ($2 = '' OR country_code = $2) AND city_name ILIKE $1 || '%' GROUP BY city_name ORDER BY ip_count DESC LIMIT $3"
+	mockHandler.ExpectQuery(regexp.QuoteMeta(expectedQuery)).WithArgs(testData.prefix, testData.countryCode, testData.limit).WillReturnRows(testData.rows)
+
+	output, err := mockDao.Autocomplete(context.Background(), testData.field, testData.prefix, testData.countryCode, testData.limit)
+	assert.Equal(t, testData.output, output)
+	assert.True(t, errors.Is(err, testData.err))
+
+}
+
+func testDaoAutocompleteInvalidFieldError(t *testing.T) {
+	mockDB, _ := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	output, err := mockDao.Autocomplete(context.Background(), "isp", "a", "", 5)
+	assert.Equal(t, []Suggestion{}, output)
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+
+}
+
+// GetRangesByIsp
+
+func testDaoGetRangesByIspNoError(t *testing.T) {
+	type test struct {
+		isp         string
+		countryCode string
+		limit       int
+		offset      int
+		rows        *sqlmock.Rows
+		output      []IpRange
+		err         error
+	}
+
+	testData := test{
+		isp:         "IPXO Limited",
+		countryCode: "GB",
+		limit:       2,
+		offset:      4,
+		rows:        sqlmock.NewRows([]string{"ip_from", "ip_to", "proxy_type", "country_code"}).AddRow(95781810, 95781817, "VPN", "GB"),
+		output:      []IpRange{{IpFrom: 95781810, IpTo: 95781817, ProxyType: "VPN", CountryCode: "GB"}},
+		err:         nil,
+	}
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta(getRangesByIspQuery)).WithArgs(testData.isp, testData.countryCode, testData.limit, testData.offset).WillReturnRows(testData.rows)
+
+	output, err := mockDao.GetRangesByIsp(context.Background(), testData.isp, testData.countryCode, testData.limit, testData.offset)
+	assert.Equal(t, testData.output, output)
+	assert.True(t, errors.Is(err, testData.err))
+
+}
+
+func testDaoGetRangesByIspConnectionError(t *testing.T) {
+	type test struct {
+		isp    string
+		rows   *sqlmock.Rows
+		output []IpRange
+		err    error
+	}
+
+	rowsWithError := sqlmock.NewRows([]string{"ip_from", "ip_to", "proxy_type", "country_code"}).AddRow(1, 2, "VPN", "GB").RowError(0, errors.New("connection error"))
+	testData := test{isp: "IPXO Limited", rows: rowsWithError, output: []IpRange{}, err: common.ErrorInternalServer}
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta(getRangesByIspQuery)).WithArgs(testData.isp, "", 10, 0).WillReturnRows(testData.rows)
+
+	output, err := mockDao.GetRangesByIsp(context.Background(), testData.isp, "", 10, 0)
+	assert.Equal(t, testData.output, output)
+	assert.True(t, errors.Is(err, testData.err))
+
+}
+
+// GetPreviousRanges and GetNextRanges
+
+func testDaoGetPreviousRangesNoError(t *testing.T) {
+	rows := sqlmock.NewRows([]string{"ip_from", "ip_to", "proxy_type", "country_code", "isp"}).
+		AddRow(95781810, 95781817, "VPN", "GB", "IPXO Limited").
+		AddRow(95781800, 95781809, "TOR", "GB", "IPXO Limited")
+	expected := []IpRange{
+		{IpFrom: 95781810, IpTo: 95781817, ProxyType: "VPN", CountryCode: "GB", Isp: "IPXO Limited"},
+		{IpFrom: 95781800, IpTo: 95781809, ProxyType: "TOR", CountryCode: "GB", Isp: "IPXO Limited"},
+	}
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta(getPreviousRangesQuery)).WithArgs(int64(95781812), 3).WillReturnRows(rows)
+
+	output, err := mockDao.GetPreviousRanges(context.Background(), netip.MustParseAddr("5.181.131.180"), 3)
+	assert.Equal(t, expected, output)
+	assert.Nil(t, err)
+}
+
+func testDaoGetNextRangesNoError(t *testing.T) {
+	rows := sqlmock.NewRows([]string{"ip_from", "ip_to", "proxy_type", "country_code", "isp"}).
+		AddRow(95781818, 95781820, "DCH", "NL", "Leaseweb")
+	expected := []IpRange{{IpFrom: 95781818, IpTo: 95781820, ProxyType: "DCH", CountryCode: "NL", Isp: "Leaseweb"}}
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta(getNextRangesQuery)).WithArgs(int64(95781812), 2).WillReturnRows(rows)
+
+	output, err := mockDao.GetNextRanges(context.Background(), netip.MustParseAddr("5.181.131.180"), 2)
+	assert.Equal(t, expected, output)
+	assert.Nil(t, err)
+}
+
+func testDaoGetNextRangesConnectionError(t *testing.T) {
+	rowsWithError := sqlmock.NewRows([]string{"ip_from", "ip_to", "proxy_type", "country_code", "isp"}).
+		AddRow(1, 2, "VPN", "GB", "IPXO Limited").RowError(0, errors.New("connection error"))
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta(getNextRangesQuery)).WithArgs(int64(95781812), 2).WillReturnRows(rowsWithError)
+
+	output, err := mockDao.GetNextRanges(context.Background(), netip.MustParseAddr("5.181.131.180"), 2)
+	assert.Equal(t, []IpRange{}, output)
+	assert.True(t, errors.Is(err, common.ErrorInternalServer))
+}
+
+func testDaoGetPreviousRangesIpv6Error(t *testing.T) {
+	mockDB, _ := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	output, err := mockDao.GetPreviousRanges(context.Background(), netip.MustParseAddr("2a00:1450::1"), 2)
+	assert.Equal(t, []IpRange{}, output)
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+}
+
+// WalkRanges
+
+func testDaoWalkRangesNoError(t *testing.T) {
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta(walkRangesQuery)).WillReturnRows(getIpDataRows())
+
+	walked := make([]IpData, 0)
+	err := mockDao.WalkRanges(context.Background(), func(data IpData) error {
+		walked = append(walked, data)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []IpData{mockIpDataDao}, walked)
+}
+
+func testDaoWalkRangesCallbackError(t *testing.T) {
+	fnErr := errors.New("stop")
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta(walkRangesQuery)).WillReturnRows(getIpDataRows())
+
+	err := mockDao.WalkRanges(context.Background(), func(IpData) error { return fnErr })
+	assert.True(t, errors.Is(err, fnErr))
+}
+
+func testDaoWalkRangesConnectionError(t *testing.T) {
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta(walkRangesQuery)).WillReturnRows(getIpDataRows().RowError(0, errors.New("connection error")))
+
+	err := mockDao.WalkRanges(context.Background(), func(IpData) error { return nil })
+	assert.True(t, errors.Is(err, common.ErrorInternalServer))
+}
+
+// GetBlocklistRanges
+
+func testDaoGetBlocklistRangesNoError(t *testing.T) {
+	type test struct {
+		filter BlocklistFilter
+		rows   *sqlmock.Rows
+		output []IpRange
+		err    error
+	}
+
+	testData := test{
+		filter: BlocklistFilter{ProxyTypes: []string{"VPN", "TOR"}},
+		rows:   sqlmock.NewRows([]string{"ip_from", "ip_to"}).AddRow(10, 19).AddRow(20, 29),
+		output: []IpRange{{IpFrom: 10, IpTo: 19}, {IpFrom: 20, IpTo: 29}},
+		err:    nil,
+	}
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta(getBlocklistRangesQuery)).
+		WithArgs(pq.Array([]string{"VPN", "TOR"}), pq.Array([]string{}), "").
+		WillReturnRows(testData.rows)
+
+	output, err := mockDao.GetBlocklistRanges(context.Background(), testData.filter)
+	assert.Equal(t, testData.output, output)
+	assert.True(t, errors.Is(err, testData.err))
+
+}
+
+func testDaoGetBlocklistRangesConnectionError(t *testing.T) {
+	type test struct {
+		filter BlocklistFilter
+		rows   *sqlmock.Rows
+		output []IpRange
+		err    error
+	}
+
+	rowsWithError := sqlmock.NewRows([]string{"ip_from", "ip_to"}).AddRow(10, 19).RowError(0, errors.New("connection error"))
+	testData := test{filter: BlocklistFilter{CountryCodes: []string{"CH"}}, rows: rowsWithError, output: []IpRange{}, err: common.ErrorInternalServer}
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta(getBlocklistRangesQuery)).
+		WithArgs(pq.Array([]string{}), pq.Array([]string{"CH"}), "").
+		WillReturnRows(testData.rows)
+
+	output, err := mockDao.GetBlocklistRanges(context.Background(), testData.filter)
+	assert.Equal(t, testData.output, output)
+	assert.True(t, errors.Is(err, testData.err))
+
+}
+
 // mock utils
 
+var mockCountryIpCountDao = []CountryIpCount{
+	{CountryCode: "US", IpCount: 60},
+	{CountryCode: "DE", IpCount: 25},
+}
+
+func getTopCountriesRows() *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"country_code", "ip_count", "total"})
+	for _, count := range mockCountryIpCountDao {
+		rows.AddRow(count.CountryCode, count.IpCount, 100)
+	}
+
+	return rows
+}
+
+var mockGroupedIpCountDao = []GroupedIpCount{
+	{Group: map[string]string{"proxy_type": "VPN", "city_name": "Dublin"}, IpCount: 30},
+	{Group: map[string]string{"proxy_type": "PUB", "city_name": "Cork"}, IpCount: 10},
+}
+
+func getIpSumByCountryGroupedRows() *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"proxy_type", "city_name", "ip_count"})
+	for _, count := range mockGroupedIpCountDao {
+		rows.AddRow(count.Group["proxy_type"], count.Group["city_name"], count.IpCount)
+	}
+
+	return rows
+}
+
 var mockIpDataDao = IpData{
 	IpFrom:      2130706433,
 	IpTo:        2130706433,
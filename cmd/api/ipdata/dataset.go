@@ -0,0 +1,262 @@
+package ipdata
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ipv4MappedBase is the decimal value of ::ffff:0.0.0.0, the IPv6 files hold the ipv4 ranges after it
+	ipv4MappedBase = 0xffff00000000
+
+	// binHeaderSize is the size of the IP2Proxy BIN header fields read by LoadBIN
+	binHeaderSize = 29
+
+	// notProxyValue fills the fields of the IP2Proxy rows of the addresses that are not proxies, the BIN files cover
+	// the whole ipv4 space with them
+	notProxyValue = "-"
+)
+
+// positions (1 based, 0 means missing) of the fields of an IP2Proxy BIN row, indexed by the database type (PX1...PX12)
+var (
+	binProxyTypePosition = [13]int{0, 0, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2}
+	binCountryPosition   = [13]int{0, 2, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3}
+	binRegionPosition    = [13]int{0, 0, 0, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4}
+	binCityPosition      = [13]int{0, 0, 0, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5}
+	binIspPosition       = [13]int{0, 0, 0, 0, 6, 6, 6, 6, 6, 6, 6, 6, 6}
+)
+
+// LoadDatasetFile loads an IP2Proxy CSV or BIN file, chosen by its extension. Only the ipv4 ranges are loaded
+func LoadDatasetFile(path string) ([]IpData, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return []IpData{}, err
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return LoadCSV(file)
+	case ".bin":
+		return LoadBIN(file)
+	default:
+		return []IpData{}, fmt.Errorf("unknown dataset format %s, a .csv or .bin file is expected", path)
+	}
+}
+
+// LoadCSV reads the ipv4 ranges of an IP2Proxy CSV (PX2 or higher), sorted by ip_from. The columns after isp are
+// ignored, an optional header row and the not proxy rows ("-" proxy type) are skipped. The IPv6 files are accepted, only their ipv4 mapped ranges are kept
+func LoadCSV(r io.Reader) ([]IpData, error) {
+	reader := csv.NewReader(bufio.NewReader(r))
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	strs := newInterner()
+	ranges := make([]IpData, 0)
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return []IpData{}, err
+		}
+		if len(record) < 3 {
+			return []IpData{}, fmt.Errorf("line %d: at least ip_from, ip_to and proxy_type are expected", line)
+		}
+
+		ipFrom, fromErr := strconv.ParseUint(record[0], 10, 64)
+		ipTo, toErr := strconv.ParseUint(record[1], 10, 64)
+		if fromErr != nil || toErr != nil {
+			if line == 1 {
+				// header
+				continue
+			}
+			if isDecimalNumber(record[0]) && isDecimalNumber(record[1]) {
+				// ipv6 range out of uint64
+				continue
+			}
+			return []IpData{}, fmt.Errorf("line %d: invalid ip_from or ip_to", line)
+		}
+		ipFrom, ipTo, isIPv4 := toIPv4Range(ipFrom, ipTo)
+		if !isIPv4 {
+			continue
+		}
+
+		if isNotProxy(record[2]) {
+			continue
+		}
+
+		data := IpData{IpFrom: int64(ipFrom), IpTo: int64(ipTo), ProxyType: strs.get(record[2])}
+		fields := []*string{&data.CountryCode, &data.CountryName, &data.RegionName, &data.CityName, &data.ISP}
+		for i, field := range fields {
+			if 3+i < len(record) {
+				*field = strs.get(record[3+i])
+			}
+		}
+		ranges = append(ranges, data)
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].IpFrom < ranges[j].IpFrom })
+	return ranges, nil
+}
+
+// LoadBIN reads the ipv4 ranges of an IP2Proxy BIN database, sorted by ip_from. The not proxy rows are skipped
+func LoadBIN(r io.ReaderAt) ([]IpData, error) {
+	header := make([]byte, binHeaderSize)
+	_, err := r.ReadAt(header, 0)
+	if err != nil {
+		return []IpData{}, fmt.Errorf("invalid bin header. %w", err)
+	}
+	dbType := int(header[0])
+	dbColumns := int(header[1])
+	ipv4Count := binary.LittleEndian.Uint32(header[5:9])
+	ipv4Base := binary.LittleEndian.Uint32(header[9:13])
+	if dbType < 1 || dbType >= len(binCountryPosition) || dbColumns < 2 {
+		return []IpData{}, fmt.Errorf("unsupported bin database type PX%d", dbType)
+	}
+
+	bin := binReader{r: r, strs: newInterner()}
+	rowSize := uint32(dbColumns) * 4
+	ranges := make([]IpData, 0, ipv4Count)
+	// each row only holds its ip_from, the ip_to is the ip_from of the next row minus one
+	for i := uint32(0); i < ipv4Count; i++ {
+		rowOffset := ipv4Base + i*rowSize
+		row, err := bin.bytes(rowOffset, rowSize)
+		if err != nil {
+			return []IpData{}, fmt.Errorf("error reading bin row %d. %w", i, err)
+		}
+		ipFrom := binary.LittleEndian.Uint32(row[0:4])
+		ipTo := uint64(maxIPv4Decimal)
+		next, err := bin.bytes(rowOffset+rowSize, 4)
+		if err == nil {
+			nextFrom := uint64(binary.LittleEndian.Uint32(next))
+			// the last range ends in 255.255.255.255 but its next row also starts there
+			if nextFrom > uint64(ipFrom) && nextFrom < maxIPv4Decimal {
+				ipTo = nextFrom - 1
+			}
+		} else if i < ipv4Count-1 {
+			return []IpData{}, fmt.Errorf("error reading bin row %d. %w", i+1, err)
+		}
+
+		data := IpData{IpFrom: int64(ipFrom), IpTo: int64(ipTo)}
+		data.ProxyType, err = bin.field(row, binProxyTypePosition[dbType], 0)
+		if err == nil {
+			data.CountryCode, err = bin.field(row, binCountryPosition[dbType], 0)
+		}
+		if err == nil {
+			data.CountryName, err = bin.field(row, binCountryPosition[dbType], 3)
+		}
+		if err == nil {
+			data.RegionName, err = bin.field(row, binRegionPosition[dbType], 0)
+		}
+		if err == nil {
+			data.CityName, err = bin.field(row, binCityPosition[dbType], 0)
+		}
+		if err == nil {
+			data.ISP, err = bin.field(row, binIspPosition[dbType], 0)
+		}
+		if err != nil {
+			return []IpData{}, fmt.Errorf("error reading bin row %d. %w", i, err)
+		}
+		// PX1 has no proxy type, its not proxy rows have no country
+		marker := data.ProxyType
+		if binProxyTypePosition[dbType] == 0 {
+			marker = data.CountryCode
+		}
+		if isNotProxy(marker) {
+			continue
+		}
+		ranges = append(ranges, data)
+	}
+
+	return ranges, nil
+}
+
+// isNotProxy is true for the proxy type of the rows that are not proxies, they are missing from the database
+// so their ips are not found as well
+func isNotProxy(proxyType string) bool {
+	proxyType = strings.TrimSpace(proxyType)
+	return proxyType == "" || proxyType == notProxyValue
+}
+
+type binReader struct {
+	r    io.ReaderAt
+	strs interner
+}
+
+// bytes reads size bytes at the 1 based offset of the BIN format
+func (b binReader) bytes(offset uint32, size uint32) ([]byte, error) {
+	data := make([]byte, size)
+	_, err := b.r.ReadAt(data, int64(offset)-1)
+	return data, err
+}
+
+// field reads the string pointed by the column at position of the row, skip is added to the pointer
+// (the country name is stored 3 bytes after the country code)
+func (b binReader) field(row []byte, position int, skip uint32) (string, error) {
+	if position == 0 {
+		return "", nil
+	}
+	pointer := binary.LittleEndian.Uint32(row[(position-1)*4:position*4]) + skip
+	length := make([]byte, 1)
+	_, err := b.r.ReadAt(length, int64(pointer))
+	if err != nil {
+		return "", err
+	}
+	value := make([]byte, length[0])
+	_, err = b.r.ReadAt(value, int64(pointer)+1)
+	if err != nil {
+		return "", err
+	}
+	return b.strs.get(string(value)), nil
+}
+
+// toIPv4Range returns the range in ipv4 decimal format, false if it is not an ipv4 or ipv4 mapped range
+func toIPv4Range(ipFrom uint64, ipTo uint64) (uint64, uint64, bool) {
+	if ipTo <= maxIPv4Decimal {
+		return ipFrom, ipTo, true
+	}
+	if ipFrom >= ipv4MappedBase && ipTo <= ipv4MappedBase+maxIPv4Decimal {
+		return ipFrom - ipv4MappedBase, ipTo - ipv4MappedBase, true
+	}
+	return 0, 0, false
+}
+
+func isDecimalNumber(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// interner shares the memory of the repeated values (countries, cities, ISPs...) of a dataset
+type interner map[string]string
+
+func newInterner() interner {
+	return make(interner)
+}
+
+func (i interner) get(value string) string {
+	if interned, found := i[value]; found {
+		return interned
+	}
+	// the csv reader values share the memory of their whole line
+	value = string([]byte(value))
+	i[value] = value
+	return value
+}
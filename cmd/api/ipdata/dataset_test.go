@@ -0,0 +1,175 @@
+package ipdata
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestDataset_LoadCSV(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "IP2Proxy PX7 rows", TestFn: testLoadCSVRows},
+		{Scenario: "IPv6 file only keeps ipv4 mapped ranges", TestFn: testLoadCSVIPv6File},
+		{Scenario: "Invalid row error", TestFn: testLoadCSVInvalidRowError},
+		{Scenario: "Not proxy rows skipped", TestFn: testLoadCSVNotProxyRows},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestDataset_LoadBIN(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "PX4 rows", TestFn: testLoadBINRows},
+		{Scenario: "PX1 not proxy rows skipped", TestFn: testLoadBINCountryOnlyRows},
+		{Scenario: "Unsupported type error", TestFn: testLoadBINUnsupportedTypeError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func testLoadCSVRows(t *testing.T) {
+	input := `"ip_from","ip_to","proxy_type","country_code","country_name","region_name","city_name","isp","domain","usage_type","asn","as"
+"95781810","95781817","VPN","GB","United Kingdom of Great Britain and Northern Ireland","England","London","IPXO Limited","ipxo.com","DCH","-","-"
+"16777216","16777471","PUB","AU","Australia","Queensland","Brisbane","APNIC and Cloudflare DNS Resolver Project","cloudflare.com","CDN","13335","Cloudflare Inc"
+`
+	ranges, err := LoadCSV(strings.NewReader(input))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []IpData{
+		{IpFrom: 16777216, IpTo: 16777471, ProxyType: "PUB", CountryCode: "AU", CountryName: "Australia", RegionName: "Queensland", CityName: "Brisbane", ISP: "APNIC and Cloudflare DNS Resolver Project"},
+		{IpFrom: 95781810, IpTo: 95781817, ProxyType: "VPN", CountryCode: "GB", CountryName: "United Kingdom of Great Britain and Northern Ireland", RegionName: "England", CityName: "London", ISP: "IPXO Limited"},
+	}, ranges)
+}
+
+func testLoadCSVIPv6File(t *testing.T) {
+	input := `"281470777525170","281470777525177","VPN","GB","United Kingdom of Great Britain and Northern Ireland","England","London","IPXO Limited"
+"42540766411282592856903984951653826560","42540766411282592875350729025363378175","PUB","US","United States of America","California","Los Angeles","Example"
+`
+	ranges, err := LoadCSV(strings.NewReader(input))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []IpData{
+		{IpFrom: 95781810, IpTo: 95781817, ProxyType: "VPN", CountryCode: "GB", CountryName: "United Kingdom of Great Britain and Northern Ireland", RegionName: "England", CityName: "London", ISP: "IPXO Limited"},
+	}, ranges)
+}
+
+func testLoadCSVInvalidRowError(t *testing.T) {
+	input := `"1","2","VPN"
+"3","four","VPN"
+`
+	ranges, err := LoadCSV(strings.NewReader(input))
+
+	assert.NotNil(t, err)
+	assert.Equal(t, []IpData{}, ranges)
+}
+
+func testLoadCSVNotProxyRows(t *testing.T) {
+	input := `"0","16777215","-","-","-","-","-","-"
+"16777216","16777471","PUB","AU","Australia","Queensland","Brisbane","Cloudflare"
+"16777472","16777727","","","","","",""
+`
+	ranges, err := LoadCSV(strings.NewReader(input))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []IpData{
+		{IpFrom: 16777216, IpTo: 16777471, ProxyType: "PUB", CountryCode: "AU", CountryName: "Australia", RegionName: "Queensland", CityName: "Brisbane", ISP: "Cloudflare"},
+	}, ranges)
+}
+
+func testLoadBINRows(t *testing.T) {
+	bin := buildMockBIN(4, []IpData{
+		{IpFrom: 16777216, ProxyType: "PUB", CountryCode: "AU", CountryName: "Australia", RegionName: "Queensland", CityName: "Brisbane", ISP: "Cloudflare"},
+		{IpFrom: 16777472, ProxyType: "-", CountryCode: "-", CountryName: "-", RegionName: "-", CityName: "-", ISP: "-"},
+	})
+
+	ranges, err := LoadBIN(bytes.NewReader(bin))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []IpData{
+		{IpFrom: 16777216, IpTo: 16777471, ProxyType: "PUB", CountryCode: "AU", CountryName: "Australia", RegionName: "Queensland", CityName: "Brisbane", ISP: "Cloudflare"},
+	}, ranges)
+}
+
+func testLoadBINCountryOnlyRows(t *testing.T) {
+	bin := buildMockBIN(1, []IpData{
+		{IpFrom: 0, CountryCode: "-", CountryName: "-"},
+		{IpFrom: 16777216, CountryCode: "AU", CountryName: "Australia"},
+		{IpFrom: 16777472, CountryCode: "-", CountryName: "-"},
+	})
+
+	ranges, err := LoadBIN(bytes.NewReader(bin))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []IpData{{IpFrom: 16777216, IpTo: 16777471, CountryCode: "AU", CountryName: "Australia"}}, ranges)
+}
+
+func testLoadBINUnsupportedTypeError(t *testing.T) {
+	bin := buildMockBIN(4, []IpData{{IpFrom: 1}})
+	bin[0] = 42
+
+	ranges, err := LoadBIN(bytes.NewReader(bin))
+
+	assert.NotNil(t, err)
+	assert.Equal(t, []IpData{}, ranges)
+}
+
+// mock utils
+
+// buildMockBIN writes an IP2Proxy BIN of the given type (PX1 to PX4) with the ipv4 rows and the final 255.255.255.255 row
+func buildMockBIN(dbType int, rows []IpData) []byte {
+	columns := 2 + dbType
+	if dbType < 3 {
+		columns = dbType + 1
+	}
+	rowSize := columns * 4
+	base := 65 // 1 based offset of the first row
+	stringsStart := base - 1 + rowSize*(len(rows)+1)
+
+	var strs bytes.Buffer
+	pointer := func(value string) uint32 {
+		offset := uint32(stringsStart + strs.Len())
+		strs.WriteByte(byte(len(value)))
+		strs.WriteString(value)
+		return offset
+	}
+	// the country name starts 3 bytes after the country code
+	countryPointer := func(code string, name string) uint32 {
+		offset := pointer(code)
+		strs.Write(make([]byte, 2-len(code)))
+		pointer(name)
+		return offset
+	}
+
+	file := make([]byte, stringsStart)
+	file[0] = byte(dbType)
+	file[1] = byte(columns)
+	binary.LittleEndian.PutUint32(file[5:9], uint32(len(rows)))
+	binary.LittleEndian.PutUint32(file[9:13], uint32(base))
+
+	for i, data := range append(rows, IpData{IpFrom: maxIPv4Decimal}) {
+		row := file[base-1+i*rowSize : base-1+(i+1)*rowSize]
+		binary.LittleEndian.PutUint32(row[0:4], uint32(data.IpFrom))
+		if i == len(rows) {
+			break
+		}
+		set := func(position int, value uint32) {
+			if position > 0 {
+				binary.LittleEndian.PutUint32(row[(position-1)*4:position*4], value)
+			}
+		}
+		set(binProxyTypePosition[dbType], pointer(data.ProxyType))
+		set(binCountryPosition[dbType], countryPointer(data.CountryCode, data.CountryName))
+		set(binRegionPosition[dbType], pointer(data.RegionName))
+		set(binCityPosition[dbType], pointer(data.CityName))
+		set(binIspPosition[dbType], pointer(data.ISP))
+	}
+
+	return append(file, strs.Bytes()...)
+}
@@ -0,0 +1,363 @@
+package ipdata
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// changes of a RangeChange
+const (
+	RangeAdded        = "added"
+	RangeRemoved      = "removed"
+	RangeReclassified = "reclassified"
+
+	// ProxyTypeNone is the proxy type of the ips without range in a ProxyTypeTransition
+	ProxyTypeNone = "none"
+)
+
+// DiffHeader is the header of the DatasetDiff rows
+var DiffHeader = []string{"change", "ip_from", "ip_to", "fields", "old", "new"}
+
+// tableNameRegexp accepts a table or schema.table name, the identifiers can't be query params
+var tableNameRegexp = regexp.MustCompile(`^[a-z_][a-z0-9_]*(\.[a-z_][a-z0-9_]*)?$`)
+
+// ValidateTableName returns a bad request error if the table can't be placed in a query
+func ValidateTableName(table string) error {
+	if !tableNameRegexp.MatchString(table) {
+		return fmt.Errorf("invalid table name %s %w", table, common.ErrorBadRequest)
+	}
+	return nil
+}
+
+// RangeWalker walks the ranges of a dataset ordered by ip_from, every Dao is one
+type RangeWalker interface {
+	WalkRanges(ctx context.Context, fn func(data IpData) error) error
+}
+
+type tableWalker struct {
+	db    *sql.DB
+	query string
+}
+
+// NewTableWalker walks the ranges of another table with the columns of the ip2location one, e.g. an imported
+// release not promoted yet
+func NewTableWalker(db *sql.DB, table string) (RangeWalker, error) {
+	err := ValidateTableName(table)
+	if err != nil {
+		return nil, err
+	}
+	return tableWalker{db: db, query: fmt.Sprintf(walkTableQuery, table)}, nil
+}
+
+func (w tableWalker) WalkRanges(ctx context.Context, fn func(data IpData) error) error {
+	return walkRows(ctx, w.db, w.query, fn)
+}
+
+// RangeChange is a range added to, removed from or reclassified by the new dataset. Fields are the changed
+// fields of a reclassified range
+type RangeChange struct {
+	Change       string   `json:"change"`
+	IpFrom       int64    `json:"ip_from"`
+	IpTo         int64    `json:"ip_to"`
+	IpFromString string   `json:"ip_from_string"`
+	IpToString   string   `json:"ip_to_string"`
+	Fields       []string `json:"fields,omitempty"`
+	Old          *IpData  `json:"old,omitempty"`
+	New          *IpData  `json:"new,omitempty"`
+}
+
+// CountChange is the ip count of a country or ISP in both datasets
+type CountChange struct {
+	Key        string `json:"key"`
+	OldIpCount int64  `json:"old_ip_count"`
+	NewIpCount int64  `json:"new_ip_count"`
+	Delta      int64  `json:"delta"`
+}
+
+// ProxyTypeTransition is the number of ips whose proxy type changed from From to To, ProxyTypeNone when the
+// ips have no range
+type ProxyTypeTransition struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	IpCount int64  `json:"ip_count"`
+}
+
+// DatasetDiff is what changed from the old to the new dataset. A range is matched by its ip_from and ip_to, a
+// resized range is removed and added
+type DatasetDiff struct {
+	OldRanges    int64 `json:"old_ranges"`
+	NewRanges    int64 `json:"new_ranges"`
+	OldIps       int64 `json:"old_ips"`
+	NewIps       int64 `json:"new_ips"`
+	Added        int64 `json:"added"`
+	Removed      int64 `json:"removed"`
+	Reclassified int64 `json:"reclassified"`
+	Unchanged    int64 `json:"unchanged"`
+	// Changes are the first changed ranges, by ip_from
+	Changes []RangeChange `json:"changes"`
+	// Countries (by country_code) and Isps are the biggest changes of ip count, by absolute delta
+	Countries []CountChange `json:"countries"`
+	Isps      []CountChange `json:"isps"`
+	// ProxyTypeTransitions are ordered by ip count desc
+	ProxyTypeTransitions []ProxyTypeTransition `json:"proxy_type_transitions"`
+}
+
+// DiffDatasets compares the ranges of both datasets, limit is the number of changed ranges, countries and
+// ISPs listed. The invalid ranges (see ValidateDataset) are skipped
+func DiffDatasets(ctx context.Context, oldDataset RangeWalker, newDataset RangeWalker, limit int) (DatasetDiff, error) {
+	if limit < 0 || limit > maxRangesLimit {
+		return DatasetDiff{}, fmt.Errorf("limit must be between 0 and %d %w", maxRangesLimit, common.ErrorBadRequest)
+	}
+	strs := newInterner()
+	oldRanges, err := collectRanges(ctx, oldDataset, strs)
+	if err != nil {
+		return DatasetDiff{}, err
+	}
+	newRanges, err := collectRanges(ctx, newDataset, strs)
+	if err != nil {
+		return DatasetDiff{}, err
+	}
+
+	diff := DatasetDiff{OldRanges: int64(len(oldRanges)), NewRanges: int64(len(newRanges)), Changes: []RangeChange{}}
+	diff.matchRanges(oldRanges, newRanges, limit)
+
+	oldCountries, oldIsps := make(map[string]int64), make(map[string]int64)
+	for _, data := range oldRanges {
+		diff.OldIps += ipCountOf(data)
+		oldCountries[data.CountryCode] += ipCountOf(data)
+		oldIsps[data.ISP] += ipCountOf(data)
+	}
+	newCountries, newIsps := make(map[string]int64), make(map[string]int64)
+	for _, data := range newRanges {
+		diff.NewIps += ipCountOf(data)
+		newCountries[data.CountryCode] += ipCountOf(data)
+		newIsps[data.ISP] += ipCountOf(data)
+	}
+	diff.Countries = countChanges(oldCountries, newCountries, limit)
+	diff.Isps = countChanges(oldIsps, newIsps, limit)
+	diff.ProxyTypeTransitions = proxyTypeTransitions(oldRanges, newRanges)
+
+	return diff, nil
+}
+
+// collectRanges returns the valid ranges of the dataset ordered by ip_from and ip_to
+func collectRanges(ctx context.Context, dataset RangeWalker, strs interner) ([]IpData, error) {
+	ranges := make([]IpData, 0)
+	err := dataset.WalkRanges(ctx, func(data IpData) error {
+		if data.IpFrom < 0 || data.IpTo > maxIPv4Decimal || data.IpFrom > data.IpTo {
+			return nil
+		}
+		for _, field := range []*string{&data.ProxyType, &data.CountryCode, &data.CountryName, &data.RegionName, &data.CityName, &data.ISP} {
+			*field = strs.get(*field)
+		}
+		ranges = append(ranges, data)
+		return nil
+	})
+	if err != nil {
+		return []IpData{}, err
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return lessRange(ranges[i], ranges[j]) })
+	return ranges, nil
+}
+
+func lessRange(a IpData, b IpData) bool {
+	if a.IpFrom != b.IpFrom {
+		return a.IpFrom < b.IpFrom
+	}
+	return a.IpTo < b.IpTo
+}
+
+// matchRanges merges both ordered lists matching the ranges by ip_from and ip_to
+func (d *DatasetDiff) matchRanges(oldRanges []IpData, newRanges []IpData, limit int) {
+	addChange := func(change string, oldData *IpData, newData *IpData, fields []string) {
+		if len(d.Changes) >= limit {
+			return
+		}
+		data := oldData
+		if data == nil {
+			data = newData
+		}
+		span := newCoverageSpan(data.IpFrom, data.IpTo)
+		d.Changes = append(d.Changes, RangeChange{Change: change, IpFrom: span.IpFrom, IpTo: span.IpTo,
+			IpFromString: span.IpFromString, IpToString: span.IpToString, Fields: fields, Old: oldData, New: newData})
+	}
+
+	i, j := 0, 0
+	for i < len(oldRanges) || j < len(newRanges) {
+		switch {
+		case j == len(newRanges) || (i < len(oldRanges) && lessRange(oldRanges[i], newRanges[j])):
+			d.Removed++
+			addChange(RangeRemoved, &oldRanges[i], nil, nil)
+			i++
+		case i == len(oldRanges) || lessRange(newRanges[j], oldRanges[i]):
+			d.Added++
+			addChange(RangeAdded, nil, &newRanges[j], nil)
+			j++
+		default:
+			fields := changedFields(oldRanges[i], newRanges[j])
+			if len(fields) > 0 {
+				d.Reclassified++
+				addChange(RangeReclassified, &oldRanges[i], &newRanges[j], fields)
+			} else {
+				d.Unchanged++
+			}
+			i++
+			j++
+		}
+	}
+}
+
+func changedFields(oldData IpData, newData IpData) []string {
+	fields := make([]string, 0)
+	for _, column := range []string{DimensionProxyType, "country_code", "country_name", DimensionRegionName, DimensionCityName, DimensionISP} {
+		if columnValue(oldData, column) != columnValue(newData, column) {
+			fields = append(fields, column)
+		}
+	}
+	return fields
+}
+
+// countChanges returns the (limit) biggest changes of ip count by absolute delta, and key asc
+func countChanges(oldCounts map[string]int64, newCounts map[string]int64, limit int) []CountChange {
+	changes := make([]CountChange, 0)
+	for key, oldCount := range oldCounts {
+		if newCounts[key] != oldCount {
+			changes = append(changes, CountChange{Key: key, OldIpCount: oldCount, NewIpCount: newCounts[key], Delta: newCounts[key] - oldCount})
+		}
+	}
+	for key, newCount := range newCounts {
+		if _, found := oldCounts[key]; !found {
+			changes = append(changes, CountChange{Key: key, NewIpCount: newCount, Delta: newCount})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if absInt64(changes[i].Delta) != absInt64(changes[j].Delta) {
+			return absInt64(changes[i].Delta) > absInt64(changes[j].Delta)
+		}
+		return changes[i].Key < changes[j].Key
+	})
+	if len(changes) > limit {
+		changes = changes[:limit]
+	}
+	return changes
+}
+
+// proxyTypeTransitions sweeps the ipv4 space over both ordered lists, from a range boundary to the next one
+func proxyTypeTransitions(oldRanges []IpData, newRanges []IpData) []ProxyTypeTransition {
+	counts := make(map[ProxyTypeTransition]int64)
+	i, j := 0, 0
+	for ip := int64(0); ip <= maxIPv4Decimal; {
+		for i < len(oldRanges) && oldRanges[i].IpTo < ip {
+			i++
+		}
+		for j < len(newRanges) && newRanges[j].IpTo < ip {
+			j++
+		}
+		end := int64(maxIPv4Decimal)
+		oldType, end := proxyTypeAt(oldRanges, i, ip, end)
+		newType, end := proxyTypeAt(newRanges, j, ip, end)
+		if oldType != newType {
+			counts[ProxyTypeTransition{From: oldType, To: newType}] += end - ip + 1
+		}
+		ip = end + 1
+	}
+
+	transitions := make([]ProxyTypeTransition, 0, len(counts))
+	for transition, ipCount := range counts {
+		transition.IpCount = ipCount
+		transitions = append(transitions, transition)
+	}
+	sort.Slice(transitions, func(i, j int) bool {
+		if transitions[i].IpCount != transitions[j].IpCount {
+			return transitions[i].IpCount > transitions[j].IpCount
+		}
+		return transitions[i].From+transitions[i].To < transitions[j].From+transitions[j].To
+	})
+	return transitions
+}
+
+// proxyTypeAt returns the proxy type of the ip, the range i is the first one not ending before it, and lowers
+// end to the last ip with the same proxy type
+func proxyTypeAt(ranges []IpData, i int, ip int64, end int64) (string, int64) {
+	if i == len(ranges) {
+		return ProxyTypeNone, end
+	}
+	if ranges[i].IpFrom > ip {
+		return ProxyTypeNone, minInt64(end, ranges[i].IpFrom-1)
+	}
+	return ranges[i].ProxyType, minInt64(end, ranges[i].IpTo)
+}
+
+func absInt64(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+// Rows returns a changed range per row, to be written as CSV
+func (d DatasetDiff) Rows() [][]string {
+	rows := make([][]string, 0, len(d.Changes))
+	for _, change := range d.Changes {
+		rows = append(rows, []string{change.Change, change.IpFromString, change.IpToString, strings.Join(change.Fields, " "),
+			describeRange(change.Old), describeRange(change.New)})
+	}
+	return rows
+}
+
+// WriteSummary writes the diff as an aligned human-readable summary
+func (d DatasetDiff) WriteSummary(w io.Writer) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(table, "ranges\t%d -> %d\t(%s)\n", d.OldRanges, d.NewRanges, signed(d.NewRanges-d.OldRanges))
+	fmt.Fprintf(table, "ips\t%d -> %d\t(%s)\n", d.OldIps, d.NewIps, signed(d.NewIps-d.OldIps))
+	fmt.Fprintf(table, "changed ranges\t%d added, %d removed, %d reclassified, %d unchanged\n", d.Added, d.Removed, d.Reclassified, d.Unchanged)
+
+	fmt.Fprintf(table, "\nproxy type transitions\n")
+	for _, transition := range d.ProxyTypeTransitions {
+		fmt.Fprintf(table, "  %s -> %s\t%d ips\n", transition.From, transition.To, transition.IpCount)
+	}
+	for _, section := range []struct {
+		name    string
+		changes []CountChange
+	}{{"countries", d.Countries}, {"isps", d.Isps}} {
+		fmt.Fprintf(table, "\n%s\n", section.name)
+		for _, change := range section.changes {
+			fmt.Fprintf(table, "  %s\t%d -> %d\t(%s)\n", change.Key, change.OldIpCount, change.NewIpCount, signed(change.Delta))
+		}
+	}
+	fmt.Fprintf(table, "\nchanges\n")
+	for _, change := range d.Changes {
+		description := describeRange(change.New)
+		switch change.Change {
+		case RangeRemoved:
+			description = describeRange(change.Old)
+		case RangeReclassified:
+			description = strings.Join(change.Fields, ", ") + ": " + describeRange(change.Old) + " -> " + describeRange(change.New)
+		}
+		fmt.Fprintf(table, "  %s %s-%s\t%s\n", change.Change, change.IpFromString, change.IpToString, description)
+	}
+	return table.Flush()
+}
+
+// describeRange joins the proxy type, country code and ISP of the range
+func describeRange(data *IpData) string {
+	if data == nil {
+		return ""
+	}
+	return strings.Join([]string{data.ProxyType, data.CountryCode, data.ISP}, " ")
+}
+
+func signed(value int64) string {
+	if value > 0 {
+		return "+" + strconv.FormatInt(value, 10)
+	}
+	return strconv.FormatInt(value, 10)
+}
@@ -0,0 +1,148 @@
+package ipdata
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/services"
+	"bytes"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+)
+
+func TestDiff_DiffDatasets(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Changes, counts and transitions", TestFn: testDiffDatasetsNoError},
+		{Scenario: "Limit", TestFn: testDiffDatasetsLimit},
+		{Scenario: "Invalid limit", TestFn: testDiffDatasetsInvalidLimit},
+		{Scenario: "Summary", TestFn: testDiffWriteSummary},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestDiff_NewTableWalker(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testNewTableWalkerNoError},
+		{Scenario: "Invalid table name", TestFn: testNewTableWalkerInvalidName},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func testDiffDatasetsNoError(t *testing.T) {
+	diff, err := DiffDatasets(context.Background(), NewMemoryDao(mockOldRelease), NewMemoryDao(mockNewRelease), 10)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), diff.OldRanges)
+	assert.Equal(t, int64(3), diff.NewRanges)
+	assert.Equal(t, int64(30), diff.OldIps)
+	assert.Equal(t, int64(25), diff.NewIps)
+	assert.Equal(t, []int64{1, 1, 1, 1}, []int64{diff.Added, diff.Removed, diff.Reclassified, diff.Unchanged})
+	assert.Equal(t, []RangeChange{
+		{Change: RangeReclassified, IpFrom: 10, IpTo: 19, IpFromString: "0.0.0.10", IpToString: "0.0.0.19",
+			Fields: []string{"proxy_type"}, Old: &mockOldRelease[1], New: &mockNewRelease[1]},
+		{Change: RangeRemoved, IpFrom: 30, IpTo: 39, IpFromString: "0.0.0.30", IpToString: "0.0.0.39", Old: &mockOldRelease[2]},
+		{Change: RangeAdded, IpFrom: 40, IpTo: 44, IpFromString: "0.0.0.40", IpToString: "0.0.0.44", New: &mockNewRelease[2]},
+	}, diff.Changes)
+	assert.Equal(t, []CountChange{{Key: "AR", OldIpCount: 10, Delta: -10}, {Key: "DE", NewIpCount: 5, Delta: 5}}, diff.Countries)
+	assert.Equal(t, []CountChange{{Key: "Telecom Argentina", OldIpCount: 10, Delta: -10}, {Key: "Hetzner", NewIpCount: 5, Delta: 5}}, diff.Isps)
+	assert.Equal(t, []ProxyTypeTransition{
+		{From: "PUB", To: ProxyTypeNone, IpCount: 10},
+		{From: "TOR", To: "VPN", IpCount: 10},
+		{From: ProxyTypeNone, To: "DCH", IpCount: 5},
+	}, diff.ProxyTypeTransitions)
+}
+
+func testDiffDatasetsLimit(t *testing.T) {
+	diff, err := DiffDatasets(context.Background(), NewMemoryDao(mockOldRelease), NewMemoryDao(mockNewRelease), 1)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), diff.Added)
+	assert.Len(t, diff.Changes, 1)
+	assert.Equal(t, [][]string{{"reclassified", "0.0.0.10", "0.0.0.19", "proxy_type", "TOR CH Init7", "VPN CH Init7"}}, diff.Rows())
+	assert.Len(t, diff.Countries, 1)
+}
+
+func testDiffDatasetsInvalidLimit(t *testing.T) {
+	diff, err := DiffDatasets(context.Background(), NewMemoryDao(mockOldRelease), NewMemoryDao(mockNewRelease), -1)
+
+	assert.Equal(t, DatasetDiff{}, diff)
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+}
+
+func testDiffWriteSummary(t *testing.T) {
+	diff, err := DiffDatasets(context.Background(), NewMemoryDao(mockOldRelease), NewMemoryDao(mockNewRelease), 10)
+	assert.Nil(t, err)
+
+	var out bytes.Buffer
+	err = diff.WriteSummary(&out)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "ranges          3 -> 3    (0)\n"+
+		"ips             30 -> 25  (-5)\n"+
+		"changed ranges  1 added, 1 removed, 1 reclassified, 1 unchanged\n"+
+		"\n"+
+		"proxy type transitions\n"+
+		"  PUB -> none  10 ips\n"+
+		"  TOR -> VPN   10 ips\n"+
+		"  none -> DCH  5 ips\n"+
+		"\n"+
+		"countries\n"+
+		"  AR  10 -> 0  (-10)\n"+
+		"  DE  0 -> 5   (+5)\n"+
+		"\n"+
+		"isps\n"+
+		"  Telecom Argentina  10 -> 0  (-10)\n"+
+		"  Hetzner            0 -> 5   (+5)\n"+
+		"\n"+
+		"changes\n"+
+		"  reclassified 0.0.0.10-0.0.0.19  proxy_type: TOR CH Init7 -> VPN CH Init7\n"+
+		"  removed 0.0.0.30-0.0.0.39       PUB AR Telecom Argentina\n"+
+		"  added 0.0.0.40-0.0.0.44         DCH DE Hetzner\n", out.String())
+}
+
+func testNewTableWalkerNoError(t *testing.T) {
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	walker, err := NewTableWalker(mockDB, "proxydata.ip2location_202604")
+	assert.Nil(t, err)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta("FROM proxydata.ip2location_202604 ORDER BY ip_from, ip_to")).WillReturnRows(getIpDataRows())
+
+	walked := make([]IpData, 0)
+	err = walker.WalkRanges(context.Background(), func(data IpData) error {
+		walked = append(walked, data)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []IpData{mockIpDataDao}, walked)
+}
+
+func testNewTableWalkerInvalidName(t *testing.T) {
+	mockDB, _ := services.ConnectToSQLDB(services.MockDB)
+
+	walker, err := NewTableWalker(mockDB, "proxydata.ip2location; DROP TABLE users")
+
+	assert.Nil(t, walker)
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+}
+
+// mock utils
+
+var mockOldRelease = []IpData{
+	{IpFrom: 0, IpTo: 9, ProxyType: "VPN", CountryCode: "CH", ISP: "Swisscom"},
+	{IpFrom: 10, IpTo: 19, ProxyType: "TOR", CountryCode: "CH", ISP: "Init7"},
+	{IpFrom: 30, IpTo: 39, ProxyType: "PUB", CountryCode: "AR", ISP: "Telecom Argentina"},
+}
+
+// mockNewRelease reclassifies 10-19, removes 30-39 and adds 40-44
+var mockNewRelease = []IpData{
+	{IpFrom: 0, IpTo: 9, ProxyType: "VPN", CountryCode: "CH", ISP: "Swisscom"},
+	{IpFrom: 10, IpTo: 19, ProxyType: "VPN", CountryCode: "CH", ISP: "Init7"},
+	{IpFrom: 40, IpTo: 44, ProxyType: "DCH", CountryCode: "DE", ISP: "Hetzner"},
+}
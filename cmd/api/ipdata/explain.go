@@ -0,0 +1,45 @@
+package ipdata
+
+import (
+	"time"
+)
+
+// statuses of an Explanation
+const (
+	ExplainFound          = "found"
+	ExplainNotFound       = "not_found"
+	ExplainSpecialPurpose = "special_purpose"
+)
+
+// backends answering the lookups
+const (
+	BackendPostgres = "postgres"
+	BackendMemory   = "memory"
+)
+
+// Explanation tells why an ip got its data: the dataset row it matched and how the lookup went through the layers
+type Explanation struct {
+	Ip     string `json:"ip"`
+	Status string `json:"status"`
+	// Match is the raw row the ip fell into, with its dotted and CIDR forms
+	Match *IpRange `json:"match,omitempty"`
+	Data  IpData   `json:"data"`
+	// Backend and DatasetVersion are the Dao and the release (when known) that answered
+	Backend        string `json:"backend"`
+	DatasetVersion string `json:"dataset_version,omitempty"`
+	// Overrides are the ids of the local overrides applied to Data
+	Overrides []string          `json:"overrides"`
+	Policy    PolicyExplanation `json:"policy"`
+	Timings   LayerTimings      `json:"timings"`
+}
+
+// LayerTimings is the time spent by each layer in microseconds, each one includes the layers below it
+type LayerTimings struct {
+	HandlerMicros int64 `json:"handler_us"`
+	GatewayMicros int64 `json:"gateway_us"`
+	DaoMicros     int64 `json:"dao_us"`
+}
+
+func microsSince(start time.Time) int64 {
+	return time.Since(start).Microseconds()
+}
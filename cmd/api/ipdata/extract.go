@@ -0,0 +1,122 @@
+package ipdata
+
+import (
+	"net/netip"
+	"strings"
+)
+
+// statuses of an extracted ip
+const (
+	ExtractFound    = "found"
+	ExtractNotFound = "not_found"
+	ExtractSkipped  = "skipped"
+
+	// ReasonIPv6 is the skip reason of the public ipv6 addresses, the special purpose ones are skipped with
+	// their classification
+	ReasonIPv6 = "ipv6 is not present in the dataset"
+
+	// maxExtractedIps is the max number of distinct ips looked up from a text
+	maxExtractedIps = 1000
+)
+
+// ExtractedIP is an ip found in a text with its data, or the reason why it was not looked up
+type ExtractedIP struct {
+	Ip      string `json:"ip"`
+	Version int    `json:"version"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	// SpecialPurpose is the registry entry of the skipped special purpose addresses
+	SpecialPurpose *SpecialPurpose `json:"special_purpose,omitempty"`
+	Data           *IpData         `json:"data,omitempty"`
+}
+
+// extractIPs returns the distinct ipv4 and ipv6 addresses of the text in order of first appearance. An ip followed
+// by a port is accepted, ipv4 mapped ipv6 addresses are given as ipv4
+func extractIPs(text string) []netip.Addr {
+	seen := make(map[netip.Addr]struct{})
+	addrs := make([]netip.Addr, 0)
+	for _, word := range strings.FieldsFunc(text, isNotWordChar) {
+		for _, candidate := range addressCandidates(word) {
+			addr, ok := parseCandidate(candidate)
+			if !ok {
+				continue
+			}
+			if _, found := seen[addr]; found {
+				continue
+			}
+			seen[addr] = struct{}{}
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// addressCandidates splits a word by its colons and joins back the consecutive parts made of hex digits and dots.
+// The parts with other letters are text ("Received:1.1.1.1", "std::vector"), an address can't be glued to them
+func addressCandidates(word string) []string {
+	candidates := make([]string, 0)
+	parts := make([]string, 0)
+	for _, part := range strings.Split(word, ":") {
+		if strings.IndexFunc(part, isNotAddressChar) >= 0 {
+			if len(parts) > 0 {
+				candidates = append(candidates, strings.Join(parts, ":"))
+			}
+			parts = parts[:0]
+			continue
+		}
+		parts = append(parts, part)
+	}
+	if len(parts) > 0 {
+		candidates = append(candidates, strings.Join(parts, ":"))
+	}
+	return candidates
+}
+
+// parseCandidate parses a run of address chars, the dots and colons around it (sentences, "key:value") are ignored.
+// An ipv6 must have a decimal digit and can't end with "::", so hex words ("abc::def") and the "::" of the text
+// are not taken as addresses
+func parseCandidate(candidate string) (netip.Addr, bool) {
+	if !strings.ContainsAny(candidate, ".:") {
+		return netip.Addr{}, false
+	}
+	candidate = strings.Trim(candidate, ".")
+	if strings.HasSuffix(candidate, ":") && !strings.HasSuffix(candidate, "::") {
+		candidate = strings.TrimSuffix(candidate, ":")
+	}
+	if strings.HasPrefix(candidate, ":") && !strings.HasPrefix(candidate, "::") {
+		candidate = strings.TrimPrefix(candidate, ":")
+	}
+
+	if addr, err := netip.ParseAddr(candidate); err == nil {
+		if addr.Is6() && !addr.Is4In6() && !isLikelyIPv6(candidate) {
+			return netip.Addr{}, false
+		}
+		return addr.Unmap(), true
+	}
+	if addrPort, err := netip.ParseAddrPort(candidate); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	// an ipv4 glued to a hex word, e.g. "cafe:1.2.3.4"
+	for _, segment := range strings.Split(candidate, ":") {
+		if addr, err := netip.ParseAddr(segment); err == nil && addr.Is4() {
+			return addr, true
+		}
+	}
+	return netip.Addr{}, false
+}
+
+func isLikelyIPv6(candidate string) bool {
+	return strings.ContainsAny(candidate, "0123456789") && !strings.HasSuffix(candidate, "::")
+}
+
+// isNotWordChar splits the text in words, an address is only taken from a word made of address chars and colons
+func isNotWordChar(c rune) bool {
+	isAlphanumeric := (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+	return !isAlphanumeric && c != '_' && c != '.' && c != ':'
+}
+
+// isNotAddressChar is true for the chars of a word an ip (with port) can't have
+func isNotAddressChar(c rune) bool {
+	isHex := (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+	return !isHex && c != '.'
+}
@@ -0,0 +1,72 @@
+package ipdata
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"github.com/stretchr/testify/assert"
+	"net/netip"
+	"testing"
+)
+
+func TestExtract_ExtractIPs(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Order and duplicates", TestFn: testExtractIPsOrderAndDuplicates},
+		{Scenario: "Ports and punctuation", TestFn: testExtractIPsPortsAndPunctuation},
+		{Scenario: "IPv6 and mapped addresses", TestFn: testExtractIPsIPv6AndMapped},
+		{Scenario: "Not addresses", TestFn: testExtractIPsNotAddresses},
+		{Scenario: "Source code and log lines", TestFn: testExtractIPsSourceCodeAndLogLines},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func testExtractIPsOrderAndDuplicates(t *testing.T) {
+	text := "blocked 8.8.8.8 then 1.1.1.1, again 8.8.8.8"
+
+	assert.Equal(t, []netip.Addr{netip.MustParseAddr("8.8.8.8"), netip.MustParseAddr("1.1.1.1")}, extractIPs(text))
+}
+
+func testExtractIPsPortsAndPunctuation(t *testing.T) {
+	text := "conn from 8.8.8.8:443. Received:1.1.1.1 [9.9.9.9]:53 (4.4.4.4)"
+
+	expected := []netip.Addr{
+		netip.MustParseAddr("8.8.8.8"),
+		netip.MustParseAddr("1.1.1.1"),
+		netip.MustParseAddr("9.9.9.9"),
+		netip.MustParseAddr("4.4.4.4"),
+	}
+	assert.Equal(t, expected, extractIPs(text))
+}
+
+func testExtractIPsIPv6AndMapped(t *testing.T) {
+	text := "client 2001:db8::1 proxied by ::ffff:8.8.8.8 and 8.8.8.8"
+
+	assert.Equal(t, []netip.Addr{netip.MustParseAddr("2001:db8::1"), netip.MustParseAddr("8.8.8.8")}, extractIPs(text))
+}
+
+func testExtractIPsNotAddresses(t *testing.T) {
+	text := "version 1.2.3 at 12:30, 999.1.1.1 and deadbeef"
+
+	assert.Equal(t, []netip.Addr{}, extractIPs(text))
+}
+
+func testExtractIPsSourceCodeAndLogLines(t *testing.T) {
+	type test struct {
+		text     string
+		expected []netip.Addr
+	}
+	testData := []test{
+		{text: "use std::vector and Foo::bar, ERROR:: 1.2.3.4 abc::def", expected: []netip.Addr{netip.MustParseAddr("1.2.3.4")}},
+		{text: "if (a::b) { ::std::cout << x; } // see cafe::babe", expected: []netip.Addr{}},
+		{text: "2026-04-01T09:30:12Z WARN  [auth] login failed user=bob src=5.181.131.180:51522 via fe80::1",
+			expected: []netip.Addr{netip.MustParseAddr("5.181.131.180"), netip.MustParseAddr("fe80::1")}},
+		{text: "Apr  1 09:30:12 host sshd[812]: Failed password from 2001:db8::42 port 22 ssh2",
+			expected: []netip.Addr{netip.MustParseAddr("2001:db8::42")}},
+		{text: "value v1.2.3.4 host_8.8.8.8 node1:: :: Received:9.9.9.9", expected: []netip.Addr{netip.MustParseAddr("9.9.9.9")}},
+	}
+
+	for _, data := range testData {
+		assert.Equal(t, data.expected, extractIPs(data.text), data.text)
+	}
+}
@@ -3,7 +3,19 @@ package ipdata
 import (
 	"DreamLabChallenge/cmd/api/common"
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+const (
+	minSearchLength = 2
+	maxSearchLimit  = 50
+	maxRangesLimit  = 1000
+	maxNeighbors    = 50
 )
 
 //go:generate mockgen -destination=mock_gateway.go -package=ipdata -source=gateway.go Gateway
@@ -15,21 +27,61 @@ type Gateway interface {
 	GetIpCountByCountryName(ctx context.Context, countryName string) (int64, error)
 	// GetTopISPFromSwitzerland returns a list of the top 10 ISPs based on how many IPs does it have
 	GetTopISPFromSwitzerland(ctx context.Context) ([]IspIpCount, error)
-	// GetDataFromIP gets the data associated from the given IP in string(xxx.xxx.xxx.xxx) format
-	GetDataFromIP(ctx context.Context, ip string) (IpData, error)
+	// GetDataFromIP gets the data associated from the given IP
+	GetDataFromIP(ctx context.Context, ip netip.Addr) (IpData, error)
+	// ExplainIP returns the data of the given IP with the row it matched and the timing of the lookup
+	ExplainIP(ctx context.Context, ip netip.Addr) (Explanation, error)
+	// GetIpCountByCountryNameGrouped returns the number of Ips of the given countryName and its breakdown by
+	// one or two of proxy_type, region_name, city_name or isp
+	GetIpCountByCountryNameGrouped(ctx context.Context, countryName string, groupBy []string) (int64, []GroupedIpCount, error)
+	// GetTopCountries returns the top (limit) countries by ip count, filtered by proxyType if not empty
+	GetTopCountries(ctx context.Context, proxyType string, limit int) ([]CountryIpCount, error)
+	// RunQuery validates and runs a filter-and-group query, returning the groups with their share of the filtered total
+	RunQuery(ctx context.Context, query Query) (QueryResult, error)
+	// SearchIsp returns the ISPs matching the given text, filtered by countryCode if not empty
+	SearchIsp(ctx context.Context, text string, countryCode string, limit int) ([]IspMatch, error)
+	// Autocomplete returns the values of country_name, region_name or city_name starting with prefix
+	Autocomplete(ctx context.Context, field string, prefix string, countryCode string, limit int) ([]Suggestion, error)
+	// GetRangesByIsp returns a page of the ranges of the given isp, filtered by countryCode if not empty
+	GetRangesByIsp(ctx context.Context, isp string, countryCode string, limit int, offset int) (IspRangesPage, error)
+	// GetBlocklist returns the minimal list of CIDR blocks covering the ranges selected by the filter
+	GetBlocklist(ctx context.Context, filter BlocklistFilter) ([]string, error)
+	// GetNeighbors returns the n ranges before and after the range (or the gap) holding the given IP
+	GetNeighbors(ctx context.Context, ip netip.Addr, n int) (Neighbors, error)
+	// GetCoverage walks all the ranges and returns the parts of the ipv4 space covered by the dataset, limit is
+	// the number of gaps and overlaps listed
+	GetCoverage(ctx context.Context, limit int) (Coverage, error)
+	// ValidateDataset checks every range of the dataset, limit is the number of issues listed
+	ValidateDataset(ctx context.Context, limit int) (ValidationReport, error)
+	// ExtractIPs returns the ips found in the text with their data, in order of first appearance
+	ExtractIPs(ctx context.Context, text string) ([]ExtractedIP, error)
+	// ListOverrides returns the local overrides ordered by ip_from
+	ListOverrides(ctx context.Context) ([]Override, error)
+	GetOverride(ctx context.Context, id string) (Override, error)
+	// CreateOverride validates and stores a new override, its id is generated
+	CreateOverride(ctx context.Context, override Override) (Override, error)
+	// UpdateOverride validates and replaces the override with the given id
+	UpdateOverride(ctx context.Context, id string, override Override) (Override, error)
+	DeleteOverride(ctx context.Context, id string) error
 }
 
 type gateway struct {
-	dao Dao
+	dao       Dao
+	overrides OverrideStore
 }
 
-func NewGateway(dao Dao) Gateway {
-	return gateway{dao: dao}
+// NewGateway builds the gateway over the dataset of the dao, the lookups apply the overrides of the store. A nil
+// store has no overrides
+func NewGateway(dao Dao, overrides OverrideStore) Gateway {
+	if overrides == nil {
+		overrides, _ = NewOverrideStore("")
+	}
+	return gateway{dao: dao, overrides: overrides}
 }
 
 // GetIspIpsByCountryCode returns the top (limit) ISPs of the (countryCode) given
 func (g gateway) GetIspIpsByCountryCode(ctx context.Context, countryCode string, limit int) ([]IspIpCount, error) {
-	isValid := isValidCountryCode(countryCode)
+	isValid := IsValidCountryCode(countryCode)
 	if !isValid {
 		return []IspIpCount{}, fmt.Errorf("invalid country_code  %w", common.ErrorBadRequest)
 
@@ -67,14 +119,467 @@ func (g gateway) GetTopISPFromSwitzerland(ctx context.Context) ([]IspIpCount, er
 	return sortedISPs, err
 }
 
-// GetDataFromIP gets the data associated from the given IP in string(xxx.xxx.xxx.xxx) format
-func (g gateway) GetDataFromIP(ctx context.Context, ip string) (IpData, error) {
-	ipData, err := g.dao.GetByIp(ctx, stringIPToDecimal(ip))
+// GetDataFromIP gets the data associated from the given IP, IpString is its canonical form (ipv4 mapped
+// addresses are unmapped). the special purpose addresses (private, loopback, cgnat...) are not looked up, their
+// registry entry is returned. the dataset only holds ipv4, other public ips are a common.ErrorBadRequest
+func (g gateway) GetDataFromIP(ctx context.Context, ip netip.Addr) (IpData, error) {
+	ip = ip.Unmap()
+	if specialPurpose, special := LookupSpecialPurpose(ip); special {
+		return g.specialPurposeData(ip, specialPurpose), nil
+	}
+	if !ip.Is4() {
+		return IpData{}, fmt.Errorf("ip %s is not an ipv4, the dataset only holds ipv4 %w", ip, common.ErrorBadRequest)
+	}
+
+	ipData, err := g.dao.GetByIp(ctx, ip)
+	ipData, err = g.applyOverride(ip, ipData, err)
 	if err != nil {
 		err = fmt.Errorf("error getting Ips Ip count.  %w", err)
 		return IpData{}, err
 	}
-	ipData.IpString = ip
+	ipData.IpString = ip.String()
 
 	return ipData, nil
 }
+
+// specialPurposeData answers a special purpose ip, they are not in the dataset but an ipv4 one held by an override
+// (e.g. an office egress range in 10.0.0.0/8) gets it with the range of the override
+func (g gateway) specialPurposeData(ip netip.Addr, specialPurpose SpecialPurpose) IpData {
+	ipData := IpData{IpString: ip.String(), SpecialPurpose: &specialPurpose}
+	if !ip.Is4() {
+		return ipData
+	}
+	decimalIp, _ := AddrToDecimal(ip)
+	override, found := g.overrides.Match(decimalIp)
+	if !found {
+		return ipData
+	}
+	ipData.IpFrom, ipData.IpTo = override.IpFrom, override.IpTo
+	return override.apply(ipData)
+}
+
+// applyOverride replaces the data the dao returned for the ip with the override holding it, an ip not in the
+// dataset gets the range of the override. The dao error is returned when there is no override
+func (g gateway) applyOverride(ip netip.Addr, ipData IpData, daoErr error) (IpData, error) {
+	decimalIp, _ := AddrToDecimal(ip)
+	override, found := g.overrides.Match(decimalIp)
+	if !found || (daoErr != nil && !errors.Is(daoErr, common.ErrorNotFound)) {
+		return ipData, daoErr
+	}
+	if daoErr != nil {
+		ipData = IpData{IpFrom: override.IpFrom, IpTo: override.IpTo, IpString: ipData.IpString}
+	}
+	return override.apply(ipData), nil
+}
+
+// ExplainIP looks up the given IP as GetDataFromIP does, an ip not present in the dataset is explained with
+// the not_found status instead of an error
+func (g gateway) ExplainIP(ctx context.Context, ip netip.Addr) (Explanation, error) {
+	start := time.Now()
+	ip = ip.Unmap()
+	explanation := Explanation{Ip: ip.String(), Backend: g.dao.Backend(), Overrides: []string{}}
+	if specialPurpose, special := LookupSpecialPurpose(ip); special {
+		explanation.Status = ExplainSpecialPurpose
+		explanation.Data = g.specialPurposeData(ip, specialPurpose)
+		if explanation.Data.Override != nil {
+			explanation.Overrides = append(explanation.Overrides, explanation.Data.Override.ID)
+		}
+		explanation.Timings.GatewayMicros = microsSince(start)
+		return explanation, nil
+	}
+	if !ip.Is4() {
+		return Explanation{}, fmt.Errorf("ip %s is not an ipv4, the dataset only holds ipv4 %w", ip, common.ErrorBadRequest)
+	}
+
+	daoStart := time.Now()
+	ipData, err := g.dao.GetByIp(ctx, ip)
+	if err == nil || errors.Is(err, common.ErrorNotFound) {
+		var versionErr error
+		explanation.DatasetVersion, versionErr = g.dao.DatasetVersion(ctx)
+		if versionErr != nil {
+			return Explanation{}, versionErr
+		}
+	}
+	explanation.Timings.DaoMicros = microsSince(daoStart)
+	switch {
+	case errors.Is(err, common.ErrorNotFound):
+		explanation.Status = ExplainNotFound
+		explanation.Data = IpData{IpString: ip.String()}
+	case err != nil:
+		return Explanation{}, err
+	default:
+		ipData.IpString = ip.String()
+		match := EnrichRange(IpRange{IpFrom: ipData.IpFrom, IpTo: ipData.IpTo, ProxyType: ipData.ProxyType, CountryCode: ipData.CountryCode})
+		explanation.Status = ExplainFound
+		explanation.Data = ipData
+		explanation.Match = &match
+	}
+	// the status and match stay the dataset ones, the data is the overridden one
+	explanation.Data, err = g.applyOverride(ip, explanation.Data, err)
+	if err == nil && explanation.Data.Override != nil {
+		explanation.Overrides = append(explanation.Overrides, explanation.Data.Override.ID)
+	}
+
+	explanation.Timings.GatewayMicros = microsSince(start)
+	return explanation, nil
+}
+
+// GetIpCountByCountryNameGrouped returns the number of Ips of the given countryName and its breakdown by
+// one or two of proxy_type, region_name, city_name or isp, with the percentage of the country total of each group
+func (g gateway) GetIpCountByCountryNameGrouped(ctx context.Context, countryName string, groupBy []string) (int64, []GroupedIpCount, error) {
+	isValid := isValidCountryName(countryName)
+	if !isValid {
+		return 0, []GroupedIpCount{}, fmt.Errorf("invalid country_name  %w", common.ErrorBadRequest)
+	}
+	err := validateGroupBy(groupBy)
+	if err != nil {
+		return 0, []GroupedIpCount{}, err
+	}
+
+	groupedCounts, err := g.dao.GetIpSumByCountryGrouped(ctx, countryName, groupBy)
+	if err != nil {
+		return 0, []GroupedIpCount{}, err
+	}
+
+	var total int64
+	for _, group := range groupedCounts {
+		total += group.IpCount
+	}
+	for i := range groupedCounts {
+		groupedCounts[i].Percentage = PercentageOf(groupedCounts[i].IpCount, total)
+	}
+
+	return total, groupedCounts, nil
+}
+
+// GetTopCountries returns the top (limit) countries by ip count, filtered by proxyType if not empty.
+// the share of each country is the percentage of the ips (of the given proxyType) of all countries
+func (g gateway) GetTopCountries(ctx context.Context, proxyType string, limit int) ([]CountryIpCount, error) {
+	if proxyType != "" && !IsValidProxyType(proxyType) {
+		return []CountryIpCount{}, fmt.Errorf("invalid proxy_type  %w", common.ErrorBadRequest)
+	}
+	if limit < 1 || limit > len(countryNamesByCode) {
+		return []CountryIpCount{}, fmt.Errorf("limit must be between 1 and %d %w", len(countryNamesByCode), common.ErrorBadRequest)
+	}
+
+	countryCounts, total, err := g.dao.GetTopCountries(ctx, proxyType, limit)
+	if err != nil {
+		return []CountryIpCount{}, err
+	}
+
+	for i := range countryCounts {
+		countryCounts[i].CountryName = countryNamesByCode[countryCounts[i].CountryCode]
+		countryCounts[i].Share = PercentageOf(countryCounts[i].IpCount, total)
+	}
+
+	return countryCounts, nil
+}
+
+// RunQuery validates and runs a filter-and-group query, returning the groups with their share of the filtered total
+func (g gateway) RunQuery(ctx context.Context, query Query) (QueryResult, error) {
+	query = query.withDefaults()
+	err := query.validate()
+	if err != nil {
+		return QueryResult{}, err
+	}
+
+	groupedCounts, total, err := g.dao.RunQuery(ctx, query)
+	if err != nil {
+		return QueryResult{}, err
+	}
+
+	for i := range groupedCounts {
+		groupedCounts[i].Percentage = PercentageOf(groupedCounts[i].IpCount, total)
+	}
+
+	return QueryResult{TotalIpCount: total, Groups: groupedCounts}, nil
+}
+
+// SearchIsp returns the ISPs matching the given text by prefix, substring or trigram similarity, filtered by
+// countryCode if not empty
+func (g gateway) SearchIsp(ctx context.Context, text string, countryCode string, limit int) ([]IspMatch, error) {
+	text = strings.TrimSpace(text)
+	if len([]rune(text)) < minSearchLength {
+		return []IspMatch{}, fmt.Errorf("search text must have at least %d characters %w", minSearchLength, common.ErrorBadRequest)
+	}
+	err := validateSearchFilters(countryCode, limit)
+	if err != nil {
+		return []IspMatch{}, err
+	}
+
+	return g.dao.SearchIsp(ctx, text, countryCode, limit)
+}
+
+// Autocomplete returns the values of country_name, region_name or city_name starting with prefix, filtered by
+// countryCode if not empty
+func (g gateway) Autocomplete(ctx context.Context, field string, prefix string, countryCode string, limit int) ([]Suggestion, error) {
+	if field != AutocompleteCountryName && field != AutocompleteRegionName && field != AutocompleteCityName {
+		return []Suggestion{}, fmt.Errorf("invalid autocomplete field %s %w", field, common.ErrorBadRequest)
+	}
+	prefix = strings.TrimLeft(prefix, " ")
+	if prefix == "" {
+		return []Suggestion{}, fmt.Errorf("autocomplete prefix can't be empty %w", common.ErrorBadRequest)
+	}
+	err := validateSearchFilters(countryCode, limit)
+	if err != nil {
+		return []Suggestion{}, err
+	}
+
+	return g.dao.Autocomplete(ctx, field, prefix, countryCode, limit)
+}
+
+// GetRangesByIsp returns a page of the ranges of the given isp ordered by ip_from, filtered by countryCode if not
+// empty. Each range is also given as dotted strings and as the minimal list of CIDR blocks covering it
+func (g gateway) GetRangesByIsp(ctx context.Context, isp string, countryCode string, limit int, offset int) (IspRangesPage, error) {
+	if strings.TrimSpace(isp) == "" {
+		return IspRangesPage{}, fmt.Errorf("isp can't be empty %w", common.ErrorBadRequest)
+	}
+	if countryCode != "" && !IsValidCountryCode(countryCode) {
+		return IspRangesPage{}, fmt.Errorf("invalid country_code  %w", common.ErrorBadRequest)
+	}
+	if limit < 1 || limit > maxRangesLimit {
+		return IspRangesPage{}, fmt.Errorf("limit must be between 1 and %d %w", maxRangesLimit, common.ErrorBadRequest)
+	}
+	if offset < 0 {
+		return IspRangesPage{}, fmt.Errorf("offset can't be negative %w", common.ErrorBadRequest)
+	}
+
+	// one extra range is requested to know if there is a next page
+	ranges, err := g.dao.GetRangesByIsp(ctx, isp, countryCode, limit+1, offset)
+	if err != nil {
+		return IspRangesPage{}, err
+	}
+
+	page := IspRangesPage{Isp: isp, Offset: offset, Limit: limit}
+	if len(ranges) > limit {
+		ranges = ranges[:limit]
+		nextOffset := offset + limit
+		page.NextOffset = &nextOffset
+	}
+	for i := range ranges {
+		ranges[i] = EnrichRange(ranges[i])
+	}
+	page.Ranges = ranges
+
+	return page, nil
+}
+
+// GetNeighbors returns the n ranges before and after the range holding the given ipv4, or the gap it falls into
+// when no range holds it. Each range is also given as dotted strings and CIDR blocks
+func (g gateway) GetNeighbors(ctx context.Context, ip netip.Addr, n int) (Neighbors, error) {
+	ip = ip.Unmap()
+	decimal, isIPv4 := AddrToDecimal(ip)
+	if !isIPv4 {
+		return Neighbors{}, fmt.Errorf("ip %s is not an ipv4, the dataset only holds ipv4 %w", ip, common.ErrorBadRequest)
+	}
+	if n < 1 || n > maxNeighbors {
+		return Neighbors{}, fmt.Errorf("n must be between 1 and %d %w", maxNeighbors, common.ErrorBadRequest)
+	}
+
+	// the first previous range is the only one that can hold the ip
+	previous, err := g.dao.GetPreviousRanges(ctx, ip, n+1)
+	if err != nil {
+		return Neighbors{}, err
+	}
+	next, err := g.dao.GetNextRanges(ctx, ip, n)
+	if err != nil {
+		return Neighbors{}, err
+	}
+
+	neighbors := Neighbors{Ip: ip.String()}
+	if len(previous) > 0 && previous[0].IpTo >= decimal {
+		match := EnrichRange(previous[0])
+		neighbors.Match = &match
+		previous = previous[1:]
+	} else {
+		gap := IpRange{IpFrom: 0, IpTo: maxIPv4Decimal}
+		if len(previous) > 0 {
+			gap.IpFrom = previous[0].IpTo + 1
+		}
+		if len(next) > 0 {
+			gap.IpTo = next[0].IpFrom - 1
+		}
+		gap = EnrichRange(gap)
+		neighbors.Gap = &gap
+	}
+	if len(previous) > n {
+		previous = previous[:n]
+	}
+
+	neighbors.Before = make([]IpRange, 0, len(previous))
+	for i := len(previous) - 1; i >= 0; i-- {
+		neighbors.Before = append(neighbors.Before, EnrichRange(previous[i]))
+	}
+	for i := range next {
+		next[i] = EnrichRange(next[i])
+	}
+	neighbors.After = next
+
+	return neighbors, nil
+}
+
+// GetCoverage walks all the ranges by ip_from and returns the gaps, the overlaps and the covered ips in total,
+// per /8, per country and per proxy type. limit is the number of gaps (the biggest) and overlaps (the first) listed
+func (g gateway) GetCoverage(ctx context.Context, limit int) (Coverage, error) {
+	if limit < 0 || limit > maxRangesLimit {
+		return Coverage{}, fmt.Errorf("limit must be between 0 and %d %w", maxRangesLimit, common.ErrorBadRequest)
+	}
+
+	builder := newCoverageBuilder(limit)
+	err := g.dao.WalkRanges(ctx, builder.add)
+	if err != nil {
+		return Coverage{}, err
+	}
+	return builder.build(), nil
+}
+
+// ValidateDataset walks all the ranges by ip_from checking for overlaps, inverted and out of range ranges,
+// country codes, country names and proxy types not in the registries and empty ISPs. limit is the number of
+// issues listed, the report is not Valid when any error is found
+func (g gateway) ValidateDataset(ctx context.Context, limit int) (ValidationReport, error) {
+	return ValidateRanges(ctx, g.dao, limit)
+}
+
+func validateSearchFilters(countryCode string, limit int) error {
+	if countryCode != "" && !IsValidCountryCode(countryCode) {
+		return fmt.Errorf("invalid country_code  %w", common.ErrorBadRequest)
+	}
+	if limit < 1 || limit > maxSearchLimit {
+		return fmt.Errorf("limit must be between 1 and %d %w", maxSearchLimit, common.ErrorBadRequest)
+	}
+	return nil
+}
+
+func validateGroupBy(groupBy []string) error {
+	if len(groupBy) == 0 || len(groupBy) > maxGroupDimensions {
+		return fmt.Errorf("group_by must have between 1 and %d dimensions %w", maxGroupDimensions, common.ErrorBadRequest)
+	}
+	for i, dimension := range groupBy {
+		if !isValidGroupDimension(dimension) {
+			return fmt.Errorf("invalid group_by dimension %s %w", dimension, common.ErrorBadRequest)
+		}
+		if i > 0 && groupBy[i-1] == dimension {
+			return fmt.Errorf("repeated group_by dimension %s %w", dimension, common.ErrorBadRequest)
+		}
+	}
+	return nil
+}
+
+// PercentageOf returns the percentage of count in total rounded to two decimals, 0 when total is 0
+func PercentageOf(count int64, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(count)*10000/float64(total)) / 100
+}
+
+// GetBlocklist returns the minimal list of CIDR blocks covering the ranges selected by the filter, the overlapping
+// and adjacent ranges are merged before the conversion
+func (g gateway) GetBlocklist(ctx context.Context, filter BlocklistFilter) ([]string, error) {
+	err := filter.validate()
+	if err != nil {
+		return []string{}, err
+	}
+
+	ranges, err := g.dao.GetBlocklistRanges(ctx, filter)
+	if err != nil {
+		return []string{}, err
+	}
+
+	return rangesToCIDRs(ranges)
+}
+
+// ExtractIPs returns the distinct ipv4 and ipv6 addresses found in the text in order of first appearance, with their
+// data. The special purpose addresses (private, loopback...) and the ipv6 ones are skipped with the reason
+func (g gateway) ExtractIPs(ctx context.Context, text string) ([]ExtractedIP, error) {
+	addrs := extractIPs(text)
+	if len(addrs) > maxExtractedIps {
+		return []ExtractedIP{}, fmt.Errorf("the text has more than %d distinct ips %w", maxExtractedIps, common.ErrorBadRequest)
+	}
+
+	extracted := make([]ExtractedIP, 0, len(addrs))
+	for _, addr := range addrs {
+		ip := ExtractedIP{Ip: addr.String(), Version: 4}
+		if addr.Is6() {
+			ip.Version = 6
+		}
+		if specialPurpose, special := LookupSpecialPurpose(addr); special {
+			ip.Status = ExtractSkipped
+			ip.Reason = specialPurpose.Classification
+			ip.SpecialPurpose = &specialPurpose
+			extracted = append(extracted, ip)
+			continue
+		}
+		if addr.Is6() {
+			ip.Status = ExtractSkipped
+			ip.Reason = ReasonIPv6
+			extracted = append(extracted, ip)
+			continue
+		}
+
+		data, err := g.GetDataFromIP(ctx, addr)
+		switch {
+		case errors.Is(err, common.ErrorNotFound):
+			ip.Status = ExtractNotFound
+		case err != nil:
+			return []ExtractedIP{}, err
+		default:
+			ip.Status = ExtractFound
+			ip.Data = &data
+		}
+		extracted = append(extracted, ip)
+	}
+
+	return extracted, nil
+}
+
+func (g gateway) ListOverrides(ctx context.Context) ([]Override, error) {
+	return g.overrides.List(), nil
+}
+
+func (g gateway) GetOverride(ctx context.Context, id string) (Override, error) {
+	return g.overrides.Get(id)
+}
+
+// CreateOverride stores the override under a new random id, it applies to the next lookups
+func (g gateway) CreateOverride(ctx context.Context, override Override) (Override, error) {
+	override, err := normalizeOverride(override)
+	if err != nil {
+		return Override{}, err
+	}
+	override.ID, err = newOverrideID()
+	if err != nil {
+		return Override{}, fmt.Errorf("error creating override id. %s %w", err.Error(), common.ErrorInternalServer)
+	}
+	override.UpdatedAt = time.Now().UTC()
+
+	err = g.overrides.Save(override)
+	if err != nil {
+		return Override{}, err
+	}
+	return override, nil
+}
+
+// UpdateOverride replaces every field of the override, the ones missing in override are cleared
+func (g gateway) UpdateOverride(ctx context.Context, id string, override Override) (Override, error) {
+	_, err := g.overrides.Get(id)
+	if err != nil {
+		return Override{}, err
+	}
+	override, err = normalizeOverride(override)
+	if err != nil {
+		return Override{}, err
+	}
+	override.ID = id
+	override.UpdatedAt = time.Now().UTC()
+
+	err = g.overrides.Save(override)
+	if err != nil {
+		return Override{}, err
+	}
+	return override, nil
+}
+
+func (g gateway) DeleteOverride(ctx context.Context, id string) error {
+	return g.overrides.Delete(id)
+}
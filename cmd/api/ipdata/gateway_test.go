@@ -4,8 +4,10 @@ import (
 	"DreamLabChallenge/cmd/api/common"
 	"context"
 	"errors"
+	"fmt"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"net/netip"
 	"testing"
 )
 
@@ -48,6 +50,180 @@ func TestGetDataFromIP(t *testing.T) {
 	tests := []common.TestCase{
 		{Scenario: "No error", TestFn: testGtwGetDataFromIPNoError},
 		{Scenario: "Dao thrown error", TestFn: testGtwGetDataFromIPDbError},
+		{Scenario: "Special purpose address", TestFn: testGtwGetDataFromIPSpecialPurpose},
+		{Scenario: "Public ipv6 error", TestFn: testGtwGetDataFromIPIpv6Error},
+		{Scenario: "Overridden data", TestFn: testGtwGetDataFromIPOverridden},
+		{Scenario: "Override of an ip not in the dataset", TestFn: testGtwGetDataFromIPOverrideNotInDataset},
+		{Scenario: "Override of a private range", TestFn: testGtwGetDataFromIPOverridePrivateRange},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestGateway_GetIpCountByCountryNameGrouped(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testGtwGetIpCountByCountryNameGroupedNoError},
+		{Scenario: "Dao thrown error", TestFn: testGtwGetIpCountByCountryNameGroupedDBError},
+		{Scenario: "Invalid country error", TestFn: testGtwGetIpCountByCountryNameGroupedInvalidCountryError},
+		{Scenario: "Invalid dimension error", TestFn: testGtwGetIpCountByCountryNameGroupedInvalidDimensionError},
+		{Scenario: "Too many dimensions error", TestFn: testGtwGetIpCountByCountryNameGroupedTooManyDimensionsError},
+		{Scenario: "Repeated dimension error", TestFn: testGtwGetIpCountByCountryNameGroupedRepeatedDimensionError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestGateway_GetTopCountries(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testGtwGetTopCountriesNoError},
+		{Scenario: "Dao thrown error", TestFn: testGtwGetTopCountriesDBError},
+		{Scenario: "Invalid proxy type error", TestFn: testGtwGetTopCountriesInvalidProxyTypeError},
+		{Scenario: "Invalid limit error", TestFn: testGtwGetTopCountriesInvalidLimitError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestGateway_RunQuery(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error with defaults", TestFn: testGtwRunQueryNoError},
+		{Scenario: "Dao thrown error", TestFn: testGtwRunQueryDBError},
+		{Scenario: "Invalid filter error", TestFn: testGtwRunQueryInvalidFilterError},
+		{Scenario: "Invalid order error", TestFn: testGtwRunQueryInvalidOrderError},
+		{Scenario: "Limit over max error", TestFn: testGtwRunQueryLimitOverMaxError},
+		{Scenario: "Expensive dimension without filter error", TestFn: testGtwRunQueryExpensiveDimensionError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestGateway_SearchIsp(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testGtwSearchIspNoError},
+		{Scenario: "Short text error", TestFn: testGtwSearchIspShortTextError},
+		{Scenario: "Invalid country code error", TestFn: testGtwSearchIspInvalidCountryCodeError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestGateway_Autocomplete(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testGtwAutocompleteNoError},
+		{Scenario: "Invalid field error", TestFn: testGtwAutocompleteInvalidFieldError},
+		{Scenario: "Invalid limit error", TestFn: testGtwAutocompleteInvalidLimitError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestGateway_GetRangesByIsp(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error with next page", TestFn: testGtwGetRangesByIspNextPage},
+		{Scenario: "No error last page", TestFn: testGtwGetRangesByIspLastPage},
+		{Scenario: "Invalid limit error", TestFn: testGtwGetRangesByIspInvalidLimitError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestGateway_GetBlocklist(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testGtwGetBlocklistNoError},
+		{Scenario: "Empty filter error", TestFn: testGtwGetBlocklistEmptyFilterError},
+		{Scenario: "Invalid proxy type error", TestFn: testGtwGetBlocklistInvalidProxyTypeError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestGateway_ExplainIP(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Found", TestFn: testGtwExplainIPFound},
+		{Scenario: "Not found", TestFn: testGtwExplainIPNotFound},
+		{Scenario: "Special purpose address", TestFn: testGtwExplainIPSpecialPurpose},
+		{Scenario: "DB error", TestFn: testGtwExplainIPDBError},
+		{Scenario: "Overridden data", TestFn: testGtwExplainIPOverridden},
+		{Scenario: "Overridden private range", TestFn: testGtwExplainIPOverridePrivateRange},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestGateway_Overrides(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Create, update and delete", TestFn: testGtwOverridesNoError},
+		{Scenario: "Create invalid override error", TestFn: testGtwCreateOverrideInvalidError},
+		{Scenario: "Update not found error", TestFn: testGtwUpdateOverrideNotFoundError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestGateway_GetNeighbors(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Matching range", TestFn: testGtwGetNeighborsMatch},
+		{Scenario: "Ip in a gap", TestFn: testGtwGetNeighborsGap},
+		{Scenario: "Empty dataset gap is the whole ipv4 space", TestFn: testGtwGetNeighborsEmpty},
+		{Scenario: "Invalid n", TestFn: testGtwGetNeighborsInvalidN},
+		{Scenario: "Ipv6 error", TestFn: testGtwGetNeighborsIpv6Error},
+		{Scenario: "DB error", TestFn: testGtwGetNeighborsDBError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestGateway_GetCoverage(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testGtwGetCoverageNoError},
+		{Scenario: "Invalid limit", TestFn: testGtwGetCoverageInvalidLimit},
+		{Scenario: "DB error", TestFn: testGtwGetCoverageDBError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestGateway_ValidateDataset(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Invalid dataset", TestFn: testGtwValidateDatasetInvalid},
+		{Scenario: "Invalid limit", TestFn: testGtwValidateDatasetInvalidLimit},
+		{Scenario: "DB error", TestFn: testGtwValidateDatasetDBError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestGateway_ExtractIPs(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testGtwExtractIPsNoError},
+		{Scenario: "DB error", TestFn: testGtwExtractIPsDBError},
+		{Scenario: "Too many ips error", TestFn: testGtwExtractIPsTooManyIpsError},
 	}
 
 	for _, testCase := range tests {
@@ -68,7 +244,7 @@ func testGtwGetIspIpsByCountryCodeNoError(t *testing.T) {
 
 	ctrl := gomock.NewController(t)
 	mockDao := NewMockDao(ctrl)
-	gtw := NewGateway(mockDao)
+	gtw := NewGateway(mockDao, nil)
 
 	mockDao.EXPECT().
 		GetTopIspByCountryCode(gomock.Any(), testData.code, testData.limit).
@@ -91,7 +267,7 @@ func testGtwGetIspIpsByCountryCodeDBError(t *testing.T) {
 
 	ctrl := gomock.NewController(t)
 	mockDao := NewMockDao(ctrl)
-	gtw := NewGateway(mockDao)
+	gtw := NewGateway(mockDao, nil)
 
 	mockDao.EXPECT().
 		GetTopIspByCountryCode(gomock.Any(), testData.code, testData.limit).
@@ -114,7 +290,7 @@ func testGtwGetIspIpsByCountryInvalidCodeError(t *testing.T) {
 
 	ctrl := gomock.NewController(t)
 	mockDao := NewMockDao(ctrl)
-	gtw := NewGateway(mockDao)
+	gtw := NewGateway(mockDao, nil)
 
 	output, err := gtw.GetIspIpsByCountryCode(context.Background(), testData.code, testData.limit)
 
@@ -135,7 +311,7 @@ func testGtwGetIpCountByCountryNameNoErrors(t *testing.T) {
 
 	ctrl := gomock.NewController(t)
 	mockDao := NewMockDao(ctrl)
-	gtw := NewGateway(mockDao)
+	gtw := NewGateway(mockDao, nil)
 
 	mockDao.EXPECT().
 		GetIpSumByCountry(gomock.Any(), testData.countryName).
@@ -157,7 +333,7 @@ func testGtwGetIpCountByCountryNameDBError(t *testing.T) {
 
 	ctrl := gomock.NewController(t)
 	mockDao := NewMockDao(ctrl)
-	gtw := NewGateway(mockDao)
+	gtw := NewGateway(mockDao, nil)
 
 	mockDao.EXPECT().
 		GetIpSumByCountry(gomock.Any(), testData.countryName).
@@ -179,7 +355,7 @@ func testGtwGetIpCountByCountryNameInvalidCountryNameError(t *testing.T) {
 
 	ctrl := gomock.NewController(t)
 	mockDao := NewMockDao(ctrl)
-	gtw := NewGateway(mockDao)
+	gtw := NewGateway(mockDao, nil)
 
 	output, err := gtw.GetIpCountByCountryName(context.Background(), testData.countryName)
 
@@ -200,7 +376,7 @@ func testGtwGetTopIspFromSwitzerlandNoError(t *testing.T) {
 
 	ctrl := gomock.NewController(t)
 	mockDao := NewMockDao(ctrl)
-	gtw := NewGateway(mockDao)
+	gtw := NewGateway(mockDao, nil)
 
 	mockDao.EXPECT().
 		GetTopIspByCountryCode(gomock.Any(), testData.code, testData.limit).
@@ -223,7 +399,7 @@ func testGtwGetTopIspFromSwitzerlandDBError(t *testing.T) {
 
 	ctrl := gomock.NewController(t)
 	mockDao := NewMockDao(ctrl)
-	gtw := NewGateway(mockDao)
+	gtw := NewGateway(mockDao, nil)
 
 	mockDao.EXPECT().
 		GetTopIspByCountryCode(gomock.Any(), testData.code, testData.limit).
@@ -239,62 +415,1111 @@ func testGtwGetTopIspFromSwitzerlandDBError(t *testing.T) {
 
 func testGtwGetDataFromIPNoError(t *testing.T) {
 	type test struct {
-		ipString  string
-		ipDecimal int64
-		output    IpData
-		err       error
+		ipString string
+		ipAddr   netip.Addr
+		output   IpData
+		err      error
 	}
-	testData := test{ipString: "127.0.0.1", ipDecimal: 2130706433, output: mockIpDataGateway, err: nil}
+	testData := test{ipString: "::ffff:81.2.69.160", ipAddr: netip.MustParseAddr("81.2.69.160"), output: mockPublicIpDataGateway, err: nil}
 
 	ctrl := gomock.NewController(t)
 	mockDao := NewMockDao(ctrl)
-	gtw := NewGateway(mockDao)
+	gtw := NewGateway(mockDao, nil)
 
 	mockDao.EXPECT().
-		GetByIp(gomock.Any(), testData.ipDecimal).
+		GetByIp(gomock.Any(), testData.ipAddr).
 		Return(testData.output, testData.err)
 
-	output, err := gtw.GetDataFromIP(context.Background(), testData.ipString)
+	output, err := gtw.GetDataFromIP(context.Background(), netip.MustParseAddr(testData.ipString))
+
+	assert.Equal(t, testData.output, output)
+	assert.True(t, errors.Is(err, testData.err))
+}
+
+func testGtwGetDataFromIPSpecialPurpose(t *testing.T) {
+	type test struct {
+		ipString string
+		output   IpData
+		err      error
+	}
+	testData := test{
+		ipString: "100.64.12.1",
+		output: IpData{IpString: "100.64.12.1", SpecialPurpose: &SpecialPurpose{
+			Classification: SpecialCGNAT, Name: "Shared Address Space", Prefix: "100.64.0.0/10", RFC: "RFC 6598"}},
+		err: nil,
+	}
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	output, err := gtw.GetDataFromIP(context.Background(), netip.MustParseAddr(testData.ipString))
 
 	assert.Equal(t, testData.output, output)
 	assert.True(t, errors.Is(err, testData.err))
 }
 
+func testGtwGetDataFromIPIpv6Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	output, err := gtw.GetDataFromIP(context.Background(), netip.MustParseAddr("2a00:1450::1"))
+
+	assert.Equal(t, IpData{}, output)
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+}
+
 func testGtwGetDataFromIPDbError(t *testing.T) {
 	type test struct {
-		ipString  string
-		ipDecimal int64
-		output    IpData
-		err       error
+		ipString string
+		ipAddr   netip.Addr
+		output   IpData
+		err      error
 	}
-	testData := test{ipString: "127.0.0.1", ipDecimal: 2130706433, output: IpData{}, err: errors.New("db error")}
+	testData := test{ipString: "81.2.69.160", ipAddr: netip.MustParseAddr("81.2.69.160"), output: IpData{}, err: errors.New("db error")}
 
 	ctrl := gomock.NewController(t)
 	mockDao := NewMockDao(ctrl)
-	gtw := NewGateway(mockDao)
+	gtw := NewGateway(mockDao, nil)
 
 	mockDao.EXPECT().
-		GetByIp(gomock.Any(), testData.ipDecimal).
+		GetByIp(gomock.Any(), testData.ipAddr).
 		Return(testData.output, testData.err)
 
-	output, err := gtw.GetDataFromIP(context.Background(), testData.ipString)
+	output, err := gtw.GetDataFromIP(context.Background(), netip.MustParseAddr(testData.ipString))
 
 	assert.Equal(t, testData.output, output)
 	assert.True(t, errors.Is(err, testData.err))
 }
 
-// mock utils
+// GetIpCountByCountryNameGrouped
 
-var mockIpDataGateway = IpData{
-	IpFrom:      2130706433,
-	IpTo:        2130706433,
-	ProxyType:   "PUB",
-	CountryCode: "ES",
-	CountryName: "Spain",
-	RegionName:  "Spain",
-	CityName:    "Barcelona",
-	ISP:         "IPS",
-	IpString:    "127.0.0.1",
+func testGtwGetIpCountByCountryNameGroupedNoError(t *testing.T) {
+	type test struct {
+		countryName string
+		groupBy     []string
+		daoOutput   []GroupedIpCount
+		total       int64
+		output      []GroupedIpCount
+		err         error
+	}
+	testData := test{
+		countryName: "Ireland",
+		groupBy:     []string{"proxy_type"},
+		daoOutput: []GroupedIpCount{
+			{Group: map[string]string{"proxy_type": "VPN"}, IpCount: 2},
+			{Group: map[string]string{"proxy_type": "PUB"}, IpCount: 1},
+		},
+		total: 3,
+		output: []GroupedIpCount{
+			{Group: map[string]string{"proxy_type": "VPN"}, IpCount: 2, Percentage: 66.67},
+			{Group: map[string]string{"proxy_type": "PUB"}, IpCount: 1, Percentage: 33.33},
+		},
+		err: nil,
+	}
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	mockDao.EXPECT().
+		GetIpSumByCountryGrouped(gomock.Any(), testData.countryName, testData.groupBy).
+		Return(testData.daoOutput, testData.err)
+
+	total, output, err := gtw.GetIpCountByCountryNameGrouped(context.Background(), testData.countryName, testData.groupBy)
+
+	assert.Equal(t, testData.total, total)
+	assert.Equal(t, testData.output, output)
+	assert.True(t, errors.Is(err, testData.err))
+}
+
+func testGtwGetIpCountByCountryNameGroupedDBError(t *testing.T) {
+	type test struct {
+		countryName string
+		groupBy     []string
+		output      []GroupedIpCount
+		err         error
+	}
+	testData := test{countryName: "Ireland", groupBy: []string{"isp", "city_name"}, output: []GroupedIpCount{}, err: errors.New("connection error")}
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	mockDao.EXPECT().
+		GetIpSumByCountryGrouped(gomock.Any(), testData.countryName, testData.groupBy).
+		Return(testData.output, testData.err)
+
+	_, output, err := gtw.GetIpCountByCountryNameGrouped(context.Background(), testData.countryName, testData.groupBy)
+
+	assert.Equal(t, testData.output, output)
+	assert.True(t, errors.Is(err, testData.err))
+}
+
+func testGtwGetIpCountByCountryNameGroupedInvalidCountryError(t *testing.T) {
+	utilTestGtwGetIpCountByCountryNameGroupedBadRequest(t, "invalid country", []string{"isp"})
+}
+
+func testGtwGetIpCountByCountryNameGroupedInvalidDimensionError(t *testing.T) {
+	utilTestGtwGetIpCountByCountryNameGroupedBadRequest(t, "Ireland", []string{"country_code"})
+}
+
+func testGtwGetIpCountByCountryNameGroupedTooManyDimensionsError(t *testing.T) {
+	utilTestGtwGetIpCountByCountryNameGroupedBadRequest(t, "Ireland", []string{"isp", "city_name", "proxy_type"})
+}
+
+func testGtwGetIpCountByCountryNameGroupedRepeatedDimensionError(t *testing.T) {
+	utilTestGtwGetIpCountByCountryNameGroupedBadRequest(t, "Ireland", []string{"isp", "isp"})
+}
+
+func utilTestGtwGetIpCountByCountryNameGroupedBadRequest(t *testing.T, countryName string, groupBy []string) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	total, output, err := gtw.GetIpCountByCountryNameGrouped(context.Background(), countryName, groupBy)
+
+	assert.Equal(t, int64(0), total)
+	assert.Equal(t, []GroupedIpCount{}, output)
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+}
+
+// GetTopCountries
+
+func testGtwGetTopCountriesNoError(t *testing.T) {
+	type test struct {
+		proxyType string
+		limit     int
+		daoOutput []CountryIpCount
+		total     int64
+		output    []CountryIpCount
+		err       error
+	}
+	testData := test{
+		proxyType: "VPN",
+		limit:     2,
+		daoOutput: []CountryIpCount{{CountryCode: "US", IpCount: 60}, {CountryCode: "CH", IpCount: 25}},
+		total:     200,
+		output: []CountryIpCount{
+			{CountryCode: "US", CountryName: "United States of America (the)", IpCount: 60, Share: 30},
+			{CountryCode: "CH", CountryName: "Switzerland", IpCount: 25, Share: 12.5},
+		},
+		err: nil,
+	}
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	mockDao.EXPECT().
+		GetTopCountries(gomock.Any(), testData.proxyType, testData.limit).
+		Return(testData.daoOutput, testData.total, testData.err)
+
+	output, err := gtw.GetTopCountries(context.Background(), testData.proxyType, testData.limit)
+
+	assert.Equal(t, testData.output, output)
+	assert.True(t, errors.Is(err, testData.err))
+}
+
+func testGtwGetTopCountriesDBError(t *testing.T) {
+	type test struct {
+		limit  int
+		output []CountryIpCount
+		err    error
+	}
+	testData := test{limit: 10, output: []CountryIpCount{}, err: errors.New("connection error")}
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	mockDao.EXPECT().
+		GetTopCountries(gomock.Any(), "", testData.limit).
+		Return(testData.output, int64(0), testData.err)
+
+	output, err := gtw.GetTopCountries(context.Background(), "", testData.limit)
+
+	assert.Equal(t, testData.output, output)
+	assert.True(t, errors.Is(err, testData.err))
+}
+
+func testGtwGetTopCountriesInvalidProxyTypeError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	output, err := gtw.GetTopCountries(context.Background(), "XYZ", 10)
+
+	assert.Equal(t, []CountryIpCount{}, output)
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+}
+
+func testGtwGetTopCountriesInvalidLimitError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	output, err := gtw.GetTopCountries(context.Background(), "", 0)
+
+	assert.Equal(t, []CountryIpCount{}, output)
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+}
+
+// RunQuery
+
+func testGtwRunQueryNoError(t *testing.T) {
+	type test struct {
+		query         Query
+		expectedQuery Query
+		daoOutput     []GroupedIpCount
+		total         int64
+		output        QueryResult
+		err           error
+	}
+	testData := test{
+		query:         Query{Filters: map[string]string{"country_code": "CH"}, GroupBy: []string{"isp"}},
+		expectedQuery: Query{Filters: map[string]string{"country_code": "CH"}, GroupBy: []string{"isp"}, Order: OrderIpCountDesc, Limit: 100},
+		daoOutput:     []GroupedIpCount{{Group: map[string]string{"isp": "Swisscom"}, IpCount: 3}},
+		total:         4,
+		output:        QueryResult{TotalIpCount: 4, Groups: []GroupedIpCount{{Group: map[string]string{"isp": "Swisscom"}, IpCount: 3, Percentage: 75}}},
+		err:           nil,
+	}
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	mockDao.EXPECT().
+		RunQuery(gomock.Any(), testData.expectedQuery).
+		Return(testData.daoOutput, testData.total, testData.err)
+
+	output, err := gtw.RunQuery(context.Background(), testData.query)
+
+	assert.Equal(t, testData.output, output)
+	assert.True(t, errors.Is(err, testData.err))
+}
+
+func testGtwRunQueryDBError(t *testing.T) {
+	type test struct {
+		query  Query
+		output QueryResult
+		err    error
+	}
+	testData := test{query: Query{GroupBy: []string{"proxy_type"}, Order: OrderGroupAsc, Limit: 5}, output: QueryResult{}, err: errors.New("connection error")}
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	mockDao.EXPECT().
+		RunQuery(gomock.Any(), testData.query).
+		Return([]GroupedIpCount{}, int64(0), testData.err)
+
+	output, err := gtw.RunQuery(context.Background(), testData.query)
+
+	assert.Equal(t, testData.output, output)
+	assert.True(t, errors.Is(err, testData.err))
+}
+
+func testGtwRunQueryInvalidFilterError(t *testing.T) {
+	utilTestGtwRunQueryBadRequest(t, Query{Filters: map[string]string{"ip_from": "1"}})
+}
+
+func testGtwRunQueryInvalidOrderError(t *testing.T) {
+	utilTestGtwRunQueryBadRequest(t, Query{Order: "random"})
+}
+
+func testGtwRunQueryLimitOverMaxError(t *testing.T) {
+	utilTestGtwRunQueryBadRequest(t, Query{Limit: 5000})
+}
+
+func testGtwRunQueryExpensiveDimensionError(t *testing.T) {
+	utilTestGtwRunQueryBadRequest(t, Query{GroupBy: []string{"proxy_type", "city_name"}, Filters: map[string]string{"proxy_type": "VPN"}})
+}
+
+func utilTestGtwRunQueryBadRequest(t *testing.T, query Query) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	output, err := gtw.RunQuery(context.Background(), query)
+
+	assert.Equal(t, QueryResult{}, output)
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+}
+
+// SearchIsp
+
+func testGtwSearchIspNoError(t *testing.T) {
+	type test struct {
+		text        string
+		countryCode string
+		limit       int
+		output      []IspMatch
+		err         error
+	}
+	testData := test{text: " swisscom ", countryCode: "CH", limit: 10, output: []IspMatch{{Isp: "Swisscom", IpCount: 10, Match: MatchPrefix}}, err: nil}
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	mockDao.EXPECT().
+		SearchIsp(gomock.Any(), "swisscom", testData.countryCode, testData.limit).
+		Return(testData.output, testData.err)
+
+	output, err := gtw.SearchIsp(context.Background(), testData.text, testData.countryCode, testData.limit)
+
+	assert.Equal(t, testData.output, output)
+	assert.True(t, errors.Is(err, testData.err))
+}
+
+func testGtwSearchIspShortTextError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	output, err := gtw.SearchIsp(context.Background(), "s", "", 10)
+
+	assert.Equal(t, []IspMatch{}, output)
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+}
+
+func testGtwSearchIspInvalidCountryCodeError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	output, err := gtw.SearchIsp(context.Background(), "swisscom", "XX", 10)
+
+	assert.Equal(t, []IspMatch{}, output)
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+}
+
+// Autocomplete
+
+func testGtwAutocompleteNoError(t *testing.T) {
+	type test struct {
+		field  string
+		prefix string
+		limit  int
+		output []Suggestion
+		err    error
+	}
+	testData := test{field: AutocompleteRegionName, prefix: "Zu", limit: 10, output: []Suggestion{{Value: "Zurich", IpCount: 300}}, err: nil}
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	mockDao.EXPECT().
+		Autocomplete(gomock.Any(), testData.field, testData.prefix, "", testData.limit).
+		Return(testData.output, testData.err)
+
+	output, err := gtw.Autocomplete(context.Background(), testData.field, testData.prefix, "", testData.limit)
+
+	assert.Equal(t, testData.output, output)
+	assert.True(t, errors.Is(err, testData.err))
+}
+
+func testGtwAutocompleteInvalidFieldError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	output, err := gtw.Autocomplete(context.Background(), "isp", "Swiss", "", 10)
+
+	assert.Equal(t, []Suggestion{}, output)
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+}
+
+func testGtwAutocompleteInvalidLimitError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	output, err := gtw.Autocomplete(context.Background(), AutocompleteCityName, "Zu", "", 500)
+
+	assert.Equal(t, []Suggestion{}, output)
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+}
+
+// GetRangesByIsp
+
+func testGtwGetRangesByIspNextPage(t *testing.T) {
+	type test struct {
+		isp       string
+		limit     int
+		offset    int
+		daoOutput []IpRange
+		output    IspRangesPage
+		err       error
+	}
+	nextOffset := 11
+	testData := test{
+		isp:    "IPXO Limited",
+		limit:  1,
+		offset: 10,
+		daoOutput: []IpRange{
+			{IpFrom: 95781810, IpTo: 95781817, ProxyType: "VPN", CountryCode: "GB"},
+			{IpFrom: 95781818, IpTo: 95781818, ProxyType: "VPN", CountryCode: "GB"},
+		},
+		output: IspRangesPage{
+			Isp: "IPXO Limited",
+			Ranges: []IpRange{{
+				IpFrom: 95781810, IpTo: 95781817, IpFromString: "5.181.131.178", IpToString: "5.181.131.185",
+				CIDRs:     []string{"5.181.131.178/31", "5.181.131.180/30", "5.181.131.184/31"},
+				ProxyType: "VPN", CountryCode: "GB",
+			}},
+			Offset:     10,
+			Limit:      1,
+			NextOffset: &nextOffset,
+		},
+		err: nil,
+	}
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	mockDao.EXPECT().
+		GetRangesByIsp(gomock.Any(), testData.isp, "", testData.limit+1, testData.offset).
+		Return(testData.daoOutput, testData.err)
+
+	output, err := gtw.GetRangesByIsp(context.Background(), testData.isp, "", testData.limit, testData.offset)
+
+	assert.Equal(t, testData.output, output)
+	assert.True(t, errors.Is(err, testData.err))
+}
+
+func testGtwGetRangesByIspLastPage(t *testing.T) {
+	type test struct {
+		isp       string
+		limit     int
+		daoOutput []IpRange
+		output    IspRangesPage
+		err       error
+	}
+	testData := test{
+		isp:       "IPXO Limited",
+		limit:     5,
+		daoOutput: []IpRange{{IpFrom: 2130706433, IpTo: 2130706433, ProxyType: "PUB", CountryCode: "ES"}},
+		output: IspRangesPage{
+			Isp:    "IPXO Limited",
+			Ranges: []IpRange{{IpFrom: 2130706433, IpTo: 2130706433, IpFromString: "127.0.0.1", IpToString: "127.0.0.1", CIDRs: []string{"127.0.0.1/32"}, ProxyType: "PUB", CountryCode: "ES"}},
+			Limit:  5,
+		},
+		err: nil,
+	}
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	mockDao.EXPECT().
+		GetRangesByIsp(gomock.Any(), testData.isp, "ES", testData.limit+1, 0).
+		Return(testData.daoOutput, testData.err)
+
+	output, err := gtw.GetRangesByIsp(context.Background(), testData.isp, "ES", testData.limit, 0)
+
+	assert.Equal(t, testData.output, output)
+	assert.True(t, errors.Is(err, testData.err))
+}
+
+func testGtwGetRangesByIspInvalidLimitError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	output, err := gtw.GetRangesByIsp(context.Background(), "IPXO Limited", "", 0, 0)
+
+	assert.Equal(t, IspRangesPage{}, output)
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+}
+
+// GetBlocklist
+
+func testGtwGetBlocklistNoError(t *testing.T) {
+	type test struct {
+		filter    BlocklistFilter
+		daoOutput []IpRange
+		output    []string
+		err       error
+	}
+	// 10.0.0.0 - 10.0.0.255 and 10.0.1.0 - 10.0.1.0 are adjacent, 10.0.2.3 is not
+	testData := test{
+		filter: BlocklistFilter{ProxyTypes: []string{"VPN"}, CountryCodes: []string{"CH"}},
+		daoOutput: []IpRange{
+			{IpFrom: 167772160, IpTo: 167772415},
+			{IpFrom: 167772416, IpTo: 167772416},
+			{IpFrom: 167772675, IpTo: 167772675},
+		},
+		output: []string{"10.0.0.0/24", "10.0.1.0/32", "10.0.2.3/32"},
+		err:    nil,
+	}
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	mockDao.EXPECT().
+		GetBlocklistRanges(gomock.Any(), testData.filter).
+		Return(testData.daoOutput, testData.err)
+
+	output, err := gtw.GetBlocklist(context.Background(), testData.filter)
+
+	assert.Equal(t, testData.output, output)
+	assert.True(t, errors.Is(err, testData.err))
+}
+
+func testGtwGetBlocklistEmptyFilterError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	output, err := gtw.GetBlocklist(context.Background(), BlocklistFilter{})
+
+	assert.Equal(t, []string{}, output)
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+}
+
+func testGtwGetBlocklistInvalidProxyTypeError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	output, err := gtw.GetBlocklist(context.Background(), BlocklistFilter{ProxyTypes: []string{"FOO"}})
+
+	assert.Equal(t, []string{}, output)
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+}
+
+// ExplainIP
+
+func testGtwExplainIPFound(t *testing.T) {
+	daoOutput := IpData{IpFrom: 95781810, IpTo: 95781817, ProxyType: "VPN", CountryCode: "GB", ISP: "IPXO Limited"}
+	expected := Explanation{
+		Ip:     "5.181.131.180",
+		Status: ExplainFound,
+		Match: &IpRange{IpFrom: 95781810, IpTo: 95781817, IpFromString: "5.181.131.178", IpToString: "5.181.131.185",
+			CIDRs: []string{"5.181.131.178/31", "5.181.131.180/30", "5.181.131.184/31"}, ProxyType: "VPN", CountryCode: "GB"},
+		Data: IpData{IpFrom: 95781810, IpTo: 95781817, ProxyType: "VPN", CountryCode: "GB", ISP: "IPXO Limited",
+			IpString: "5.181.131.180"},
+		Backend:        BackendPostgres,
+		DatasetVersion: "2026-04-01",
+		Overrides:      []string{},
+	}
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	mockDao.EXPECT().Backend().Return(BackendPostgres)
+	mockDao.EXPECT().DatasetVersion(gomock.Any()).Return("2026-04-01", nil)
+	mockDao.EXPECT().GetByIp(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(daoOutput, nil)
+
+	output, err := gtw.ExplainIP(context.Background(), netip.MustParseAddr("::ffff:5.181.131.180"))
+
+	assert.Nil(t, err)
+	assert.True(t, output.Timings.GatewayMicros >= output.Timings.DaoMicros)
+	output.Timings = LayerTimings{}
+	assert.Equal(t, expected, output)
+}
+
+func testGtwExplainIPNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	mockDao.EXPECT().Backend().Return(BackendMemory)
+	mockDao.EXPECT().DatasetVersion(gomock.Any()).Return("", nil)
+	mockDao.EXPECT().GetByIp(gomock.Any(), netip.MustParseAddr("1.1.1.1")).Return(IpData{}, common.ErrorNotFound)
+
+	output, err := gtw.ExplainIP(context.Background(), netip.MustParseAddr("1.1.1.1"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, ExplainNotFound, output.Status)
+	assert.Equal(t, BackendMemory, output.Backend)
+	assert.Nil(t, output.Match)
+	assert.Equal(t, IpData{IpString: "1.1.1.1"}, output.Data)
+}
+
+func testGtwExplainIPSpecialPurpose(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	mockDao.EXPECT().Backend().Return(BackendPostgres)
+
+	output, err := gtw.ExplainIP(context.Background(), netip.MustParseAddr("10.1.2.3"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, ExplainSpecialPurpose, output.Status)
+	assert.Equal(t, SpecialPrivate, output.Data.SpecialPurpose.Classification)
+	assert.Equal(t, int64(0), output.Timings.DaoMicros)
+}
+
+func testGtwExplainIPDBError(t *testing.T) {
+	dbErr := errors.New("connection error")
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	mockDao.EXPECT().Backend().Return(BackendPostgres)
+	mockDao.EXPECT().GetByIp(gomock.Any(), netip.MustParseAddr("1.1.1.1")).Return(IpData{}, dbErr)
+
+	output, err := gtw.ExplainIP(context.Background(), netip.MustParseAddr("1.1.1.1"))
+
+	assert.Equal(t, Explanation{}, output)
+	assert.True(t, errors.Is(err, dbErr))
+}
+
+// GetNeighbors
+
+func testGtwGetNeighborsMatch(t *testing.T) {
+	ip := netip.MustParseAddr("0.0.0.25")
+	previous := []IpRange{
+		{IpFrom: 20, IpTo: 29, ProxyType: "VPN", CountryCode: "CH", Isp: "Swisscom"},
+		{IpFrom: 10, IpTo: 19, ProxyType: "VPN", CountryCode: "CH", Isp: "Swisscom"},
+		{IpFrom: 0, IpTo: 9, ProxyType: "TOR", CountryCode: "CH", Isp: "Init7"},
+	}
+	next := []IpRange{{IpFrom: 30, IpTo: 31, ProxyType: "TOR", CountryCode: "CH", Isp: "Init7"}}
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	mockDao.EXPECT().GetPreviousRanges(gomock.Any(), ip, 3).Return(previous, nil)
+	mockDao.EXPECT().GetNextRanges(gomock.Any(), ip, 2).Return(next, nil)
+
+	output, err := gtw.GetNeighbors(context.Background(), netip.MustParseAddr("::ffff:0.0.0.25"), 2)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "0.0.0.25", output.Ip)
+	assert.Nil(t, output.Gap)
+	assert.Equal(t, int64(20), output.Match.IpFrom)
+	assert.Equal(t, []string{"0.0.0.20/30", "0.0.0.24/30", "0.0.0.28/31"}, output.Match.CIDRs)
+	assert.Equal(t, []int64{0, 10}, []int64{output.Before[0].IpFrom, output.Before[1].IpFrom})
+	assert.Equal(t, []IpRange{{IpFrom: 30, IpTo: 31, IpFromString: "0.0.0.30", IpToString: "0.0.0.31",
+		CIDRs: []string{"0.0.0.30/31"}, ProxyType: "TOR", CountryCode: "CH", Isp: "Init7"}}, output.After)
+}
+
+func testGtwGetNeighborsGap(t *testing.T) {
+	ip := netip.MustParseAddr("0.0.0.45")
+	previous := []IpRange{
+		{IpFrom: 30, IpTo: 39, ProxyType: "TOR", CountryCode: "CH", Isp: "Init7"},
+		{IpFrom: 20, IpTo: 29, ProxyType: "VPN", CountryCode: "CH", Isp: "Swisscom"},
+	}
+	next := []IpRange{{IpFrom: 50, IpTo: 59, ProxyType: "PUB", CountryCode: "AR", Isp: "Telecom Argentina"}}
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	mockDao.EXPECT().GetPreviousRanges(gomock.Any(), ip, 2).Return(previous, nil)
+	mockDao.EXPECT().GetNextRanges(gomock.Any(), ip, 1).Return(next, nil)
+
+	output, err := gtw.GetNeighbors(context.Background(), ip, 1)
+
+	assert.Nil(t, err)
+	assert.Nil(t, output.Match)
+	assert.Equal(t, &IpRange{IpFrom: 40, IpTo: 49, IpFromString: "0.0.0.40", IpToString: "0.0.0.49",
+		CIDRs: []string{"0.0.0.40/29", "0.0.0.48/31"}}, output.Gap)
+	assert.Len(t, output.Before, 1)
+	assert.Equal(t, int64(30), output.Before[0].IpFrom)
+	assert.Equal(t, int64(50), output.After[0].IpFrom)
+}
+
+func testGtwGetNeighborsEmpty(t *testing.T) {
+	ip := netip.MustParseAddr("5.181.131.180")
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	mockDao.EXPECT().GetPreviousRanges(gomock.Any(), ip, 6).Return([]IpRange{}, nil)
+	mockDao.EXPECT().GetNextRanges(gomock.Any(), ip, 5).Return([]IpRange{}, nil)
+
+	output, err := gtw.GetNeighbors(context.Background(), ip, 5)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"0.0.0.0/0"}, output.Gap.CIDRs)
+	assert.Equal(t, []IpRange{}, output.Before)
+	assert.Equal(t, []IpRange{}, output.After)
+}
+
+func testGtwGetNeighborsInvalidN(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	for _, n := range []int{0, maxNeighbors + 1} {
+		output, err := gtw.GetNeighbors(context.Background(), netip.MustParseAddr("5.181.131.180"), n)
+
+		assert.Equal(t, Neighbors{}, output)
+		assert.True(t, errors.Is(err, common.ErrorBadRequest))
+	}
+}
+
+func testGtwGetNeighborsIpv6Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	output, err := gtw.GetNeighbors(context.Background(), netip.MustParseAddr("2a00:1450::1"), 5)
+
+	assert.Equal(t, Neighbors{}, output)
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+}
+
+func testGtwGetNeighborsDBError(t *testing.T) {
+	dbErr := errors.New("connection error")
+	ip := netip.MustParseAddr("5.181.131.180")
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	mockDao.EXPECT().GetPreviousRanges(gomock.Any(), ip, 6).Return([]IpRange{}, nil)
+	mockDao.EXPECT().GetNextRanges(gomock.Any(), ip, 5).Return([]IpRange{}, dbErr)
+
+	output, err := gtw.GetNeighbors(context.Background(), ip, 5)
+
+	assert.Equal(t, Neighbors{}, output)
+	assert.True(t, errors.Is(err, dbErr))
+}
+
+// GetCoverage
+
+func testGtwGetCoverageNoError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	mockDao.EXPECT().WalkRanges(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, fn func(IpData) error) error {
+		return fn(IpData{IpFrom: 95781810, IpTo: 95781817, ProxyType: "VPN", CountryCode: "GB"})
+	})
+
+	output, err := gtw.GetCoverage(context.Background(), 5)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(8), output.CoveredIps)
+	assert.Equal(t, int64(2), output.GapCount)
+	assert.Equal(t, []CoverageCount{{Key: "GB", IpCount: 8, Percentage: 100}}, output.ByCountry)
+	assert.Equal(t, CoverageCount{Key: "5.0.0.0/8", IpCount: 8, Percentage: 0}, output.BySlash8[5])
+}
+
+func testGtwGetCoverageInvalidLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	output, err := gtw.GetCoverage(context.Background(), -1)
+
+	assert.Equal(t, Coverage{}, output)
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+}
+
+func testGtwGetCoverageDBError(t *testing.T) {
+	dbErr := errors.New("connection error")
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	mockDao.EXPECT().WalkRanges(gomock.Any(), gomock.Any()).Return(dbErr)
+
+	output, err := gtw.GetCoverage(context.Background(), 5)
+
+	assert.Equal(t, Coverage{}, output)
+	assert.True(t, errors.Is(err, dbErr))
+}
+
+// ValidateDataset
+
+func testGtwValidateDatasetInvalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	mockDao.EXPECT().WalkRanges(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, fn func(IpData) error) error {
+		return fn(IpData{IpFrom: 95781817, IpTo: 95781810, ProxyType: "VPN", CountryCode: "GB",
+			CountryName: "United Kingdom of Great Britain and Northern Ireland (the)", ISP: "IPXO Limited"})
+	})
+
+	output, err := gtw.ValidateDataset(context.Background(), 5)
+
+	assert.Nil(t, err)
+	assert.False(t, output.Valid)
+	assert.Equal(t, []ValidationIssue{{Severity: SeverityError, Check: CheckInvertedRange, IpFrom: 95781817, IpTo: 95781810}}, output.Issues)
+}
+
+func testGtwValidateDatasetInvalidLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	output, err := gtw.ValidateDataset(context.Background(), maxRangesLimit+1)
+
+	assert.Equal(t, ValidationReport{}, output)
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+}
+
+func testGtwValidateDatasetDBError(t *testing.T) {
+	dbErr := errors.New("connection error")
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	mockDao.EXPECT().WalkRanges(gomock.Any(), gomock.Any()).Return(dbErr)
+
+	output, err := gtw.ValidateDataset(context.Background(), 5)
+
+	assert.Equal(t, ValidationReport{}, output)
+	assert.True(t, errors.Is(err, dbErr))
+}
+
+// ExtractIPs
+
+func testGtwExtractIPsNoError(t *testing.T) {
+	found := IpData{IpFrom: 134744072, IpTo: 134744072, ProxyType: "PUB", CountryCode: "US", IpString: "8.8.8.8"}
+	expected := []ExtractedIP{
+		{Ip: "8.8.8.8", Version: 4, Status: ExtractFound, Data: &found},
+		{Ip: "192.168.1.10", Version: 4, Status: ExtractSkipped, Reason: SpecialPrivate, SpecialPurpose: &SpecialPurpose{
+			Classification: SpecialPrivate, Name: "Private-Use", Prefix: "192.168.0.0/16", RFC: "RFC 1918"}},
+		{Ip: "1.1.1.1", Version: 4, Status: ExtractNotFound},
+		{Ip: "2a00:1450::1", Version: 6, Status: ExtractSkipped, Reason: ReasonIPv6},
+	}
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	mockDao.EXPECT().GetByIp(gomock.Any(), netip.MustParseAddr("8.8.8.8")).Return(found, nil)
+	mockDao.EXPECT().GetByIp(gomock.Any(), netip.MustParseAddr("1.1.1.1")).Return(IpData{}, common.ErrorNotFound)
+
+	output, err := gtw.ExtractIPs(context.Background(), "8.8.8.8 via 192.168.1.10, 1.1.1.1 and 2a00:1450::1")
+
+	assert.Equal(t, expected, output)
+	assert.Nil(t, err)
+}
+
+func testGtwExtractIPsDBError(t *testing.T) {
+	dbErr := errors.New("connection error")
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	mockDao.EXPECT().GetByIp(gomock.Any(), netip.MustParseAddr("8.8.8.8")).Return(IpData{}, dbErr)
+
+	output, err := gtw.ExtractIPs(context.Background(), "8.8.8.8")
+
+	assert.Equal(t, []ExtractedIP{}, output)
+	assert.True(t, errors.Is(err, dbErr))
+}
+
+func testGtwExtractIPsTooManyIpsError(t *testing.T) {
+	text := ""
+	for i := 0; i <= maxExtractedIps; i++ {
+		text += fmt.Sprintf("10.0.%d.%d ", i/256, i%256)
+	}
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	output, err := gtw.ExtractIPs(context.Background(), text)
+
+	assert.Equal(t, []ExtractedIP{}, output)
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+}
+
+func testGtwGetDataFromIPOverridden(t *testing.T) {
+	overrides, _ := NewOverrideStore("")
+	overrides.Save(mockOverride)
+	expected := mockPublicIpDataGateway
+	expected.ProxyType = ""
+	expected.ISP = "Our Office"
+	expected.Override = &OverrideSource{ID: "a1", Range: "81.2.69.160/30", NotProxy: true, Comment: "office egress"}
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, overrides)
+
+	mockDao.EXPECT().GetByIp(gomock.Any(), netip.MustParseAddr("81.2.69.160")).Return(mockPublicIpDataGateway, nil)
+
+	output, err := gtw.GetDataFromIP(context.Background(), netip.MustParseAddr("81.2.69.160"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, expected, output)
+}
+
+func testGtwGetDataFromIPOverrideNotInDataset(t *testing.T) {
+	overrides, _ := NewOverrideStore("")
+	overrides.Save(mockOverride)
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, overrides)
+
+	mockDao.EXPECT().GetByIp(gomock.Any(), gomock.Any()).Return(IpData{}, common.ErrorNotFound).Times(2)
+
+	output, err := gtw.GetDataFromIP(context.Background(), netip.MustParseAddr("81.2.69.161"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, IpData{IpFrom: 1359103392, IpTo: 1359103395, ISP: "Our Office", IpString: "81.2.69.161",
+		Override: &OverrideSource{ID: "a1", Range: "81.2.69.160/30", NotProxy: true, Comment: "office egress"}}, output)
+
+	_, err = gtw.GetDataFromIP(context.Background(), netip.MustParseAddr("81.2.69.164"))
+
+	assert.True(t, errors.Is(err, common.ErrorNotFound))
+}
+
+func testGtwGetDataFromIPOverridePrivateRange(t *testing.T) {
+	overrides, _ := NewOverrideStore("")
+	overrides.Save(mockPrivateOverride)
+	specialPurpose, _ := LookupSpecialPurpose(netip.MustParseAddr("10.0.0.7"))
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, overrides)
+
+	output, err := gtw.GetDataFromIP(context.Background(), netip.MustParseAddr("10.0.0.7"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, IpData{IpFrom: 167772160, IpTo: 167772415, CountryCode: "CH", CountryName: "Switzerland", ISP: "Our Office",
+		IpString: "10.0.0.7", SpecialPurpose: &specialPurpose,
+		Override: &OverrideSource{ID: "b2", Range: "10.0.0.0/24", Comment: "office lan"}}, output)
+
+	output, err = gtw.GetDataFromIP(context.Background(), netip.MustParseAddr("10.0.1.7"))
+
+	assert.Nil(t, err)
+	assert.Nil(t, output.Override)
+	assert.Equal(t, "", output.ISP)
+}
+
+func testGtwExplainIPOverridden(t *testing.T) {
+	overrides, _ := NewOverrideStore("")
+	overrides.Save(mockOverride)
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, overrides)
+
+	mockDao.EXPECT().Backend().Return(BackendPostgres)
+	mockDao.EXPECT().DatasetVersion(gomock.Any()).Return("2026-04-01", nil)
+	mockDao.EXPECT().GetByIp(gomock.Any(), netip.MustParseAddr("81.2.69.160")).Return(mockPublicIpDataGateway, nil)
+
+	output, err := gtw.ExplainIP(context.Background(), netip.MustParseAddr("81.2.69.160"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, ExplainFound, output.Status)
+	assert.Equal(t, "VPN", output.Match.ProxyType)
+	assert.Equal(t, "", output.Data.ProxyType)
+	assert.Equal(t, []string{"a1"}, output.Overrides)
+}
+
+func testGtwExplainIPOverridePrivateRange(t *testing.T) {
+	overrides, _ := NewOverrideStore("")
+	overrides.Save(mockPrivateOverride)
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, overrides)
+
+	mockDao.EXPECT().Backend().Return(BackendPostgres)
+
+	output, err := gtw.ExplainIP(context.Background(), netip.MustParseAddr("10.0.0.7"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, ExplainSpecialPurpose, output.Status)
+	assert.Equal(t, "Our Office", output.Data.ISP)
+	assert.NotNil(t, output.Data.SpecialPurpose)
+	assert.Equal(t, []string{"b2"}, output.Overrides)
+}
+
+func testGtwOverridesNoError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+	ctx := context.Background()
+
+	created, err := gtw.CreateOverride(ctx, Override{Range: "81.2.69.160/30", NotProxy: true})
+	assert.Nil(t, err)
+	assert.Len(t, created.ID, 16)
+	assert.Equal(t, int64(1359103392), created.IpFrom)
+	assert.False(t, created.UpdatedAt.IsZero())
+
+	updated, err := gtw.UpdateOverride(ctx, created.ID, Override{Range: "81.2.69.160", CountryCode: "ch"})
+	assert.Nil(t, err)
+	assert.Equal(t, created.ID, updated.ID)
+	assert.False(t, updated.NotProxy)
+	assert.Equal(t, "Switzerland", updated.CountryName)
+
+	overrides, err := gtw.ListOverrides(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []Override{updated}, overrides)
+
+	err = gtw.DeleteOverride(ctx, created.ID)
+	assert.Nil(t, err)
+	_, err = gtw.GetOverride(ctx, created.ID)
+	assert.True(t, errors.Is(err, common.ErrorNotFound))
+}
+
+func testGtwCreateOverrideInvalidError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	_, err := gtw.CreateOverride(context.Background(), Override{Range: "81.2.69.0/16", NotProxy: true})
+
+	assert.EqualError(t, err, "range 81.2.69.0/16 must be an ipv4 CIDR, a from-to range or an ipv4 bad request")
+}
+
+func testGtwUpdateOverrideNotFoundError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	gtw := NewGateway(mockDao, nil)
+
+	_, err := gtw.UpdateOverride(context.Background(), "a1", Override{Range: "81.2.69.160", NotProxy: true})
+
+	assert.True(t, errors.Is(err, common.ErrorNotFound))
+}
+
+// mock utils
+
+var mockOverride = Override{ID: "a1", Range: "81.2.69.160/30", IpFrom: 1359103392, IpTo: 1359103395, NotProxy: true,
+	ISP: "Our Office", Comment: "office egress"}
+
+var mockPrivateOverride = Override{ID: "b2", Range: "10.0.0.0/24", IpFrom: 167772160, IpTo: 167772415, CountryCode: "CH",
+	CountryName: "Switzerland", ISP: "Our Office", Comment: "office lan"}
+
+var mockIpDataGateway = IpData{
+	IpFrom:      2130706433,
+	IpTo:        2130706433,
+	ProxyType:   "PUB",
+	CountryCode: "ES",
+	CountryName: "Spain",
+	RegionName:  "Spain",
+	CityName:    "Barcelona",
+	ISP:         "IPS",
+	IpString:    "127.0.0.1",
+}
+
+var mockPublicIpDataGateway = IpData{
+	IpFrom:      1359103392,
+	IpTo:        1359103392,
+	ProxyType:   "VPN",
+	CountryCode: "GB",
+	CountryName: "United Kingdom of Great Britain and Northern Ireland",
+	RegionName:  "England",
+	CityName:    "London",
+	ISP:         "IPS",
+	IpString:    "81.2.69.160",
 }
 
 func utilGenerateIspIpCount(count int) []IspIpCount {
@@ -2,22 +2,70 @@ package ipdata
 
 import (
 	"DreamLabChallenge/cmd/api/common"
+	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Handler interface {
 	GetTopISPsFromSwitzerland(w http.ResponseWriter, r *http.Request)
 	GetIPCountByCountryName(w http.ResponseWriter, r *http.Request)
 	GetDataFromIP(w http.ResponseWriter, r *http.Request)
+	ExplainIP(w http.ResponseWriter, r *http.Request)
+	GetDataFromCaller(w http.ResponseWriter, r *http.Request)
+	GetIPCountByCountry(w http.ResponseWriter, r *http.Request)
+	GetTopCountries(w http.ResponseWriter, r *http.Request)
+	RunQuery(w http.ResponseWriter, r *http.Request)
+	SearchIsp(w http.ResponseWriter, r *http.Request)
+	Autocomplete(w http.ResponseWriter, r *http.Request)
+	GetRangesByIsp(w http.ResponseWriter, r *http.Request)
+	ExportBlocklist(w http.ResponseWriter, r *http.Request)
+	GetNeighbors(w http.ResponseWriter, r *http.Request)
+	GetCoverage(w http.ResponseWriter, r *http.Request)
+	ExtractIPs(w http.ResponseWriter, r *http.Request)
+	ListOverrides(w http.ResponseWriter, r *http.Request)
+	GetOverride(w http.ResponseWriter, r *http.Request)
+	CreateOverride(w http.ResponseWriter, r *http.Request)
+	UpdateOverride(w http.ResponseWriter, r *http.Request)
+	DeleteOverride(w http.ResponseWriter, r *http.Request)
 }
+
+const (
+	defaultTopCountriesLimit = 10
+	defaultSearchLimit       = 10
+	defaultRangesLimit       = 100
+	defaultNeighbors         = 5
+	defaultCoverageLimit     = 100
+	// coverageTimeout stops the walk of the dataset of GetCoverage, the response has coverageWriteSlack more to be
+	// written. both replace the timeouts of the server
+	coverageTimeout    = 2 * time.Minute
+	coverageWriteSlack = 10 * time.Second
+	// maxExtractTextBytes is the max size of the text of ExtractIPs
+	maxExtractTextBytes = 1 << 20
+	// maxOverrideBytes is the max size of the json body of an override
+	maxOverrideBytes = 1 << 16
+)
+
 type handler struct {
-	gtw Gateway
+	gtw            Gateway
+	trustedProxies common.TrustedProxies
+	clientIPHeader string
+	policy         Policy
 }
 
-func NewHandler(gtw Gateway) Handler {
-	return handler{gtw: gtw}
+// NewHandler builds the ipdata Handler, trustedProxies are the peers allowed to set the caller ip through the
+// clientIPHeader (X-Forwarded-For when empty, see common.GetClientIP). policy is only evaluated to explain the lookups
+func NewHandler(gtw Gateway, trustedProxies common.TrustedProxies, clientIPHeader string, policy Policy) Handler {
+	return handler{gtw: gtw, trustedProxies: trustedProxies, clientIPHeader: clientIPHeader, policy: policy}
 }
 
 func (h handler) GetTopISPsFromSwitzerland(w http.ResponseWriter, r *http.Request) {
@@ -37,14 +85,19 @@ func (h handler) GetTopISPsFromSwitzerland(w http.ResponseWriter, r *http.Reques
 }
 
 func (h handler) GetIPCountByCountryName(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-
 	countyName, err := common.GetParamFromRequest(r, "country_name")
 	if err != nil {
 		err = fmt.Errorf("param: country_name %w", err)
 		common.HandlerErrorResponse(w, err)
 		return
 	}
+
+	h.writeIPCountByCountryName(w, r, countyName)
+}
+
+func (h handler) writeIPCountByCountryName(w http.ResponseWriter, r *http.Request, countyName string) {
+	ctx := r.Context()
+
 	ipCount, err := h.gtw.GetIpCountByCountryName(ctx, countyName)
 	if err != nil {
 		common.HandlerErrorResponse(w, err)
@@ -65,7 +118,301 @@ func (h handler) GetIPCountByCountryName(w http.ResponseWriter, r *http.Request)
 
 }
 
+// GetIPCountByCountry returns the ip count of the country, when group_by is present also returns the
+// count broken down by the given dimensions (comma separated)
+func (h handler) GetIPCountByCountry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	countryName, err := common.GetParamFromRequest(r, "country")
+	if err != nil {
+		err = fmt.Errorf("param: country %w", err)
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	groupByParam := r.URL.Query().Get("group_by")
+	if groupByParam == "" {
+		h.writeIPCountByCountryName(w, r, countryName)
+		return
+	}
+
+	groupBy := strings.Split(groupByParam, ",")
+	ipCount, groups, err := h.gtw.GetIpCountByCountryNameGrouped(ctx, countryName, groupBy)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	response, err := json.Marshal(struct {
+		CountryName string           `json:"country_name"`
+		IpCount     int64            `json:"ip_count"`
+		GroupBy     []string         `json:"group_by"`
+		Groups      []GroupedIpCount `json:"groups"`
+	}{countryName, ipCount, groupBy, groups})
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	w.Write(response)
+}
+
+// GetTopCountries returns the countries ranking by ip count, limit and proxy_type are optional query params
+func (h handler) GetTopCountries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	limit, err := getLimitFromQuery(r, defaultTopCountriesLimit)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+	proxyType := strings.ToUpper(r.URL.Query().Get("proxy_type"))
+
+	countries, err := h.gtw.GetTopCountries(ctx, proxyType, limit)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	response, err := json.Marshal(countries)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	w.Write(response)
+}
+
+// RunQuery runs a filter-and-group query. every whitelisted field is a filter query param, group_by (comma
+// separated), order and limit are optional. Any other query param is rejected
+func (h handler) RunQuery(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := Query{Filters: make(map[string]string)}
+	for param, values := range r.URL.Query() {
+		value := values[0]
+		switch param {
+		case "group_by":
+			if value != "" {
+				query.GroupBy = strings.Split(value, ",")
+			}
+		case "order":
+			query.Order = value
+		case "limit":
+			limit, err := strconv.Atoi(value)
+			if err != nil {
+				err = fmt.Errorf("param: limit must be a number %w", common.ErrorBadRequest)
+				common.HandlerErrorResponse(w, err)
+				return
+			}
+			query.Limit = limit
+		default:
+			if _, found := queryColumns[param]; !found {
+				err := fmt.Errorf("param: %s is not a valid query param %w", param, common.ErrorBadRequest)
+				common.HandlerErrorResponse(w, err)
+				return
+			}
+			query.Filters[param] = value
+		}
+	}
+
+	result, err := h.gtw.RunQuery(ctx, query)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	response, err := json.Marshal(result)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	w.Write(response)
+}
+
+// SearchIsp returns the ISPs matching the q query param, country_code and limit are optional
+func (h handler) SearchIsp(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	limit, err := getLimitFromQuery(r, defaultSearchLimit)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+	countryCode := strings.ToUpper(r.URL.Query().Get("country_code"))
+
+	matches, err := h.gtw.SearchIsp(ctx, r.URL.Query().Get("q"), countryCode, limit)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	response, err := json.Marshal(matches)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	w.Write(response)
+}
+
+// Autocomplete returns the values of the field url param starting with the q query param,
+// country_code and limit are optional
+func (h handler) Autocomplete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	field, err := common.GetParamFromRequest(r, "field")
+	if err != nil {
+		err = fmt.Errorf("param: field %w", err)
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+	limit, err := getLimitFromQuery(r, defaultSearchLimit)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+	countryCode := strings.ToUpper(r.URL.Query().Get("country_code"))
+
+	suggestions, err := h.gtw.Autocomplete(ctx, field, r.URL.Query().Get("q"), countryCode, limit)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	response, err := json.Marshal(suggestions)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	w.Write(response)
+}
+
+// GetRangesByIsp returns the ranges of the isp url param, country_code, limit and offset are optional
+func (h handler) GetRangesByIsp(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	isp, err := common.GetParamFromRequest(r, "isp")
+	if err != nil {
+		err = fmt.Errorf("param: isp %w", err)
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+	limit, err := getLimitFromQuery(r, defaultRangesLimit)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+	offset := 0
+	if offsetParam := r.URL.Query().Get("offset"); offsetParam != "" {
+		offset, err = strconv.Atoi(offsetParam)
+		if err != nil {
+			err = fmt.Errorf("param: offset must be a number %w", common.ErrorBadRequest)
+			common.HandlerErrorResponse(w, err)
+			return
+		}
+	}
+	countryCode := strings.ToUpper(r.URL.Query().Get("country_code"))
+
+	page, err := h.gtw.GetRangesByIsp(ctx, isp, countryCode, limit, offset)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	response, err := json.Marshal(page)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	w.Write(response)
+}
+
+// ExportBlocklist returns the CIDR blocks of the ranges selected by the proxy_type, country_code (both comma
+// separated) and isp query params, rendered in the format query param. name is the set, chain or map value
+func (h handler) ExportBlocklist(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	opts := BlocklistOptions{Format: r.URL.Query().Get("format"), Name: r.URL.Query().Get("name")}.WithDefaults()
+	err := opts.Validate()
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+	filter := BlocklistFilter{
+		ProxyTypes:   splitUpperList(r.URL.Query().Get("proxy_type")),
+		CountryCodes: splitUpperList(r.URL.Query().Get("country_code")),
+		Isp:          r.URL.Query().Get("isp"),
+	}
+
+	cidrs, err := h.gtw.GetBlocklist(ctx, filter)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	var response bytes.Buffer
+	err = RenderBlocklist(&response, opts, cidrs)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(response.Bytes())
+}
+
+// ExtractIPs returns the ips found in the text of the body with their data, in order of first appearance
+func (h handler) ExtractIPs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	text, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxExtractTextBytes))
+	if err != nil {
+		err = fmt.Errorf("text must have at most %d bytes %w", maxExtractTextBytes, common.ErrorBadRequest)
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	extracted, err := h.gtw.ExtractIPs(ctx, string(text))
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	response, err := json.Marshal(struct {
+		Ips []ExtractedIP `json:"ips"`
+	}{extracted})
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	w.Write(response)
+}
+
 func (h handler) GetDataFromIP(w http.ResponseWriter, r *http.Request) {
+	ip, err := common.GetParamFromRequest(r, "ip")
+	if err != nil {
+		err = fmt.Errorf("param: ip %w", err)
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	addr, err := ParseIP(ip)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	h.writeDataFromIP(w, r, addr)
+}
+
+// ExplainIP returns why the ip got its data: the matched row, the backend, the policy decision and the timings
+func (h handler) ExplainIP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
 	ctx := r.Context()
 
 	ip, err := common.GetParamFromRequest(r, "ip")
@@ -74,14 +421,145 @@ func (h handler) GetDataFromIP(w http.ResponseWriter, r *http.Request) {
 		common.HandlerErrorResponse(w, err)
 		return
 	}
+	addr, err := ParseIP(ip)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	explanation, err := h.gtw.ExplainIP(ctx, addr)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+	explanation.Policy = h.policy.Explain(explanation.Data)
+	explanation.Timings.HandlerMicros = microsSince(start)
+
+	response, err := json.Marshal(explanation)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	w.Write(response)
+}
+
+// GetNeighbors returns the ranges around the ip url param, n is the optional number of ranges of each side
+func (h handler) GetNeighbors(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	ip, err := common.GetParamFromRequest(r, "ip")
+	if err != nil {
+		err = fmt.Errorf("param: ip %w", err)
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+	addr, err := ParseIP(ip)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+	n := defaultNeighbors
+	if nParam := r.URL.Query().Get("n"); nParam != "" {
+		n, err = strconv.Atoi(nParam)
+		if err != nil {
+			err = fmt.Errorf("param: n must be a number %w", common.ErrorBadRequest)
+			common.HandlerErrorResponse(w, err)
+			return
+		}
+	}
+
+	neighbors, err := h.gtw.GetNeighbors(ctx, addr, n)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
 
-	isValid := isValidIp(ip)
-	if !isValid {
-		err = fmt.Errorf("ip is not a valid Ipv4 format %w", common.ErrorBadRequest)
+	response, err := json.Marshal(neighbors)
+	if err != nil {
 		common.HandlerErrorResponse(w, err)
 		return
 	}
 
+	w.Write(response)
+}
+
+// GetCoverage returns the coverage of the ipv4 space by the dataset as json, or as csv with format=csv. limit is
+// the optional number of gaps and overlaps listed. The walk of the whole dataset is stopped after coverageTimeout,
+// the ipdata coverage command has no time limit for the bigger ones
+func (h handler) GetCoverage(w http.ResponseWriter, r *http.Request) {
+	// the write timeout of the server doesn't cancel the request context, the walk gets its own deadline
+	common.ExtendDeadlines(w, coverageTimeout+coverageWriteSlack)
+	ctx, cancel := context.WithTimeout(r.Context(), coverageTimeout)
+	defer cancel()
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		err := fmt.Errorf("invalid format %s %w", format, common.ErrorBadRequest)
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+	limit, err := getLimitFromQuery(r, defaultCoverageLimit)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	coverage, err := h.gtw.GetCoverage(ctx, limit)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("the coverage took longer than %s, run the ipdata coverage command %w", coverageTimeout, common.ErrorInternalServer)
+	}
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	if format == "csv" {
+		var response bytes.Buffer
+		err = csv.NewWriter(&response).WriteAll(append([][]string{CoverageHeader}, coverage.Rows()...))
+		if err != nil {
+			common.HandlerErrorResponse(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/csv")
+		w.Write(response.Bytes())
+		return
+	}
+
+	response, err := json.Marshal(coverage)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	w.Write(response)
+}
+
+// GetDataFromCaller returns the data of the ip that made the request
+func (h handler) GetDataFromCaller(w http.ResponseWriter, r *http.Request) {
+	clientIP, err := common.GetClientIP(r, h.trustedProxies, h.clientIPHeader)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	// the dataset only holds ipv4, a public ipv6 caller still gets its address without data
+	if _, special := LookupSpecialPurpose(clientIP); clientIP.Is6() && !special {
+		response, err := json.Marshal(IpData{IpString: clientIP.String()})
+		if err != nil {
+			common.HandlerErrorResponse(w, err)
+			return
+		}
+		w.Write(response)
+		return
+	}
+
+	h.writeDataFromIP(w, r, clientIP)
+}
+
+func (h handler) writeDataFromIP(w http.ResponseWriter, r *http.Request, ip netip.Addr) {
+	ctx := r.Context()
+
 	ipData, err := h.gtw.GetDataFromIP(ctx, ip)
 	if err != nil {
 		common.HandlerErrorResponse(w, err)
@@ -96,3 +574,141 @@ func (h handler) GetDataFromIP(w http.ResponseWriter, r *http.Request) {
 
 	w.Write(response)
 }
+
+// getLimitFromQuery returns the limit query param or defaultLimit if not present
+func getLimitFromQuery(r *http.Request, defaultLimit int) (int, error) {
+	limitParam := r.URL.Query().Get("limit")
+	if limitParam == "" {
+		return defaultLimit, nil
+	}
+	limit, err := strconv.Atoi(limitParam)
+	if err != nil {
+		return 0, fmt.Errorf("param: limit must be a number %w", common.ErrorBadRequest)
+	}
+	return limit, nil
+}
+
+// splitUpperList returns the upper-cased values of a comma separated query param, empty values are skipped
+func splitUpperList(param string) []string {
+	values := make([]string, 0)
+	for _, value := range strings.Split(param, ",") {
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func (h handler) ListOverrides(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	overrides, err := h.gtw.ListOverrides(ctx)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	writeOverrideResponse(w, http.StatusOK, overrides)
+}
+
+func (h handler) GetOverride(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := common.GetParamFromRequest(r, "id")
+	if err != nil {
+		err = fmt.Errorf("param: id %w", err)
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	override, err := h.gtw.GetOverride(ctx, id)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	writeOverrideResponse(w, http.StatusOK, override)
+}
+
+// CreateOverride reads the Override json from the body, its id is generated
+func (h handler) CreateOverride(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	override, err := decodeOverride(w, r)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	override, err = h.gtw.CreateOverride(ctx, override)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/ipdata/overrides/"+override.ID)
+	writeOverrideResponse(w, http.StatusCreated, override)
+}
+
+// UpdateOverride replaces the override with the Override json of the body
+func (h handler) UpdateOverride(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := common.GetParamFromRequest(r, "id")
+	if err != nil {
+		err = fmt.Errorf("param: id %w", err)
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	override, err := decodeOverride(w, r)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	override, err = h.gtw.UpdateOverride(ctx, id, override)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	writeOverrideResponse(w, http.StatusOK, override)
+}
+
+func (h handler) DeleteOverride(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := common.GetParamFromRequest(r, "id")
+	if err != nil {
+		err = fmt.Errorf("param: id %w", err)
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	err = h.gtw.DeleteOverride(ctx, id)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func decodeOverride(w http.ResponseWriter, r *http.Request) (Override, error) {
+	override := Override{}
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxOverrideBytes)).Decode(&override)
+	if err != nil {
+		return Override{}, fmt.Errorf("invalid body. %s %w", err.Error(), common.ErrorBadRequest)
+	}
+	return override, nil
+}
+
+func writeOverrideResponse(w http.ResponseWriter, status int, value interface{}) {
+	response, err := json.Marshal(value)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(status)
+	w.Write(response)
+}
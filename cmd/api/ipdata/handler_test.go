@@ -2,14 +2,19 @@ package ipdata
 
 import (
 	"DreamLabChallenge/cmd/api/common"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/golang/mock/gomock"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
+	"strings"
 	"testing"
+	"time"
 )
 
 /*
@@ -48,6 +53,7 @@ func TestHandler_GetDataFromIP(t *testing.T) {
 		{Scenario: "No error", TestFn: testHandlerGetDataFromIpNoError},
 		{Scenario: "No ip param present error", TestFn: testHandlerGetDataFromIpNoIpParamError},
 		{Scenario: "Invalid IP error", TestFn: testHandlerGetDataFromIpInvalidIpParamError},
+		{Scenario: "Leading zeros IP error", TestFn: testHandlerGetDataFromIpLeadingZerosError},
 		{Scenario: "Gateway thrown error", TestFn: testHandlerGetDataFromIpGtwError},
 		{Scenario: "Gateway not found error", TestFn: testHandlerGetDataFromIpGtwNotFoundError},
 	}
@@ -58,6 +64,167 @@ func TestHandler_GetDataFromIP(t *testing.T) {
 
 }
 
+func TestHandler_GetDataFromCaller(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error remote addr", TestFn: testHandlerGetDataFromCallerRemoteAddr},
+		{Scenario: "Trusted proxy X-Forwarded-For", TestFn: testHandlerGetDataFromCallerTrustedForwardedFor},
+		{Scenario: "Trusted proxy Forwarded", TestFn: testHandlerGetDataFromCallerTrustedForwarded},
+		{Scenario: "Client Forwarded ignored behind X-Forwarded-For proxy", TestFn: testHandlerGetDataFromCallerClientForwardedIgnored},
+		{Scenario: "Untrusted peer Forwarded ignored", TestFn: testHandlerGetDataFromCallerUntrustedForwarded},
+		{Scenario: "Ipv6 caller looked up", TestFn: testHandlerGetDataFromCallerIpv6},
+		{Scenario: "Public ipv6 caller echoed", TestFn: testHandlerGetDataFromCallerPublicIpv6},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+
+}
+
+func TestHandler_GetIPCountByCountry(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error grouped", TestFn: testHandlerGetIpCountByCountryGroupedNoError},
+		{Scenario: "No error without group_by", TestFn: testHandlerGetIpCountByCountryNotGroupedNoError},
+		{Scenario: "Gateway thrown error", TestFn: testHandlerGetIpCountByCountryGtwError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+
+}
+
+func TestHandler_GetTopCountries(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testHandlerGetTopCountriesNoError},
+		{Scenario: "Invalid limit error", TestFn: testHandlerGetTopCountriesInvalidLimitError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+
+}
+
+func TestHandler_RunQuery(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testHandlerRunQueryNoError},
+		{Scenario: "Unknown param error", TestFn: testHandlerRunQueryUnknownParamError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+
+}
+
+func TestHandler_SearchIsp(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testHandlerSearchIspNoError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+
+}
+
+func TestHandler_Autocomplete(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testHandlerAutocompleteNoError},
+		{Scenario: "Gateway thrown error", TestFn: testHandlerAutocompleteGtwError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+
+}
+
+func TestHandler_GetRangesByIsp(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testHandlerGetRangesByIspNoError},
+		{Scenario: "Invalid offset error", TestFn: testHandlerGetRangesByIspInvalidOffsetError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+
+}
+
+func TestHandler_ExportBlocklist(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testHandlerExportBlocklistNoError},
+		{Scenario: "Invalid format error", TestFn: testHandlerExportBlocklistInvalidFormatError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+
+}
+
+func TestHandler_GetNeighbors(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testHandlerGetNeighborsNoError},
+		{Scenario: "Invalid n error", TestFn: testHandlerGetNeighborsInvalidNError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestHandler_GetCoverage(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Json", TestFn: testHandlerGetCoverageJSON},
+		{Scenario: "Csv", TestFn: testHandlerGetCoverageCSV},
+		{Scenario: "Invalid format error", TestFn: testHandlerGetCoverageInvalidFormatError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestHandler_ExplainIP(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testHandlerExplainIPNoError},
+		{Scenario: "Invalid IP error", TestFn: testHandlerExplainIPInvalidIpError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+
+}
+
+func TestHandler_ExtractIPs(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testHandlerExtractIPsNoError},
+		{Scenario: "Too many ips error", TestFn: testHandlerExtractIPsTooManyIpsError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+
+}
+
+func TestHandler_Overrides(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Create no error", TestFn: testHandlerCreateOverrideNoError},
+		{Scenario: "Create invalid body error", TestFn: testHandlerCreateOverrideInvalidBodyError},
+		{Scenario: "Delete no error", TestFn: testHandlerDeleteOverrideNoError},
+		{Scenario: "Get not found error", TestFn: testHandlerGetOverrideNotFoundError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
 func testHandlerGetTopISPsFromSwitzerlandNoErrors(t *testing.T) {
 	type test struct {
 		expectedCode int
@@ -78,7 +245,7 @@ func testHandlerGetTopISPsFromSwitzerlandNoErrors(t *testing.T) {
 
 	ctrl := gomock.NewController(t)
 	mockGtw := NewMockGateway(ctrl)
-	testHandler := NewHandler(mockGtw)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
 
 	mockGtw.EXPECT().GetTopISPFromSwitzerland(gomock.Any()).
 		Return(testCase.ipsCount, testCase.err)
@@ -116,7 +283,7 @@ func testHandlerGetTopISPsFromSwitzerlandDBError(t *testing.T) {
 
 	ctrl := gomock.NewController(t)
 	mockGtw := NewMockGateway(ctrl)
-	testHandler := NewHandler(mockGtw)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
 
 	mockGtw.EXPECT().GetTopISPFromSwitzerland(gomock.Any()).
 		Return(testCase.ipsCount, testCase.err)
@@ -163,7 +330,7 @@ func testHandlerGetIpCountByCountryNameNoError(t *testing.T) {
 
 	ctrl := gomock.NewController(t)
 	mockGtw := NewMockGateway(ctrl)
-	testHandler := NewHandler(mockGtw)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
 
 	mockGtw.EXPECT().GetIpCountByCountryName(gomock.Any(), testCase.countryName).
 		Return(testCase.ipsCount, testCase.err)
@@ -203,7 +370,7 @@ func testHandlerGetIpCountByCountryNameNoCountryParamError(t *testing.T) {
 
 	ctrl := gomock.NewController(t)
 	mockGtw := NewMockGateway(ctrl)
-	testHandler := NewHandler(mockGtw)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
 
 	req, err := http.NewRequest("GET", testCase.url, nil)
 	if err != nil {
@@ -243,7 +410,7 @@ func testHandlerGetIpCountByCountryNameGtwError(t *testing.T) {
 
 	ctrl := gomock.NewController(t)
 	mockGtw := NewMockGateway(ctrl)
-	testHandler := NewHandler(mockGtw)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
 
 	mockGtw.EXPECT().GetIpCountByCountryName(gomock.Any(), testCase.countryName).
 		Return(testCase.ipsCount, testCase.err)
@@ -286,9 +453,9 @@ func testHandlerGetDataFromIpNoError(t *testing.T) {
 	testCase.muxVars = map[string]string{"ip": testCase.ip}
 	ctrl := gomock.NewController(t)
 	mockGtw := NewMockGateway(ctrl)
-	testHandler := NewHandler(mockGtw)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
 
-	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), testCase.ip).
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr(testCase.ip)).
 		Return(testCase.ipData, testCase.err)
 
 	req, err := http.NewRequest("GET", testCase.url, nil)
@@ -326,7 +493,7 @@ func testHandlerGetDataFromIpNoIpParamError(t *testing.T) {
 	}
 	ctrl := gomock.NewController(t)
 	mockGtw := NewMockGateway(ctrl)
-	testHandler := NewHandler(mockGtw)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
 
 	req, err := http.NewRequest("GET", testCase.url, nil)
 	if err != nil {
@@ -356,13 +523,13 @@ func testHandlerGetDataFromIpInvalidIpParamError(t *testing.T) {
 
 	testCase := test{
 		expectedCode: http.StatusBadRequest,
-		expectedBody: "ip is not a valid Ipv4 format bad request\n",
+		expectedBody: "badIP is not a valid ip bad request\n",
 		url:          "/ipdata/{ip}",
 		muxVars:      map[string]string{"ip": "badIP"},
 	}
 	ctrl := gomock.NewController(t)
 	mockGtw := NewMockGateway(ctrl)
-	testHandler := NewHandler(mockGtw)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
 
 	req, err := http.NewRequest("GET", testCase.url, nil)
 	if err != nil {
@@ -379,6 +546,26 @@ func testHandlerGetDataFromIpInvalidIpParamError(t *testing.T) {
 	assert.Equal(t, testCase.expectedBody, rr.Body.String())
 }
 
+func testHandlerGetDataFromIpLeadingZerosError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
+
+	req, err := http.NewRequest("GET", "/ipdata/{ip}", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{"ip": "010.0.0.1"})
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.GetDataFromIP)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "010.0.0.1 is not a valid ip bad request\n", rr.Body.String())
+}
+
 func testHandlerGetDataFromIpGtwError(t *testing.T) {
 	type test struct {
 		ip           string
@@ -401,9 +588,9 @@ func testHandlerGetDataFromIpGtwError(t *testing.T) {
 	testCase.muxVars = map[string]string{"ip": testCase.ip}
 	ctrl := gomock.NewController(t)
 	mockGtw := NewMockGateway(ctrl)
-	testHandler := NewHandler(mockGtw)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
 
-	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), testCase.ip).
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr(testCase.ip)).
 		Return(testCase.ipData, testCase.err)
 
 	req, err := http.NewRequest("GET", testCase.url, nil)
@@ -443,9 +630,9 @@ func testHandlerGetDataFromIpGtwNotFoundError(t *testing.T) {
 	testCase.muxVars = map[string]string{"ip": testCase.ip}
 	ctrl := gomock.NewController(t)
 	mockGtw := NewMockGateway(ctrl)
-	testHandler := NewHandler(mockGtw)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
 
-	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), testCase.ip).
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr(testCase.ip)).
 		Return(testCase.ipData, testCase.err)
 
 	req, err := http.NewRequest("GET", testCase.url, nil)
@@ -462,3 +649,1089 @@ func testHandlerGetDataFromIpGtwNotFoundError(t *testing.T) {
 	assert.Equal(t, testCase.expectedCode, rr.Code)
 	assert.Equal(t, testCase.expectedBody, rr.Body.String())
 }
+
+func testHandlerGetDataFromCallerRemoteAddr(t *testing.T) {
+	type test struct {
+		remoteAddr   string
+		headers      map[string]string
+		callerIp     string
+		expectedCode int
+		expectedBody string
+	}
+
+	upDataBytes, _ := json.Marshal(mockIpDataGateway)
+	testCase := test{
+		remoteAddr:   "127.0.0.1:43210",
+		callerIp:     "127.0.0.1",
+		expectedCode: http.StatusOK,
+		expectedBody: string(upDataBytes),
+	}
+
+	utilTestGetDataFromCaller(t, nil, "", testCase.remoteAddr, testCase.headers, testCase.callerIp, testCase.expectedCode, testCase.expectedBody)
+}
+
+func testHandlerGetDataFromCallerTrustedForwardedFor(t *testing.T) {
+	type test struct {
+		remoteAddr   string
+		headers      map[string]string
+		callerIp     string
+		expectedCode int
+		expectedBody string
+	}
+
+	upDataBytes, _ := json.Marshal(mockIpDataGateway)
+	testCase := test{
+		remoteAddr:   "10.0.0.1:43210",
+		headers:      map[string]string{"X-Forwarded-For": "127.0.0.1, 10.0.0.2"},
+		callerIp:     "127.0.0.1",
+		expectedCode: http.StatusOK,
+		expectedBody: string(upDataBytes),
+	}
+
+	utilTestGetDataFromCaller(t, []string{"10.0.0.0/8"}, "", testCase.remoteAddr, testCase.headers, testCase.callerIp, testCase.expectedCode, testCase.expectedBody)
+}
+
+func testHandlerGetDataFromCallerTrustedForwarded(t *testing.T) {
+	type test struct {
+		remoteAddr   string
+		headers      map[string]string
+		callerIp     string
+		expectedCode int
+		expectedBody string
+	}
+
+	upDataBytes, _ := json.Marshal(mockIpDataGateway)
+	testCase := test{
+		remoteAddr:   "10.0.0.1:43210",
+		headers:      map[string]string{"Forwarded": `for="127.0.0.1:5000";proto=https, for=10.0.0.2`, "X-Forwarded-For": "8.8.8.8"},
+		callerIp:     "127.0.0.1",
+		expectedCode: http.StatusOK,
+		expectedBody: string(upDataBytes),
+	}
+
+	utilTestGetDataFromCaller(t, []string{"10.0.0.0/8"}, "Forwarded", testCase.remoteAddr, testCase.headers, testCase.callerIp, testCase.expectedCode, testCase.expectedBody)
+}
+
+func testHandlerGetDataFromCallerClientForwardedIgnored(t *testing.T) {
+	type test struct {
+		remoteAddr   string
+		headers      map[string]string
+		callerIp     string
+		expectedCode int
+		expectedBody string
+	}
+
+	upDataBytes, _ := json.Marshal(mockIpDataGateway)
+	testCase := test{
+		remoteAddr:   "10.0.0.1:43210",
+		headers:      map[string]string{"Forwarded": "for=8.8.8.8", "X-Forwarded-For": "127.0.0.1"},
+		callerIp:     "127.0.0.1",
+		expectedCode: http.StatusOK,
+		expectedBody: string(upDataBytes),
+	}
+
+	utilTestGetDataFromCaller(t, []string{"10.0.0.0/8"}, "", testCase.remoteAddr, testCase.headers, testCase.callerIp, testCase.expectedCode, testCase.expectedBody)
+}
+
+func testHandlerGetDataFromCallerUntrustedForwarded(t *testing.T) {
+	type test struct {
+		remoteAddr   string
+		headers      map[string]string
+		callerIp     string
+		expectedCode int
+		expectedBody string
+	}
+
+	upDataBytes, _ := json.Marshal(mockIpDataGateway)
+	testCase := test{
+		remoteAddr:   "127.0.0.1:43210",
+		headers:      map[string]string{"Forwarded": "for=8.8.8.8"},
+		callerIp:     "127.0.0.1",
+		expectedCode: http.StatusOK,
+		expectedBody: string(upDataBytes),
+	}
+
+	utilTestGetDataFromCaller(t, []string{"10.0.0.0/8"}, "", testCase.remoteAddr, testCase.headers, testCase.callerIp, testCase.expectedCode, testCase.expectedBody)
+}
+
+func testHandlerGetDataFromCallerIpv6(t *testing.T) {
+	type test struct {
+		remoteAddr   string
+		headers      map[string]string
+		callerIp     string
+		expectedCode int
+		expectedBody string
+	}
+
+	upDataBytes, _ := json.Marshal(mockIpDataGateway)
+	testCase := test{
+		remoteAddr:   "[::1]:43210",
+		callerIp:     "::1",
+		expectedCode: http.StatusOK,
+		expectedBody: string(upDataBytes),
+	}
+
+	utilTestGetDataFromCaller(t, nil, "", testCase.remoteAddr, testCase.headers, testCase.callerIp, testCase.expectedCode, testCase.expectedBody)
+}
+
+func testHandlerGetDataFromCallerPublicIpv6(t *testing.T) {
+	type test struct {
+		remoteAddr   string
+		headers      map[string]string
+		callerIp     string
+		expectedCode int
+		expectedBody string
+	}
+
+	testCase := test{
+		remoteAddr:   "[2a00:1450:4001:81b::200e]:43210",
+		expectedCode: http.StatusOK,
+		expectedBody: `{"ip_string":"2a00:1450:4001:81b::200e"}`,
+	}
+
+	utilTestGetDataFromCaller(t, nil, "", testCase.remoteAddr, testCase.headers, testCase.callerIp, testCase.expectedCode, testCase.expectedBody)
+}
+
+// utilTestGetDataFromCaller runs GetDataFromCaller expecting a gateway lookup of callerIp when it's not empty
+func utilTestGetDataFromCaller(t *testing.T, trustedCIDRs []string, clientIPHeader string, remoteAddr string, headers map[string]string, callerIp string, expectedCode int, expectedBody string) {
+	trusted, err := common.ParseTrustedProxies(trustedCIDRs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, trusted, clientIPHeader, Policy{})
+
+	if callerIp != "" {
+		mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr(callerIp)).
+			Return(mockIpDataGateway, nil)
+	}
+
+	req, err := http.NewRequest("GET", "/ipdata/me", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.RemoteAddr = remoteAddr
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.GetDataFromCaller)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, expectedCode, rr.Code)
+	assert.Equal(t, expectedBody, rr.Body.String())
+}
+
+func testHandlerGetIpCountByCountryGroupedNoError(t *testing.T) {
+	type test struct {
+		expectedCode int
+		expectedBody string
+		countryName  string
+		groupBy      []string
+		ipsCount     int64
+		groups       []GroupedIpCount
+		err          error
+		url          string
+		muxVars      map[string]string
+	}
+
+	testCase := test{
+		expectedCode: http.StatusOK,
+		expectedBody: `{"country_name":"Ireland","ip_count":4,"group_by":["proxy_type","region_name"],"groups":[{"group":{"proxy_type":"VPN","region_name":"Dublin"},"ip_count":4,"percentage":100}]}`,
+		countryName:  "Ireland",
+		groupBy:      []string{"proxy_type", "region_name"},
+		ipsCount:     4,
+		groups:       []GroupedIpCount{{Group: map[string]string{"proxy_type": "VPN", "region_name": "Dublin"}, IpCount: 4, Percentage: 100}},
+		url:          "/ipdata/count/Ireland?group_by=proxy_type,region_name",
+		muxVars:      map[string]string{"country": "Ireland"},
+	}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
+
+	mockGtw.EXPECT().GetIpCountByCountryNameGrouped(gomock.Any(), testCase.countryName, testCase.groupBy).
+		Return(testCase.ipsCount, testCase.groups, testCase.err)
+
+	req, err := http.NewRequest("GET", testCase.url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = mux.SetURLVars(req, testCase.muxVars)
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.GetIPCountByCountry)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, testCase.expectedCode, rr.Code)
+	assert.Equal(t, testCase.expectedBody, rr.Body.String())
+}
+
+func testHandlerGetIpCountByCountryNotGroupedNoError(t *testing.T) {
+	type test struct {
+		expectedCode int
+		expectedBody string
+		countryName  string
+		ipsCount     int64
+		err          error
+		url          string
+		muxVars      map[string]string
+	}
+
+	testCase := test{
+		expectedCode: http.StatusOK,
+		expectedBody: `{"country_name":"Ireland","ip_count":42}`,
+		countryName:  "Ireland",
+		ipsCount:     42,
+		url:          "/ipdata/count/Ireland",
+		muxVars:      map[string]string{"country": "Ireland"},
+	}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
+
+	mockGtw.EXPECT().GetIpCountByCountryName(gomock.Any(), testCase.countryName).
+		Return(testCase.ipsCount, testCase.err)
+
+	req, err := http.NewRequest("GET", testCase.url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = mux.SetURLVars(req, testCase.muxVars)
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.GetIPCountByCountry)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, testCase.expectedCode, rr.Code)
+	assert.Equal(t, testCase.expectedBody, rr.Body.String())
+}
+
+func testHandlerGetIpCountByCountryGtwError(t *testing.T) {
+	type test struct {
+		expectedCode int
+		expectedBody string
+		countryName  string
+		groupBy      []string
+		err          error
+		url          string
+		muxVars      map[string]string
+	}
+
+	testCase := test{
+		expectedCode: http.StatusBadRequest,
+		expectedBody: "invalid group_by dimension ip_from bad request\n",
+		countryName:  "Ireland",
+		groupBy:      []string{"ip_from"},
+		err:          fmt.Errorf("invalid group_by dimension ip_from %w", common.ErrorBadRequest),
+		url:          "/ipdata/count/Ireland?group_by=ip_from",
+		muxVars:      map[string]string{"country": "Ireland"},
+	}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
+
+	mockGtw.EXPECT().GetIpCountByCountryNameGrouped(gomock.Any(), testCase.countryName, testCase.groupBy).
+		Return(int64(0), []GroupedIpCount{}, testCase.err)
+
+	req, err := http.NewRequest("GET", testCase.url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = mux.SetURLVars(req, testCase.muxVars)
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.GetIPCountByCountry)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, testCase.expectedCode, rr.Code)
+	assert.Equal(t, testCase.expectedBody, rr.Body.String())
+}
+
+func testHandlerGetTopCountriesNoError(t *testing.T) {
+	type test struct {
+		expectedCode int
+		expectedBody string
+		proxyType    string
+		limit        int
+		countries    []CountryIpCount
+		err          error
+		url          string
+	}
+
+	testCase := test{
+		expectedCode: http.StatusOK,
+		expectedBody: `[{"country_code":"CH","country_name":"Switzerland","ip_count":25,"share":12.5}]`,
+		proxyType:    "VPN",
+		limit:        1,
+		countries:    []CountryIpCount{{CountryCode: "CH", CountryName: "Switzerland", IpCount: 25, Share: 12.5}},
+		url:          "/ipdata/countries/top?limit=1&proxy_type=vpn",
+	}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
+
+	mockGtw.EXPECT().GetTopCountries(gomock.Any(), testCase.proxyType, testCase.limit).
+		Return(testCase.countries, testCase.err)
+
+	req, err := http.NewRequest("GET", testCase.url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.GetTopCountries)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, testCase.expectedCode, rr.Code)
+	assert.Equal(t, testCase.expectedBody, rr.Body.String())
+}
+
+func testHandlerGetTopCountriesInvalidLimitError(t *testing.T) {
+	type test struct {
+		expectedCode int
+		expectedBody string
+		url          string
+	}
+
+	testCase := test{
+		expectedCode: http.StatusBadRequest,
+		expectedBody: "param: limit must be a number bad request\n",
+		url:          "/ipdata/countries/top?limit=ten",
+	}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
+
+	req, err := http.NewRequest("GET", testCase.url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.GetTopCountries)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, testCase.expectedCode, rr.Code)
+	assert.Equal(t, testCase.expectedBody, rr.Body.String())
+}
+
+func testHandlerRunQueryNoError(t *testing.T) {
+	type test struct {
+		expectedCode int
+		expectedBody string
+		query        Query
+		result       QueryResult
+		err          error
+		url          string
+	}
+
+	testCase := test{
+		expectedCode: http.StatusOK,
+		expectedBody: `{"total_ip_count":4,"groups":[{"group":{"isp":"Swisscom"},"ip_count":3,"percentage":75}]}`,
+		query:        Query{Filters: map[string]string{"country_code": "CH", "proxy_type": "VPN"}, GroupBy: []string{"isp"}, Order: "ip_count_desc", Limit: 20},
+		result:       QueryResult{TotalIpCount: 4, Groups: []GroupedIpCount{{Group: map[string]string{"isp": "Swisscom"}, IpCount: 3, Percentage: 75}}},
+		url:          "/ipdata/query?country_code=CH&proxy_type=VPN&group_by=isp&order=ip_count_desc&limit=20",
+	}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
+
+	mockGtw.EXPECT().RunQuery(gomock.Any(), testCase.query).
+		Return(testCase.result, testCase.err)
+
+	req, err := http.NewRequest("GET", testCase.url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.RunQuery)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, testCase.expectedCode, rr.Code)
+	assert.Equal(t, testCase.expectedBody, rr.Body.String())
+}
+
+func testHandlerRunQueryUnknownParamError(t *testing.T) {
+	type test struct {
+		expectedCode int
+		expectedBody string
+		url          string
+	}
+
+	testCase := test{
+		expectedCode: http.StatusBadRequest,
+		expectedBody: "param: ip_from is not a valid query param bad request\n",
+		url:          "/ipdata/query?ip_from=1",
+	}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
+
+	req, err := http.NewRequest("GET", testCase.url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.RunQuery)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, testCase.expectedCode, rr.Code)
+	assert.Equal(t, testCase.expectedBody, rr.Body.String())
+}
+
+func testHandlerSearchIspNoError(t *testing.T) {
+	type test struct {
+		expectedCode int
+		expectedBody string
+		matches      []IspMatch
+		err          error
+		url          string
+	}
+
+	testCase := test{
+		expectedCode: http.StatusOK,
+		expectedBody: `[{"isp":"Swisscom","ip_count":10,"match":"prefix"}]`,
+		matches:      []IspMatch{{Isp: "Swisscom", IpCount: 10, Match: MatchPrefix}},
+		url:          "/ipdata/isp/search?q=swisscom&country_code=ch",
+	}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
+
+	mockGtw.EXPECT().SearchIsp(gomock.Any(), "swisscom", "CH", defaultSearchLimit).
+		Return(testCase.matches, testCase.err)
+
+	req, err := http.NewRequest("GET", testCase.url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.SearchIsp)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, testCase.expectedCode, rr.Code)
+	assert.Equal(t, testCase.expectedBody, rr.Body.String())
+}
+
+func testHandlerAutocompleteNoError(t *testing.T) {
+	type test struct {
+		expectedCode int
+		expectedBody string
+		suggestions  []Suggestion
+		err          error
+		url          string
+		muxVars      map[string]string
+	}
+
+	testCase := test{
+		expectedCode: http.StatusOK,
+		expectedBody: `[{"value":"Zurich","ip_count":300}]`,
+		suggestions:  []Suggestion{{Value: "Zurich", IpCount: 300}},
+		url:          "/ipdata/autocomplete/city_name?q=Zu&limit=3",
+		muxVars:      map[string]string{"field": "city_name"},
+	}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
+
+	mockGtw.EXPECT().Autocomplete(gomock.Any(), "city_name", "Zu", "", 3).
+		Return(testCase.suggestions, testCase.err)
+
+	req, err := http.NewRequest("GET", testCase.url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = mux.SetURLVars(req, testCase.muxVars)
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.Autocomplete)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, testCase.expectedCode, rr.Code)
+	assert.Equal(t, testCase.expectedBody, rr.Body.String())
+}
+
+func testHandlerAutocompleteGtwError(t *testing.T) {
+	type test struct {
+		expectedCode int
+		expectedBody string
+		err          error
+		url          string
+		muxVars      map[string]string
+	}
+
+	testCase := test{
+		expectedCode: http.StatusBadRequest,
+		expectedBody: "invalid autocomplete field isp bad request\n",
+		err:          fmt.Errorf("invalid autocomplete field isp %w", common.ErrorBadRequest),
+		url:          "/ipdata/autocomplete/isp?q=Sw",
+		muxVars:      map[string]string{"field": "isp"},
+	}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
+
+	mockGtw.EXPECT().Autocomplete(gomock.Any(), "isp", "Sw", "", defaultSearchLimit).
+		Return([]Suggestion{}, testCase.err)
+
+	req, err := http.NewRequest("GET", testCase.url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = mux.SetURLVars(req, testCase.muxVars)
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.Autocomplete)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, testCase.expectedCode, rr.Code)
+	assert.Equal(t, testCase.expectedBody, rr.Body.String())
+}
+
+func testHandlerGetRangesByIspNoError(t *testing.T) {
+	type test struct {
+		expectedCode int
+		expectedBody string
+		page         IspRangesPage
+		err          error
+		url          string
+		muxVars      map[string]string
+	}
+
+	testCase := test{
+		expectedCode: http.StatusOK,
+		expectedBody: `{"isp":"IPXO Limited","ranges":[{"ip_from":2130706433,"ip_to":2130706433,"ip_from_string":"127.0.0.1","ip_to_string":"127.0.0.1","cidrs":["127.0.0.1/32"],"proxy_type":"PUB","country_code":"ES"}],"offset":20,"limit":10}`,
+		page: IspRangesPage{
+			Isp:    "IPXO Limited",
+			Ranges: []IpRange{{IpFrom: 2130706433, IpTo: 2130706433, IpFromString: "127.0.0.1", IpToString: "127.0.0.1", CIDRs: []string{"127.0.0.1/32"}, ProxyType: "PUB", CountryCode: "ES"}},
+			Offset: 20,
+			Limit:  10,
+		},
+		url:     "/ipdata/isp/IPXO%20Limited/ranges?country_code=es&limit=10&offset=20",
+		muxVars: map[string]string{"isp": "IPXO Limited"},
+	}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
+
+	mockGtw.EXPECT().GetRangesByIsp(gomock.Any(), "IPXO Limited", "ES", 10, 20).
+		Return(testCase.page, testCase.err)
+
+	req, err := http.NewRequest("GET", testCase.url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = mux.SetURLVars(req, testCase.muxVars)
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.GetRangesByIsp)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, testCase.expectedCode, rr.Code)
+	assert.Equal(t, testCase.expectedBody, rr.Body.String())
+}
+
+func testHandlerGetRangesByIspInvalidOffsetError(t *testing.T) {
+	type test struct {
+		expectedCode int
+		expectedBody string
+		url          string
+		muxVars      map[string]string
+	}
+
+	testCase := test{
+		expectedCode: http.StatusBadRequest,
+		expectedBody: "param: offset must be a number bad request\n",
+		url:          "/ipdata/isp/IPXO%20Limited/ranges?offset=first",
+		muxVars:      map[string]string{"isp": "IPXO Limited"},
+	}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
+
+	req, err := http.NewRequest("GET", testCase.url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = mux.SetURLVars(req, testCase.muxVars)
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.GetRangesByIsp)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, testCase.expectedCode, rr.Code)
+	assert.Equal(t, testCase.expectedBody, rr.Body.String())
+}
+
+func testHandlerExportBlocklistNoError(t *testing.T) {
+	type test struct {
+		expectedCode int
+		expectedBody string
+		filter       BlocklistFilter
+		cidrs        []string
+		err          error
+		url          string
+	}
+
+	testCase := test{
+		expectedCode: http.StatusOK,
+		expectedBody: "deny 10.0.0.0/24;\ndeny 10.0.2.3/32;\n",
+		filter:       BlocklistFilter{ProxyTypes: []string{"VPN", "TOR"}, CountryCodes: []string{}, Isp: ""},
+		cidrs:        []string{"10.0.0.0/24", "10.0.2.3/32"},
+		url:          "/ipdata/export/blocklist?format=nginx&proxy_type=vpn,tor",
+	}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
+
+	mockGtw.EXPECT().GetBlocklist(gomock.Any(), testCase.filter).
+		Return(testCase.cidrs, testCase.err)
+
+	req, err := http.NewRequest("GET", testCase.url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.ExportBlocklist)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, testCase.expectedCode, rr.Code)
+	assert.Equal(t, testCase.expectedBody, rr.Body.String())
+	assert.Equal(t, "text/plain; charset=utf-8", rr.Header().Get("Content-Type"))
+}
+
+func testHandlerExportBlocklistInvalidFormatError(t *testing.T) {
+	type test struct {
+		expectedCode int
+		expectedBody string
+		url          string
+	}
+
+	testCase := test{
+		expectedCode: http.StatusBadRequest,
+		expectedBody: "invalid format pf bad request\n",
+		url:          "/ipdata/export/blocklist?format=pf&proxy_type=VPN",
+	}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
+
+	req, err := http.NewRequest("GET", testCase.url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.ExportBlocklist)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, testCase.expectedCode, rr.Code)
+	assert.Equal(t, testCase.expectedBody, rr.Body.String())
+}
+
+func testHandlerExtractIPsNoError(t *testing.T) {
+	type test struct {
+		expectedCode int
+		expectedBody string
+		text         string
+		extracted    []ExtractedIP
+		err          error
+	}
+
+	testCase := test{
+		expectedCode: http.StatusOK,
+		expectedBody: `{"ips":[{"ip":"1.1.1.1","version":4,"status":"not_found"},{"ip":"10.0.0.1","version":4,"status":"skipped","reason":"private"}]}`,
+		text:         "from 1.1.1.1 via 10.0.0.1",
+		extracted: []ExtractedIP{
+			{Ip: "1.1.1.1", Version: 4, Status: ExtractNotFound},
+			{Ip: "10.0.0.1", Version: 4, Status: ExtractSkipped, Reason: "private"},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
+
+	mockGtw.EXPECT().ExtractIPs(gomock.Any(), testCase.text).
+		Return(testCase.extracted, testCase.err)
+
+	req, err := http.NewRequest("POST", "/ipdata/extract", strings.NewReader(testCase.text))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.ExtractIPs)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, testCase.expectedCode, rr.Code)
+	assert.Equal(t, testCase.expectedBody, rr.Body.String())
+}
+
+func testHandlerExtractIPsTooManyIpsError(t *testing.T) {
+	type test struct {
+		expectedCode int
+		expectedBody string
+		err          error
+	}
+
+	testCase := test{
+		expectedCode: http.StatusBadRequest,
+		expectedBody: "the text has more than 1000 distinct ips bad request\n",
+		err:          fmt.Errorf("the text has more than 1000 distinct ips %w", common.ErrorBadRequest),
+	}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
+
+	mockGtw.EXPECT().ExtractIPs(gomock.Any(), gomock.Any()).
+		Return([]ExtractedIP{}, testCase.err)
+
+	req, err := http.NewRequest("POST", "/ipdata/extract", strings.NewReader("8.8.8.8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.ExtractIPs)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, testCase.expectedCode, rr.Code)
+	assert.Equal(t, testCase.expectedBody, rr.Body.String())
+}
+
+func testHandlerExplainIPNoError(t *testing.T) {
+	type test struct {
+		ip          string
+		explanation Explanation
+		err         error
+	}
+
+	testCase := test{
+		ip: "5.181.131.180",
+		explanation: Explanation{
+			Ip:        "5.181.131.180",
+			Status:    ExplainFound,
+			Data:      IpData{ProxyType: "VPN", CountryCode: "GB", IpString: "5.181.131.180"},
+			Backend:   BackendPostgres,
+			Overrides: []string{},
+			Timings:   LayerTimings{GatewayMicros: 120, DaoMicros: 100},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", NewPolicy([]string{"VPN"}, nil))
+
+	mockGtw.EXPECT().ExplainIP(gomock.Any(), netip.MustParseAddr(testCase.ip)).
+		Return(testCase.explanation, testCase.err)
+
+	req, err := http.NewRequest("GET", "/ipdata/{ip}/explain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{"ip": testCase.ip})
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.ExplainIP)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	output := Explanation{}
+	err = json.Unmarshal(rr.Body.Bytes(), &output)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, PolicyExplanation{Allowed: false, Reason: "proxy_type VPN is blocked",
+		BlockedProxyTypes: []string{"VPN"}, BlockedCountryCodes: []string{}}, output.Policy)
+	assert.Equal(t, int64(100), output.Timings.DaoMicros)
+	assert.True(t, output.Timings.HandlerMicros >= 0)
+}
+
+func testHandlerExplainIPInvalidIpError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
+
+	req, err := http.NewRequest("GET", "/ipdata/{ip}/explain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{"ip": "5.181.131"})
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.ExplainIP)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "5.181.131 is not a valid ip bad request\n", rr.Body.String())
+}
+
+func testHandlerGetNeighborsNoError(t *testing.T) {
+	type test struct {
+		ip           string
+		n            string
+		neighbors    Neighbors
+		expectedBody string
+	}
+
+	testCase := test{
+		ip: "0.0.0.45",
+		n:  "1",
+		neighbors: Neighbors{
+			Ip:     "0.0.0.45",
+			Gap:    &IpRange{IpFrom: 40, IpTo: 49},
+			Before: []IpRange{{IpFrom: 30, IpTo: 39, ProxyType: "TOR", CountryCode: "CH", Isp: "Init7"}},
+			After:  []IpRange{},
+		},
+		expectedBody: `{"ip":"0.0.0.45","gap":{"ip_from":40,"ip_to":49,"ip_from_string":"","ip_to_string":"","cidrs":null,"proxy_type":"","country_code":""},` +
+			`"before":[{"ip_from":30,"ip_to":39,"ip_from_string":"","ip_to_string":"","cidrs":null,"proxy_type":"TOR","country_code":"CH","isp":"Init7"}],"after":[]}`,
+	}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
+
+	mockGtw.EXPECT().GetNeighbors(gomock.Any(), netip.MustParseAddr(testCase.ip), 1).Return(testCase.neighbors, nil)
+
+	req, err := http.NewRequest("GET", "/ipdata/{ip}/neighbors?n="+testCase.n, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{"ip": testCase.ip})
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.GetNeighbors)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, testCase.expectedBody, rr.Body.String())
+}
+
+func testHandlerGetNeighborsInvalidNError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
+
+	req, err := http.NewRequest("GET", "/ipdata/{ip}/neighbors?n=five", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{"ip": "5.181.131.180"})
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.GetNeighbors)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "param: n must be a number bad request\n", rr.Body.String())
+}
+
+func testHandlerGetCoverageJSON(t *testing.T) {
+	coverage := Coverage{Ranges: 1, CoveredIps: 8, Gaps: []CoverageSpan{}, Overlaps: []CoverageSpan{}}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
+
+	mockGtw.EXPECT().GetCoverage(gomock.Any(), defaultCoverageLimit).DoAndReturn(func(ctx context.Context, _ int) (Coverage, error) {
+		deadline, found := ctx.Deadline()
+		assert.True(t, found)
+		assert.WithinDuration(t, time.Now().Add(coverageTimeout), deadline, time.Second)
+		return coverage, nil
+	})
+
+	req, err := http.NewRequest("GET", "/ipdata/coverage", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.GetCoverage)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	output := Coverage{}
+	err = json.Unmarshal(rr.Body.Bytes(), &output)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, coverage, output)
+}
+
+func testHandlerGetCoverageCSV(t *testing.T) {
+	coverage := Coverage{Ranges: 1, CoveredIps: 8, ByCountry: []CoverageCount{{Key: "GB", IpCount: 8, Percentage: 100}}}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
+
+	mockGtw.EXPECT().GetCoverage(gomock.Any(), 0).Return(coverage, nil)
+
+	req, err := http.NewRequest("GET", "/ipdata/coverage?format=csv&limit=0", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.GetCoverage)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/csv", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "section,key,ip_count,percentage\n"+
+		"total,ranges,1,\n"+
+		"total,invalid_ranges,0,\n"+
+		"total,covered,8,0.00\n"+
+		"total,gaps,0,0.00\n"+
+		"total,overlaps,0,\n"+
+		"country,GB,8,100.00\n", rr.Body.String())
+}
+
+func testHandlerGetCoverageInvalidFormatError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
+
+	req, err := http.NewRequest("GET", "/ipdata/coverage?format=xml", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.GetCoverage)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "invalid format xml bad request\n", rr.Body.String())
+}
+
+func testHandlerCreateOverrideNoError(t *testing.T) {
+	created := Override{ID: "a1", Range: "81.2.69.160/30", IpFrom: 1359103392, IpTo: 1359103395, NotProxy: true,
+		UpdatedAt: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
+
+	mockGtw.EXPECT().CreateOverride(gomock.Any(), Override{Range: "81.2.69.160/30", NotProxy: true}).Return(created, nil)
+
+	req, err := http.NewRequest("POST", "/ipdata/overrides", strings.NewReader(`{"range":"81.2.69.160/30","not_proxy":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.CreateOverride)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "/ipdata/overrides/a1", rr.Header().Get("Location"))
+	assert.Equal(t, `{"id":"a1","range":"81.2.69.160/30","ip_from":1359103392,"ip_to":1359103395,"not_proxy":true,`+
+		`"updated_at":"2026-04-01T00:00:00Z"}`, rr.Body.String())
+}
+
+func testHandlerCreateOverrideInvalidBodyError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
+
+	req, err := http.NewRequest("POST", "/ipdata/overrides", strings.NewReader(`{"range":`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.CreateOverride)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "invalid body. unexpected EOF bad request\n", rr.Body.String())
+}
+
+func testHandlerDeleteOverrideNoError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
+
+	mockGtw.EXPECT().DeleteOverride(gomock.Any(), "a1").Return(nil)
+
+	req, err := http.NewRequest("DELETE", "/ipdata/overrides/{id}", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{"id": "a1"})
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.DeleteOverride)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "", rr.Body.String())
+}
+
+func testHandlerGetOverrideNotFoundError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw, nil, "", Policy{})
+
+	mockGtw.EXPECT().GetOverride(gomock.Any(), "a1").Return(Override{}, fmt.Errorf("override a1 %w", common.ErrorNotFound))
+
+	req, err := http.NewRequest("GET", "/ipdata/overrides/{id}", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{"id": "a1"})
+
+	rr := httptest.NewRecorder()
+	httpHandler := http.HandlerFunc(testHandler.GetOverride)
+
+	httpHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, "override a1 not found\n", rr.Body.String())
+}
@@ -0,0 +1,27 @@
+package ipdata
+
+import "net/http"
+
+// enrichment headers used to hand the IpData of a client to other services
+const (
+	HeaderProxyType   = "X-Proxy-Type"
+	HeaderCountryCode = "X-Country-Code"
+	HeaderISP         = "X-ISP"
+)
+
+// SetEnrichmentHeaders replaces any previous enrichment header with the values of the given data
+func SetEnrichmentHeaders(header http.Header, ipData IpData) {
+	header.Del(HeaderProxyType)
+	header.Del(HeaderCountryCode)
+	header.Del(HeaderISP)
+
+	if ipData.ProxyType != "" {
+		header.Set(HeaderProxyType, ipData.ProxyType)
+	}
+	if ipData.CountryCode != "" {
+		header.Set(HeaderCountryCode, ipData.CountryCode)
+	}
+	if ipData.ISP != "" {
+		header.Set(HeaderISP, ipData.ISP)
+	}
+}
@@ -1,16 +1,50 @@
 package ipdata
 
-import (
-	"math"
-	"net"
-	"strconv"
-	"strings"
+const (
+	CountryCodeSwitzerland = "CH"
 )
 
+// dimensions the ip counts can be grouped by
 const (
-	CountryCodeSwitzerland = "CH"
+	DimensionProxyType  = "proxy_type"
+	DimensionRegionName = "region_name"
+	DimensionCityName   = "city_name"
+	DimensionISP        = "isp"
+
+	maxGroupDimensions = 2
+)
+
+var groupDimensions = []string{DimensionProxyType, DimensionRegionName, DimensionCityName, DimensionISP}
+
+// kinds of IspMatch, in order of relevance
+const (
+	MatchPrefix    = "prefix"
+	MatchSubstring = "substring"
+	MatchFuzzy     = "fuzzy"
 )
 
+var matchesByRank = []string{MatchPrefix, MatchSubstring, MatchFuzzy}
+
+// fields that can be autocompleted
+const (
+	AutocompleteCountryName = "country_name"
+	AutocompleteRegionName  = "region_name"
+	AutocompleteCityName    = "city_name"
+)
+
+// validProxyTypes are the IP2Proxy usage types of the proxies
+var validProxyTypes = map[string]struct{}{
+	"VPN": {}, // anonymizing vpn services
+	"TOR": {}, // tor exit nodes
+	"DCH": {}, // hosting providers and data centers
+	"PUB": {}, // public proxies
+	"WEB": {}, // web proxies
+	"SES": {}, // search engine robots
+	"RES": {}, // residential proxies
+	"CPN": {}, // consumer privacy networks
+	"EPN": {}, // enterprise private networks
+}
+
 type IpData struct {
 	IpFrom      int64  `json:"ip_from,omitempty"`
 	IpTo        int64  `json:"ip_to,omitempty"`
@@ -21,6 +55,10 @@ type IpData struct {
 	CityName    string `json:"city_name,omitempty"`
 	ISP         string `json:"isp,omitempty"`
 	IpString    string `json:"ip_string,omitempty"`
+	// SpecialPurpose is set instead of the dataset fields for the addresses of the special purpose registry
+	SpecialPurpose *SpecialPurpose `json:"special_purpose,omitempty"`
+	// Override is set when a local override replaced the dataset data
+	Override *OverrideSource `json:"override,omitempty"`
 }
 
 type IspIpCount struct {
@@ -28,29 +66,35 @@ type IspIpCount struct {
 	IpCount int64  `json:"ip_count"`
 }
 
-func stringIPToDecimal(ip string) int64 {
-	segments := strings.Split(ip, ".")
-	var decimalValue int64
-	for i := 3; i >= 0; i-- {
-		segmentValue, _ := strconv.ParseInt(segments[3-i], 10, 64)
-		segmentValueWeighted := segmentValue * ipConverterWeights[i]
-		decimalValue += segmentValueWeighted
-	}
-	return decimalValue
+type CountryIpCount struct {
+	CountryCode string  `json:"country_code"`
+	CountryName string  `json:"country_name"`
+	IpCount     int64   `json:"ip_count"`
+	Share       float64 `json:"share"`
 }
 
-var ipConverterWeights = buildIPConverterWeights()
+// IspMatch is an ISP found by a search and how it matched the searched text
+type IspMatch struct {
+	Isp     string `json:"isp"`
+	IpCount int64  `json:"ip_count"`
+	Match   string `json:"match"`
+}
 
-func buildIPConverterWeights() map[int]int64 {
-	ipConvertedWeights := make(map[int]int64)
-	for i := 0; i <= 3; i++ {
-		ipConvertedWeights[i] = int64(math.Pow(256, float64(i)))
-	}
-	return ipConvertedWeights
+// Suggestion is an autocomplete value with its ip count
+type Suggestion struct {
+	Value   string `json:"value"`
+	IpCount int64  `json:"ip_count"`
+}
+
+type GroupedIpCount struct {
+	Group      map[string]string `json:"group"`
+	IpCount    int64             `json:"ip_count"`
+	Percentage float64           `json:"percentage"`
 }
 
-func isValidCountryCode(countryCode string) bool {
-	_, found := validCountryCodes[countryCode]
+// IsValidCountryCode tells if the country code is in the ISO 3166 registry
+func IsValidCountryCode(countryCode string) bool {
+	_, found := countryNamesByCode[countryCode]
 	return found
 }
 
@@ -60,512 +104,280 @@ func isValidCountryName(countryName string) bool {
 
 }
 
-func isValidIp(ip string) bool {
-	parsedIP := net.ParseIP(ip)
-	if parsedIP == nil {
-		return false
+// IsValidProxyType tells if the proxy type is one of the IP2Proxy ones
+func IsValidProxyType(proxyType string) bool {
+	_, found := validProxyTypes[proxyType]
+	return found
+}
+
+func isValidGroupDimension(dimension string) bool {
+	for _, valid := range groupDimensions {
+		if dimension == valid {
+			return true
+		}
 	}
-	return true
+	return false
 }
 
-var validCountryCodes = map[string]struct{}{
-	"AF": {},
-	"AL": {},
-	"DZ": {},
-	"AS": {},
-	"AD": {},
-	"AO": {},
-	"AI": {},
-	"AQ": {},
-	"AG": {},
-	"AR": {},
-	"AM": {},
-	"AW": {},
-	"AU": {},
-	"AT": {},
-	"AZ": {},
-	"BS": {},
-	"BH": {},
-	"BD": {},
-	"BB": {},
-	"BY": {},
-	"BE": {},
-	"BZ": {},
-	"BJ": {},
-	"BM": {},
-	"BT": {},
-	"BO": {},
-	"BQ": {},
-	"BA": {},
-	"BW": {},
-	"BV": {},
-	"BR": {},
-	"IO": {},
-	"BN": {},
-	"BG": {},
-	"BF": {},
-	"BI": {},
-	"CV": {},
-	"KH": {},
-	"CM": {},
-	"CA": {},
-	"KY": {},
-	"CF": {},
-	"TD": {},
-	"CL": {},
-	"CN": {},
-	"CX": {},
-	"CC": {},
-	"CO": {},
-	"KM": {},
-	"CD": {},
-	"CG": {},
-	"CK": {},
-	"CR": {},
-	"HR": {},
-	"CU": {},
-	"CW": {},
-	"CY": {},
-	"CZ": {},
-	"CI": {},
-	"DK": {},
-	"DJ": {},
-	"DM": {},
-	"DO": {},
-	"EC": {},
-	"EG": {},
-	"SV": {},
-	"GQ": {},
-	"ER": {},
-	"EE": {},
-	"SZ": {},
-	"ET": {},
-	"FK": {},
-	"FO": {},
-	"FJ": {},
-	"FI": {},
-	"FR": {},
-	"GF": {},
-	"PF": {},
-	"TF": {},
-	"GA": {},
-	"GM": {},
-	"GE": {},
-	"DE": {},
-	"GH": {},
-	"GI": {},
-	"GR": {},
-	"GL": {},
-	"GD": {},
-	"GP": {},
-	"GU": {},
-	"GT": {},
-	"GG": {},
-	"GN": {},
-	"GW": {},
-	"GY": {},
-	"HT": {},
-	"HM": {},
-	"VA": {},
-	"HN": {},
-	"HK": {},
-	"HU": {},
-	"IS": {},
-	"IN": {},
-	"ID": {},
-	"IR": {},
-	"IQ": {},
-	"IE": {},
-	"IM": {},
-	"IL": {},
-	"IT": {},
-	"JM": {},
-	"JP": {},
-	"JE": {},
-	"JO": {},
-	"KZ": {},
-	"KE": {},
-	"KI": {},
-	"KP": {},
-	"KR": {},
-	"KW": {},
-	"KG": {},
-	"LA": {},
-	"LV": {},
-	"LB": {},
-	"LS": {},
-	"LR": {},
-	"LY": {},
-	"LI": {},
-	"LT": {},
-	"LU": {},
-	"MO": {},
-	"MG": {},
-	"MW": {},
-	"MY": {},
-	"MV": {},
-	"ML": {},
-	"MT": {},
-	"MH": {},
-	"MQ": {},
-	"MR": {},
-	"MU": {},
-	"YT": {},
-	"MX": {},
-	"FM": {},
-	"MD": {},
-	"MC": {},
-	"MN": {},
-	"ME": {},
-	"MS": {},
-	"MA": {},
-	"MZ": {},
-	"MM": {},
-	"NA": {},
-	"NR": {},
-	"NP": {},
-	"NL": {},
-	"NC": {},
-	"NZ": {},
-	"NI": {},
-	"NE": {},
-	"NG": {},
-	"NU": {},
-	"NF": {},
-	"MP": {},
-	"NO": {},
-	"OM": {},
-	"PK": {},
-	"PW": {},
-	"PS": {},
-	"PA": {},
-	"PG": {},
-	"PY": {},
-	"PE": {},
-	"PH": {},
-	"PN": {},
-	"PL": {},
-	"PT": {},
-	"PR": {},
-	"QA": {},
-	"MK": {},
-	"RO": {},
-	"RU": {},
-	"RW": {},
-	"RE": {},
-	"BL": {},
-	"SH": {},
-	"KN": {},
-	"LC": {},
-	"MF": {},
-	"PM": {},
-	"VC": {},
-	"WS": {},
-	"SM": {},
-	"ST": {},
-	"SA": {},
-	"SN": {},
-	"RS": {},
-	"SC": {},
-	"SL": {},
-	"SG": {},
-	"SX": {},
-	"SK": {},
-	"SI": {},
-	"SB": {},
-	"SO": {},
-	"ZA": {},
-	"GS": {},
-	"SS": {},
-	"ES": {},
-	"LK": {},
-	"SD": {},
-	"SR": {},
-	"SJ": {},
-	"SE": {},
-	"CH": {},
-	"SY": {},
-	"TW": {},
-	"TJ": {},
-	"TZ": {},
-	"TH": {},
-	"TL": {},
-	"TG": {},
-	"TK": {},
-	"TO": {},
-	"TT": {},
-	"TN": {},
-	"TR": {},
-	"TM": {},
-	"TC": {},
-	"TV": {},
-	"UG": {},
-	"UA": {},
-	"AE": {},
-	"GB": {},
-	"UM": {},
-	"US": {},
-	"UY": {},
-	"UZ": {},
-	"VU": {},
-	"VE": {},
-	"VN": {},
-	"VG": {},
-	"VI": {},
-	"WF": {},
-	"EH": {},
-	"YE": {},
-	"ZM": {},
-	"ZW": {},
-	"AX": {}}
+// countryNamesByCode is the ISO 3166 registry of country codes and their canonical names
+var countryNamesByCode = map[string]string{
+	"AF": "Afghanistan",
+	"AL": "Albania",
+	"DZ": "Algeria",
+	"AS": "American Samoa",
+	"AD": "Andorra",
+	"AO": "Angola",
+	"AI": "Anguilla",
+	"AQ": "Antarctica",
+	"AG": "Antigua and Barbuda",
+	"AR": "Argentina",
+	"AM": "Armenia",
+	"AW": "Aruba",
+	"AU": "Australia",
+	"AT": "Austria",
+	"AZ": "Azerbaijan",
+	"BS": "Bahamas (the)",
+	"BH": "Bahrain",
+	"BD": "Bangladesh",
+	"BB": "Barbados",
+	"BY": "Belarus",
+	"BE": "Belgium",
+	"BZ": "Belize",
+	"BJ": "Benin",
+	"BM": "Bermuda",
+	"BT": "Bhutan",
+	"BO": "Bolivia (Plurinational State of)",
+	"BQ": "Bonaire, Sint Eustatius and Saba",
+	"BA": "Bosnia and Herzegovina",
+	"BW": "Botswana",
+	"BV": "Bouvet Island",
+	"BR": "Brazil",
+	"IO": "British Indian Ocean Territory (the)",
+	"BN": "Brunei Darussalam",
+	"BG": "Bulgaria",
+	"BF": "Burkina Faso",
+	"BI": "Burundi",
+	"CV": "Cabo Verde",
+	"KH": "Cambodia",
+	"CM": "Cameroon",
+	"CA": "Canada",
+	"KY": "Cayman Islands (the)",
+	"CF": "Central African Republic (the)",
+	"TD": "Chad",
+	"CL": "Chile",
+	"CN": "China",
+	"CX": "Christmas Island",
+	"CC": "Cocos (Keeling) Islands (the)",
+	"CO": "Colombia",
+	"KM": "Comoros (the)",
+	"CD": "Congo (the Democratic Republic of the)",
+	"CG": "Congo (the)",
+	"CK": "Cook Islands (the)",
+	"CR": "Costa Rica",
+	"HR": "Croatia",
+	"CU": "Cuba",
+	"CW": "Curaçao",
+	"CY": "Cyprus",
+	"CZ": "Czechia",
+	"CI": "Côte d'Ivoire",
+	"DK": "Denmark",
+	"DJ": "Djibouti",
+	"DM": "Dominica",
+	"DO": "Dominican Republic (the)",
+	"EC": "Ecuador",
+	"EG": "Egypt",
+	"SV": "El Salvador",
+	"GQ": "Equatorial Guinea",
+	"ER": "Eritrea",
+	"EE": "Estonia",
+	"SZ": "Eswatini",
+	"ET": "Ethiopia",
+	"FK": "Falkland Islands (the) [Malvinas]",
+	"FO": "Faroe Islands (the)",
+	"FJ": "Fiji",
+	"FI": "Finland",
+	"FR": "France",
+	"GF": "French Guiana",
+	"PF": "French Polynesia",
+	"TF": "French Southern Territories (the)",
+	"GA": "Gabon",
+	"GM": "Gambia (the)",
+	"GE": "Georgia",
+	"DE": "Germany",
+	"GH": "Ghana",
+	"GI": "Gibraltar",
+	"GR": "Greece",
+	"GL": "Greenland",
+	"GD": "Grenada",
+	"GP": "Guadeloupe",
+	"GU": "Guam",
+	"GT": "Guatemala",
+	"GG": "Guernsey",
+	"GN": "Guinea",
+	"GW": "Guinea-Bissau",
+	"GY": "Guyana",
+	"HT": "Haiti",
+	"HM": "Heard Island and McDonald Islands",
+	"VA": "Holy See (the)",
+	"HN": "Honduras",
+	"HK": "Hong Kong",
+	"HU": "Hungary",
+	"IS": "Iceland",
+	"IN": "India",
+	"ID": "Indonesia",
+	"IR": "Iran (Islamic Republic of)",
+	"IQ": "Iraq",
+	"IE": "Ireland",
+	"IM": "Isle of Man",
+	"IL": "Israel",
+	"IT": "Italy",
+	"JM": "Jamaica",
+	"JP": "Japan",
+	"JE": "Jersey",
+	"JO": "Jordan",
+	"KZ": "Kazakhstan",
+	"KE": "Kenya",
+	"KI": "Kiribati",
+	"KP": "Korea (the Democratic People's Republic of)",
+	"KR": "Korea (the Republic of)",
+	"KW": "Kuwait",
+	"KG": "Kyrgyzstan",
+	"LA": "Lao People's Democratic Republic (the)",
+	"LV": "Latvia",
+	"LB": "Lebanon",
+	"LS": "Lesotho",
+	"LR": "Liberia",
+	"LY": "Libya",
+	"LI": "Liechtenstein",
+	"LT": "Lithuania",
+	"LU": "Luxembourg",
+	"MO": "Macao",
+	"MG": "Madagascar",
+	"MW": "Malawi",
+	"MY": "Malaysia",
+	"MV": "Maldives",
+	"ML": "Mali",
+	"MT": "Malta",
+	"MH": "Marshall Islands (the)",
+	"MQ": "Martinique",
+	"MR": "Mauritania",
+	"MU": "Mauritius",
+	"YT": "Mayotte",
+	"MX": "Mexico",
+	"FM": "Micronesia (Federated States of)",
+	"MD": "Moldova (the Republic of)",
+	"MC": "Monaco",
+	"MN": "Mongolia",
+	"ME": "Montenegro",
+	"MS": "Montserrat",
+	"MA": "Morocco",
+	"MZ": "Mozambique",
+	"MM": "Myanmar",
+	"NA": "Namibia",
+	"NR": "Nauru",
+	"NP": "Nepal",
+	"NL": "Netherlands (the)",
+	"NC": "New Caledonia",
+	"NZ": "New Zealand",
+	"NI": "Nicaragua",
+	"NE": "Niger (the)",
+	"NG": "Nigeria",
+	"NU": "Niue",
+	"NF": "Norfolk Island",
+	"MP": "Northern Mariana Islands (the)",
+	"NO": "Norway",
+	"OM": "Oman",
+	"PK": "Pakistan",
+	"PW": "Palau",
+	"PS": "Palestine, State of",
+	"PA": "Panama",
+	"PG": "Papua New Guinea",
+	"PY": "Paraguay",
+	"PE": "Peru",
+	"PH": "Philippines (the)",
+	"PN": "Pitcairn",
+	"PL": "Poland",
+	"PT": "Portugal",
+	"PR": "Puerto Rico",
+	"QA": "Qatar",
+	"MK": "Republic of North Macedonia",
+	"RO": "Romania",
+	"RU": "Russian Federation (the)",
+	"RW": "Rwanda",
+	"RE": "Réunion",
+	"BL": "Saint Barthélemy",
+	"SH": "Saint Helena, Ascension and Tristan da Cunha",
+	"KN": "Saint Kitts and Nevis",
+	"LC": "Saint Lucia",
+	"MF": "Saint Martin (French part)",
+	"PM": "Saint Pierre and Miquelon",
+	"VC": "Saint Vincent and the Grenadines",
+	"WS": "Samoa",
+	"SM": "San Marino",
+	"ST": "Sao Tome and Principe",
+	"SA": "Saudi Arabia",
+	"SN": "Senegal",
+	"RS": "Serbia",
+	"SC": "Seychelles",
+	"SL": "Sierra Leone",
+	"SG": "Singapore",
+	"SX": "Sint Maarten (Dutch part)",
+	"SK": "Slovakia",
+	"SI": "Slovenia",
+	"SB": "Solomon Islands",
+	"SO": "Somalia",
+	"ZA": "South Africa",
+	"GS": "South Georgia and the South Sandwich Islands",
+	"SS": "South Sudan",
+	"ES": "Spain",
+	"LK": "Sri Lanka",
+	"SD": "Sudan (the)",
+	"SR": "Suriname",
+	"SJ": "Svalbard and Jan Mayen",
+	"SE": "Sweden",
+	"CH": "Switzerland",
+	"SY": "Syrian Arab Republic",
+	"TW": "Taiwan (Province of China)",
+	"TJ": "Tajikistan",
+	"TZ": "Tanzania, United Republic of",
+	"TH": "Thailand",
+	"TL": "Timor-Leste",
+	"TG": "Togo",
+	"TK": "Tokelau",
+	"TO": "Tonga",
+	"TT": "Trinidad and Tobago",
+	"TN": "Tunisia",
+	"TR": "Turkey",
+	"TM": "Turkmenistan",
+	"TC": "Turks and Caicos Islands (the)",
+	"TV": "Tuvalu",
+	"UG": "Uganda",
+	"UA": "Ukraine",
+	"AE": "United Arab Emirates (the)",
+	"GB": "United Kingdom of Great Britain and Northern Ireland (the)",
+	"UM": "United States Minor Outlying Islands (the)",
+	"US": "United States of America (the)",
+	"UY": "Uruguay",
+	"UZ": "Uzbekistan",
+	"VU": "Vanuatu",
+	"VE": "Venezuela (Bolivarian Republic of)",
+	"VN": "Viet Nam",
+	"VG": "Virgin Islands (British)",
+	"VI": "Virgin Islands (U.S.)",
+	"WF": "Wallis and Futuna",
+	"EH": "Western Sahara",
+	"YE": "Yemen",
+	"ZM": "Zambia",
+	"ZW": "Zimbabwe",
+	"AX": "Åland Islands",
+}
+
+var validCountries = buildValidCountries()
 
-var validCountries = map[string]struct{}{"Afghanistan": {},
-	"Albania":                                {},
-	"Algeria":                                {},
-	"American Samoa":                         {},
-	"Andorra":                                {},
-	"Angola":                                 {},
-	"Anguilla":                               {},
-	"Antarctica":                             {},
-	"Antigua and Barbuda":                    {},
-	"Argentina":                              {},
-	"Armenia":                                {},
-	"Aruba":                                  {},
-	"Australia":                              {},
-	"Austria":                                {},
-	"Azerbaijan":                             {},
-	"Bahamas (the)":                          {},
-	"Bahrain":                                {},
-	"Bangladesh":                             {},
-	"Barbados":                               {},
-	"Belarus":                                {},
-	"Belgium":                                {},
-	"Belize":                                 {},
-	"Benin":                                  {},
-	"Bermuda":                                {},
-	"Bhutan":                                 {},
-	"Bolivia (Plurinational State of)":       {},
-	"Bonaire, Sint Eustatius and Saba":       {},
-	"Bosnia and Herzegovina":                 {},
-	"Botswana":                               {},
-	"Bouvet Island":                          {},
-	"Brazil":                                 {},
-	"British Indian Ocean Territory (the)":   {},
-	"Brunei Darussalam":                      {},
-	"Bulgaria":                               {},
-	"Burkina Faso":                           {},
-	"Burundi":                                {},
-	"Cabo Verde":                             {},
-	"Cambodia":                               {},
-	"Cameroon":                               {},
-	"Canada":                                 {},
-	"Cayman Islands (the)":                   {},
-	"Central African Republic (the)":         {},
-	"Chad":                                   {},
-	"Chile":                                  {},
-	"China":                                  {},
-	"Christmas Island":                       {},
-	"Cocos (Keeling) Islands (the)":          {},
-	"Colombia":                               {},
-	"Comoros (the)":                          {},
-	"Congo (the Democratic Republic of the)": {},
-	"Congo (the)":                            {},
-	"Cook Islands (the)":                     {},
-	"Costa Rica":                             {},
-	"Croatia":                                {},
-	"Cuba":                                   {},
-	"Curaçao":                                {},
-	"Cyprus":                                 {},
-	"Czechia":                                {},
-	"Côte d'Ivoire":                          {},
-	"Denmark":                                {},
-	"Djibouti":                               {},
-	"Dominica":                               {},
-	"Dominican Republic (the)":               {},
-	"Ecuador":                                {},
-	"Egypt":                                  {},
-	"El Salvador":                            {},
-	"Equatorial Guinea":                      {},
-	"Eritrea":                                {},
-	"Estonia":                                {},
-	"Eswatini":                               {},
-	"Ethiopia":                               {},
-	"Falkland Islands (the) [Malvinas]":      {},
-	"Faroe Islands (the)":                    {},
-	"Fiji":                                   {},
-	"Finland":                                {},
-	"France":                                 {},
-	"French Guiana":                          {},
-	"French Polynesia":                       {},
-	"French Southern Territories (the)":      {},
-	"Gabon":                                  {},
-	"Gambia (the)":                           {},
-	"Georgia":                                {},
-	"Germany":                                {},
-	"Ghana":                                  {},
-	"Gibraltar":                              {},
-	"Greece":                                 {},
-	"Greenland":                              {},
-	"Grenada":                                {},
-	"Guadeloupe":                             {},
-	"Guam":                                   {},
-	"Guatemala":                              {},
-	"Guernsey":                               {},
-	"Guinea":                                 {},
-	"Guinea-Bissau":                          {},
-	"Guyana":                                 {},
-	"Haiti":                                  {},
-	"Heard Island and McDonald Islands":      {},
-	"Holy See (the)":                         {},
-	"Honduras":                               {},
-	"Hong Kong":                              {},
-	"Hungary":                                {},
-	"Iceland":                                {},
-	"India":                                  {},
-	"Indonesia":                              {},
-	"Iran (Islamic Republic of)":             {},
-	"Iraq":                                   {},
-	"Ireland":                                {},
-	"Isle of Man":                            {},
-	"Israel":                                 {},
-	"Italy":                                  {},
-	"Jamaica":                                {},
-	"Japan":                                  {},
-	"Jersey":                                 {},
-	"Jordan":                                 {},
-	"Kazakhstan":                             {},
-	"Kenya":                                  {},
-	"Kiribati":                               {},
-	"Korea (the Democratic People's Republic of)": {},
-	"Korea (the Republic of)":                     {},
-	"Kuwait":                                      {},
-	"Kyrgyzstan":                                  {},
-	"Lao People's Democratic Republic (the)":      {},
-	"Latvia":                                      {},
-	"Lebanon":                                     {},
-	"Lesotho":                                     {},
-	"Liberia":                                     {},
-	"Libya":                                       {},
-	"Liechtenstein":                               {},
-	"Lithuania":                                   {},
-	"Luxembourg":                                  {},
-	"Macao":                                       {},
-	"Madagascar":                                  {},
-	"Malawi":                                      {},
-	"Malaysia":                                    {},
-	"Maldives":                                    {},
-	"Mali":                                        {},
-	"Malta":                                       {},
-	"Marshall Islands (the)":                      {},
-	"Martinique":                                  {},
-	"Mauritania":                                  {},
-	"Mauritius":                                   {},
-	"Mayotte":                                     {},
-	"Mexico":                                      {},
-	"Micronesia (Federated States of)":            {},
-	"Moldova (the Republic of)":                   {},
-	"Monaco":                                      {},
-	"Mongolia":                                    {},
-	"Montenegro":                                  {},
-	"Montserrat":                                  {},
-	"Morocco":                                     {},
-	"Mozambique":                                  {},
-	"Myanmar":                                     {},
-	"Namibia":                                     {},
-	"Nauru":                                       {},
-	"Nepal":                                       {},
-	"Netherlands (the)":                           {},
-	"New Caledonia":                               {},
-	"New Zealand":                                 {},
-	"Nicaragua":                                   {},
-	"Niger (the)":                                 {},
-	"Nigeria":                                     {},
-	"Niue":                                        {},
-	"Norfolk Island":                              {},
-	"Northern Mariana Islands (the)":              {},
-	"Norway":                                      {},
-	"Oman":                                        {},
-	"Pakistan":                                    {},
-	"Palau":                                       {},
-	"Palestine, State of":                         {},
-	"Panama":                                      {},
-	"Papua New Guinea":                            {},
-	"Paraguay":                                    {},
-	"Peru":                                        {},
-	"Philippines (the)":                           {},
-	"Pitcairn":                                    {},
-	"Poland":                                      {},
-	"Portugal":                                    {},
-	"Puerto Rico":                                 {},
-	"Qatar":                                       {},
-	"Republic of North Macedonia":                 {},
-	"Romania":                                     {},
-	"Russian Federation (the)":                    {},
-	"Rwanda":                                      {},
-	"Réunion":                                     {},
-	"Saint Barthélemy":                            {},
-	"Saint Helena, Ascension and Tristan da Cunha": {},
-	"Saint Kitts and Nevis":                        {},
-	"Saint Lucia":                                  {},
-	"Saint Martin (French part)":                   {},
-	"Saint Pierre and Miquelon":                    {},
-	"Saint Vincent and the Grenadines":             {},
-	"Samoa":                                        {},
-	"San Marino":                                   {},
-	"Sao Tome and Principe":                        {},
-	"Saudi Arabia":                                 {},
-	"Senegal":                                      {},
-	"Serbia":                                       {},
-	"Seychelles":                                   {},
-	"Sierra Leone":                                 {},
-	"Singapore":                                    {},
-	"Sint Maarten (Dutch part)":                    {},
-	"Slovakia":                                     {},
-	"Slovenia":                                     {},
-	"Solomon Islands":                              {},
-	"Somalia":                                      {},
-	"South Africa":                                 {},
-	"South Georgia and the South Sandwich Islands": {},
-	"South Sudan":                    {},
-	"Spain":                          {},
-	"Sri Lanka":                      {},
-	"Sudan (the)":                    {},
-	"Suriname":                       {},
-	"Svalbard and Jan Mayen":         {},
-	"Sweden":                         {},
-	"Switzerland":                    {},
-	"Syrian Arab Republic":           {},
-	"Taiwan (Province of China)":     {},
-	"Tajikistan":                     {},
-	"Tanzania, United Republic of":   {},
-	"Thailand":                       {},
-	"Timor-Leste":                    {},
-	"Togo":                           {},
-	"Tokelau":                        {},
-	"Tonga":                          {},
-	"Trinidad and Tobago":            {},
-	"Tunisia":                        {},
-	"Turkey":                         {},
-	"Turkmenistan":                   {},
-	"Turks and Caicos Islands (the)": {},
-	"Tuvalu":                         {},
-	"Uganda":                         {},
-	"Ukraine":                        {},
-	"United Arab Emirates (the)":     {},
-	"United Kingdom of Great Britain and Northern Ireland (the)": {},
-	"United States Minor Outlying Islands (the)":                 {},
-	"United States of America (the)":                             {},
-	"Uruguay":                                                    {},
-	"Uzbekistan":                                                 {},
-	"Vanuatu":                                                    {},
-	"Venezuela (Bolivarian Republic of)":                         {},
-	"Viet Nam":                                                   {},
-	"Virgin Islands (British)":                                   {},
-	"Virgin Islands (U.S.)":                                      {},
-	"Wallis and Futuna":                                          {},
-	"Western Sahara":                                             {},
-	"Yemen":                                                      {},
-	"Zambia":                                                     {},
-	"Zimbabwe":                                                   {},
-	"Åland Islands":                                              {},
+func buildValidCountries() map[string]struct{} {
+	countries := make(map[string]struct{}, len(countryNamesByCode))
+	for _, name := range countryNamesByCode {
+		countries[name] = struct{}{}
+	}
+	return countries
 }
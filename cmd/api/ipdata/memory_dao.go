@@ -0,0 +1,343 @@
+package ipdata
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"context"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// memoryDao is a Dao over ranges held in memory, used with the datasets loaded from a file.
+// it answers the same as the sql dao except the ISP search, which has no trigram (fuzzy) matches
+type memoryDao struct {
+	ranges  []IpData
+	version string
+}
+
+// NewMemoryDao builds a Dao over the given ranges, they must not overlap
+func NewMemoryDao(ranges []IpData) Dao {
+	sorted := make([]IpData, len(ranges))
+	copy(sorted, ranges)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].IpFrom < sorted[j].IpFrom })
+	return memoryDao{ranges: sorted}
+}
+
+// NewDatasetFileDao builds a Dao over the ranges of the dataset file at path, see LoadDatasetFile. Its version is the
+// file name and modification time
+func NewDatasetFileDao(path string) (Dao, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	ranges, err := LoadDatasetFile(path)
+	if err != nil {
+		return nil, err
+	}
+	d := NewMemoryDao(ranges).(memoryDao)
+	d.version = fmt.Sprintf("%s@%s", filepath.Base(path), info.ModTime().UTC().Format(time.RFC3339))
+	return d, nil
+}
+
+func (d memoryDao) Backend() string {
+	return BackendMemory
+}
+
+func (d memoryDao) DatasetVersion(_ context.Context) (string, error) {
+	return d.version, nil
+}
+
+// GetByIp gets all the data of the given ipv4
+func (d memoryDao) GetByIp(_ context.Context, addr netip.Addr) (IpData, error) {
+	ip, isIPv4 := AddrToDecimal(addr)
+	if !isIPv4 {
+		return IpData{}, fmt.Errorf("ip %s is not an ipv4 %w", addr, common.ErrorBadRequest)
+	}
+	// first range starting after the ip, the previous one is the only candidate
+	i := sort.Search(len(d.ranges), func(i int) bool { return d.ranges[i].IpFrom > ip })
+	if i == 0 || d.ranges[i-1].IpTo < ip {
+		return IpData{}, fmt.Errorf("error with get query with DB.  %w", common.ErrorNotFound)
+	}
+	return d.ranges[i-1], nil
+}
+
+// GetIpSumByCountry gets the number of Ips of the given countryName
+func (d memoryDao) GetIpSumByCountry(_ context.Context, countryName string) (int64, error) {
+	var ipSum int64
+	for _, data := range d.ranges {
+		if data.CountryName == countryName {
+			ipSum += ipCountOf(data)
+		}
+	}
+	return ipSum, nil
+}
+
+// GetTopIspByCountryCode get the top (limit) ISPs from the given countryCode
+func (d memoryDao) GetTopIspByCountryCode(_ context.Context, countryCode string, limit int) ([]IspIpCount, error) {
+	groups := d.group(func(data IpData) bool { return data.CountryCode == countryCode }, []string{DimensionISP})
+
+	ispCounts := make([]IspIpCount, 0, len(groups))
+	for _, group := range limitGroups(groups, limit) {
+		ispCounts = append(ispCounts, IspIpCount{Isp: group.Group[DimensionISP], IpCount: group.IpCount})
+	}
+	return ispCounts, nil
+}
+
+// GetIpSumByCountryGrouped gets the number of Ips of the given countryName grouped by the given dimensions
+func (d memoryDao) GetIpSumByCountryGrouped(_ context.Context, countryName string, groupBy []string) ([]GroupedIpCount, error) {
+	if _, found := countryGroupedQueries[strings.Join(groupBy, ",")]; !found {
+		return []GroupedIpCount{}, fmt.Errorf("invalid group_by %v %w", groupBy, common.ErrorBadRequest)
+	}
+	return d.group(func(data IpData) bool { return data.CountryName == countryName }, groupBy), nil
+}
+
+// GetTopCountries gets the top (limit) countries by ip count, only counting the given proxyType if not empty.
+// also returns the total ip count of all the countries
+func (d memoryDao) GetTopCountries(_ context.Context, proxyType string, limit int) ([]CountryIpCount, int64, error) {
+	groups := d.group(func(data IpData) bool { return proxyType == "" || data.ProxyType == proxyType }, []string{"country_code"})
+
+	total := totalOf(groups)
+	countryCounts := make([]CountryIpCount, 0, len(groups))
+	for _, group := range limitGroups(groups, limit) {
+		countryCounts = append(countryCounts, CountryIpCount{CountryCode: group.Group["country_code"], IpCount: group.IpCount})
+	}
+	return countryCounts, total, nil
+}
+
+// RunQuery returns the groups of the given query and the total ip count of the filtered rows
+func (d memoryDao) RunQuery(_ context.Context, query Query) ([]GroupedIpCount, int64, error) {
+	for field := range query.Filters {
+		if _, found := queryColumns[field]; !found {
+			return []GroupedIpCount{}, 0, fmt.Errorf("invalid filter field %s %w", field, common.ErrorBadRequest)
+		}
+	}
+	groups := d.group(func(data IpData) bool {
+		for field, value := range query.Filters {
+			if columnValue(data, field) != value {
+				return false
+			}
+		}
+		return true
+	}, query.GroupBy)
+
+	switch query.Order {
+	case OrderIpCountAsc:
+		sort.SliceStable(groups, func(i, j int) bool { return groups[i].IpCount < groups[j].IpCount })
+	case OrderGroupAsc:
+		sort.SliceStable(groups, func(i, j int) bool { return groupKey(groups[i], query.GroupBy) < groupKey(groups[j], query.GroupBy) })
+	}
+
+	return limitGroups(groups, query.Limit), totalOf(groups), nil
+}
+
+// SearchIsp gets the ISPs matching the given text by prefix or substring, filtered by countryCode if not empty
+func (d memoryDao) SearchIsp(_ context.Context, text string, countryCode string, limit int) ([]IspMatch, error) {
+	lowerText := strings.ToLower(text)
+	groups := d.group(func(data IpData) bool {
+		return (countryCode == "" || data.CountryCode == countryCode) && strings.Contains(strings.ToLower(data.ISP), lowerText)
+	}, []string{DimensionISP})
+
+	matches := make([]IspMatch, 0, len(groups))
+	for _, group := range groups {
+		match := MatchSubstring
+		if strings.HasPrefix(strings.ToLower(group.Group[DimensionISP]), lowerText) {
+			match = MatchPrefix
+		}
+		matches = append(matches, IspMatch{Isp: group.Group[DimensionISP], IpCount: group.IpCount, Match: match})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Match == MatchPrefix && matches[j].Match != MatchPrefix
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// Autocomplete gets the values of the given field starting with prefix, filtered by countryCode if not empty
+func (d memoryDao) Autocomplete(_ context.Context, field string, prefix string, countryCode string, limit int) ([]Suggestion, error) {
+	if _, found := autocompleteQueries[field]; !found {
+		return []Suggestion{}, fmt.Errorf("invalid autocomplete field %s %w", field, common.ErrorBadRequest)
+	}
+	lowerPrefix := strings.ToLower(prefix)
+	groups := d.group(func(data IpData) bool {
+		return (countryCode == "" || data.CountryCode == countryCode) && strings.HasPrefix(strings.ToLower(columnValue(data, field)), lowerPrefix)
+	}, []string{field})
+
+	suggestions := make([]Suggestion, 0, len(groups))
+	for _, group := range limitGroups(groups, limit) {
+		suggestions = append(suggestions, Suggestion{Value: group.Group[field], IpCount: group.IpCount})
+	}
+	return suggestions, nil
+}
+
+// GetRangesByIsp gets a page of the ranges of the given isp ordered by ip_from, filtered by countryCode if not empty
+func (d memoryDao) GetRangesByIsp(_ context.Context, isp string, countryCode string, limit int, offset int) ([]IpRange, error) {
+	ranges := make([]IpRange, 0)
+	skipped := 0
+	for _, data := range d.ranges {
+		if len(ranges) == limit {
+			break
+		}
+		if data.ISP != isp || (countryCode != "" && data.CountryCode != countryCode) {
+			continue
+		}
+		if skipped < offset {
+			skipped++
+			continue
+		}
+		ranges = append(ranges, IpRange{IpFrom: data.IpFrom, IpTo: data.IpTo, ProxyType: data.ProxyType, CountryCode: data.CountryCode})
+	}
+	return ranges, nil
+}
+
+// GetBlocklistRanges gets the ip_from and ip_to of all the ranges selected by the given filter, ordered by ip_from
+func (d memoryDao) GetBlocklistRanges(_ context.Context, filter BlocklistFilter) ([]IpRange, error) {
+	ranges := make([]IpRange, 0)
+	for _, data := range d.ranges {
+		if len(filter.ProxyTypes) > 0 && indexOfValue(filter.ProxyTypes, data.ProxyType) < 0 {
+			continue
+		}
+		if len(filter.CountryCodes) > 0 && indexOfValue(filter.CountryCodes, data.CountryCode) < 0 {
+			continue
+		}
+		if filter.Isp != "" && data.ISP != filter.Isp {
+			continue
+		}
+		ranges = append(ranges, IpRange{IpFrom: data.IpFrom, IpTo: data.IpTo})
+	}
+	return ranges, nil
+}
+
+// GetPreviousRanges gets the (limit) ranges starting at or before the given ipv4, ordered by ip_from desc
+func (d memoryDao) GetPreviousRanges(_ context.Context, addr netip.Addr, limit int) ([]IpRange, error) {
+	ip, isIPv4 := AddrToDecimal(addr)
+	if !isIPv4 {
+		return []IpRange{}, fmt.Errorf("ip %s is not an ipv4 %w", addr, common.ErrorBadRequest)
+	}
+	ranges := make([]IpRange, 0)
+	i := sort.Search(len(d.ranges), func(i int) bool { return d.ranges[i].IpFrom > ip })
+	for i--; i >= 0 && len(ranges) < limit; i-- {
+		ranges = append(ranges, rangeOf(d.ranges[i]))
+	}
+	return ranges, nil
+}
+
+// GetNextRanges gets the (limit) ranges starting after the given ipv4, ordered by ip_from
+func (d memoryDao) GetNextRanges(_ context.Context, addr netip.Addr, limit int) ([]IpRange, error) {
+	ip, isIPv4 := AddrToDecimal(addr)
+	if !isIPv4 {
+		return []IpRange{}, fmt.Errorf("ip %s is not an ipv4 %w", addr, common.ErrorBadRequest)
+	}
+	ranges := make([]IpRange, 0)
+	i := sort.Search(len(d.ranges), func(i int) bool { return d.ranges[i].IpFrom > ip })
+	for ; i < len(d.ranges) && len(ranges) < limit; i++ {
+		ranges = append(ranges, rangeOf(d.ranges[i]))
+	}
+	return ranges, nil
+}
+
+// WalkRanges calls fn with every range ordered by ip_from
+func (d memoryDao) WalkRanges(_ context.Context, fn func(data IpData) error) error {
+	for _, data := range d.ranges {
+		err := fn(data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func rangeOf(data IpData) IpRange {
+	return IpRange{IpFrom: data.IpFrom, IpTo: data.IpTo, ProxyType: data.ProxyType, CountryCode: data.CountryCode, Isp: data.ISP}
+}
+
+// group sums the ip count of the matching ranges by the given columns, ordered by ip count desc and group values asc
+func (d memoryDao) group(match func(data IpData) bool, columns []string) []GroupedIpCount {
+	counts := make(map[string]*GroupedIpCount)
+	keys := make([]string, 0)
+	for _, data := range d.ranges {
+		if !match(data) {
+			continue
+		}
+		values := make(map[string]string, len(columns))
+		for _, column := range columns {
+			values[column] = columnValue(data, column)
+		}
+		group := GroupedIpCount{Group: values}
+		key := groupKey(group, columns)
+		if _, found := counts[key]; !found {
+			counts[key] = &group
+			keys = append(keys, key)
+		}
+		counts[key].IpCount += ipCountOf(data)
+	}
+
+	groups := make([]GroupedIpCount, 0, len(keys))
+	sort.Strings(keys)
+	for _, key := range keys {
+		groups = append(groups, *counts[key])
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].IpCount > groups[j].IpCount })
+	return groups
+}
+
+// columnValue returns the value of a queryColumns column of the given data
+func columnValue(data IpData, column string) string {
+	switch column {
+	case "country_code":
+		return data.CountryCode
+	case "country_name":
+		return data.CountryName
+	case DimensionProxyType:
+		return data.ProxyType
+	case DimensionRegionName:
+		return data.RegionName
+	case DimensionCityName:
+		return data.CityName
+	case DimensionISP:
+		return data.ISP
+	default:
+		return ""
+	}
+}
+
+func groupKey(group GroupedIpCount, columns []string) string {
+	values := make([]string, 0, len(columns))
+	for _, column := range columns {
+		values = append(values, group.Group[column])
+	}
+	return strings.Join(values, "\x00")
+}
+
+func limitGroups(groups []GroupedIpCount, limit int) []GroupedIpCount {
+	if limit >= 0 && len(groups) > limit {
+		return groups[:limit]
+	}
+	return groups
+}
+
+func totalOf(groups []GroupedIpCount) int64 {
+	var total int64
+	for _, group := range groups {
+		total += group.IpCount
+	}
+	return total
+}
+
+func ipCountOf(data IpData) int64 {
+	return data.IpTo - data.IpFrom + 1
+}
+
+func indexOfValue(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,170 @@
+package ipdata
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMemoryDao_GetByIp(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testMemoryDaoGetByIpNoError},
+		{Scenario: "Ip in a gap not found", TestFn: testMemoryDaoGetByIpGapNotFound},
+		{Scenario: "Ip before the first range not found", TestFn: testMemoryDaoGetByIpBeforeFirstNotFound},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestMemoryDao_Aggregations(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Top ISPs by country code", TestFn: testMemoryDaoGetTopIspByCountryCode},
+		{Scenario: "Ip sum by country", TestFn: testMemoryDaoGetIpSumByCountry},
+		{Scenario: "Query grouped by proxy type", TestFn: testMemoryDaoRunQuery},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestMemoryDao_DatasetFile(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Version of the file", TestFn: testMemoryDaoDatasetFileVersion},
+		{Scenario: "Missing file error", TestFn: testMemoryDaoDatasetFileMissingError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestMemoryDao_Neighbors(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Previous ranges", TestFn: testMemoryDaoGetPreviousRanges},
+		{Scenario: "Next ranges", TestFn: testMemoryDaoGetNextRanges},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func testMemoryDaoGetByIpNoError(t *testing.T) {
+	d := NewMemoryDao(mockMemoryRanges)
+
+	output, err := d.GetByIp(context.Background(), netip.AddrFrom4([4]byte{0, 0, 0, 25}))
+
+	assert.Nil(t, err)
+	assert.Equal(t, mockMemoryRanges[2], output)
+}
+
+func testMemoryDaoGetByIpGapNotFound(t *testing.T) {
+	d := NewMemoryDao(mockMemoryRanges)
+
+	output, err := d.GetByIp(context.Background(), netip.AddrFrom4([4]byte{0, 0, 0, 45}))
+
+	assert.Equal(t, IpData{}, output)
+	assert.True(t, errors.Is(err, common.ErrorNotFound))
+}
+
+func testMemoryDaoGetByIpBeforeFirstNotFound(t *testing.T) {
+	d := NewMemoryDao(mockMemoryRanges)
+
+	output, err := d.GetByIp(context.Background(), netip.AddrFrom4([4]byte{0, 0, 0, 5}))
+
+	assert.Equal(t, IpData{}, output)
+	assert.True(t, errors.Is(err, common.ErrorNotFound))
+}
+
+func testMemoryDaoGetTopIspByCountryCode(t *testing.T) {
+	d := NewMemoryDao(mockMemoryRanges)
+
+	output, err := d.GetTopIspByCountryCode(context.Background(), "CH", 1)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []IspIpCount{{Isp: "Swisscom", IpCount: 20}}, output)
+}
+
+func testMemoryDaoGetIpSumByCountry(t *testing.T) {
+	d := NewMemoryDao(mockMemoryRanges)
+
+	output, err := d.GetIpSumByCountry(context.Background(), "Switzerland")
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(30), output)
+}
+
+func testMemoryDaoRunQuery(t *testing.T) {
+	d := NewMemoryDao(mockMemoryRanges)
+	query := Query{Filters: map[string]string{"country_code": "CH"}, GroupBy: []string{"proxy_type"}, Order: OrderGroupAsc, Limit: 10}
+
+	groups, total, err := d.RunQuery(context.Background(), query)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(30), total)
+	assert.Equal(t, []GroupedIpCount{
+		{Group: map[string]string{"proxy_type": "TOR"}, IpCount: 10},
+		{Group: map[string]string{"proxy_type": "VPN"}, IpCount: 20},
+	}, groups)
+}
+
+func testMemoryDaoGetPreviousRanges(t *testing.T) {
+	d := NewMemoryDao(mockMemoryRanges)
+
+	output, err := d.GetPreviousRanges(context.Background(), netip.AddrFrom4([4]byte{0, 0, 0, 30}), 2)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []IpRange{
+		{IpFrom: 30, IpTo: 39, ProxyType: "TOR", CountryCode: "CH", Isp: "Init7"},
+		{IpFrom: 20, IpTo: 29, ProxyType: "VPN", CountryCode: "CH", Isp: "Swisscom"},
+	}, output)
+}
+
+func testMemoryDaoGetNextRanges(t *testing.T) {
+	d := NewMemoryDao(mockMemoryRanges)
+
+	output, err := d.GetNextRanges(context.Background(), netip.AddrFrom4([4]byte{0, 0, 0, 30}), 5)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []IpRange{{IpFrom: 50, IpTo: 59, ProxyType: "PUB", CountryCode: "AR", Isp: "Telecom Argentina"}}, output)
+}
+
+// mock utils
+
+// unsorted on purpose
+var mockMemoryRanges = []IpData{
+	{IpFrom: 30, IpTo: 39, ProxyType: "TOR", CountryCode: "CH", CountryName: "Switzerland", ISP: "Init7"},
+	{IpFrom: 10, IpTo: 19, ProxyType: "VPN", CountryCode: "CH", CountryName: "Switzerland", ISP: "Swisscom"},
+	{IpFrom: 20, IpTo: 29, ProxyType: "VPN", CountryCode: "CH", CountryName: "Switzerland", ISP: "Swisscom"},
+	{IpFrom: 50, IpTo: 59, ProxyType: "PUB", CountryCode: "AR", CountryName: "Argentina", ISP: "Telecom Argentina"},
+}
+
+func testMemoryDaoDatasetFileVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "IP2PROXY-LITE-PX7.CSV")
+	os.WriteFile(path, []byte(`"95781810","95781817","VPN","GB","United Kingdom of Great Britain and Northern Ireland","England","London","IPXO Limited"`+"\n"), 0o640)
+	modTime := time.Date(2026, 4, 1, 9, 30, 0, 0, time.UTC)
+	os.Chtimes(path, modTime, modTime)
+
+	dao, err := NewDatasetFileDao(path)
+	assert.Nil(t, err)
+	version, err := dao.DatasetVersion(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, "IP2PROXY-LITE-PX7.CSV@2026-04-01T09:30:00Z", version)
+	assert.Equal(t, BackendMemory, dao.Backend())
+}
+
+func testMemoryDaoDatasetFileMissingError(t *testing.T) {
+	_, err := NewDatasetFileDao(filepath.Join(t.TempDir(), "missing.csv"))
+
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
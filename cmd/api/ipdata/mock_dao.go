@@ -6,6 +6,7 @@ package ipdata
 
 import (
 	context "context"
+	netip "net/netip"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -34,23 +35,67 @@ func (m *MockDao) EXPECT() *MockDaoMockRecorder {
 	return m.recorder
 }
 
-// Get mocks base method.
-func (m *MockDao) Get(ctx context.Context, filters *IpData) ([]IpData, error) {
+// Autocomplete mocks base method.
+func (m *MockDao) Autocomplete(ctx context.Context, field string, prefix string, countryCode string, limit int) ([]Suggestion, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get", ctx, filters)
-	ret0, _ := ret[0].([]IpData)
+	ret := m.ctrl.Call(m, "Autocomplete", ctx, field, prefix, countryCode, limit)
+	ret0, _ := ret[0].([]Suggestion)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// Get indicates an expected call of Get.
-func (mr *MockDaoMockRecorder) Get(ctx, filters interface{}) *gomock.Call {
+// Autocomplete indicates an expected call of Autocomplete.
+func (mr *MockDaoMockRecorder) Autocomplete(ctx, field, prefix, countryCode, limit interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockDao)(nil).Get), ctx, filters)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Autocomplete", reflect.TypeOf((*MockDao)(nil).Autocomplete), ctx, field, prefix, countryCode, limit)
+}
+
+// Backend mocks base method.
+func (m *MockDao) Backend() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Backend")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Backend indicates an expected call of Backend.
+func (mr *MockDaoMockRecorder) Backend() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Backend", reflect.TypeOf((*MockDao)(nil).Backend))
+}
+
+// DatasetVersion mocks base method.
+func (m *MockDao) DatasetVersion(ctx context.Context) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DatasetVersion", ctx)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DatasetVersion indicates an expected call of DatasetVersion.
+func (mr *MockDaoMockRecorder) DatasetVersion(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DatasetVersion", reflect.TypeOf((*MockDao)(nil).DatasetVersion), ctx)
+}
+
+// GetBlocklistRanges mocks base method.
+func (m *MockDao) GetBlocklistRanges(ctx context.Context, filter BlocklistFilter) ([]IpRange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlocklistRanges", ctx, filter)
+	ret0, _ := ret[0].([]IpRange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlocklistRanges indicates an expected call of GetBlocklistRanges.
+func (mr *MockDaoMockRecorder) GetBlocklistRanges(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlocklistRanges", reflect.TypeOf((*MockDao)(nil).GetBlocklistRanges), ctx, filter)
 }
 
 // GetByIp mocks base method.
-func (m *MockDao) GetByIp(ctx context.Context, ip int64) (IpData, error) {
+func (m *MockDao) GetByIp(ctx context.Context, ip netip.Addr) (IpData, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetByIp", ctx, ip)
 	ret0, _ := ret[0].(IpData)
@@ -79,6 +124,82 @@ func (mr *MockDaoMockRecorder) GetIpSumByCountry(ctx, countryName interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIpSumByCountry", reflect.TypeOf((*MockDao)(nil).GetIpSumByCountry), ctx, countryName)
 }
 
+// GetIpSumByCountryGrouped mocks base method.
+func (m *MockDao) GetIpSumByCountryGrouped(ctx context.Context, countryName string, groupBy []string) ([]GroupedIpCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIpSumByCountryGrouped", ctx, countryName, groupBy)
+	ret0, _ := ret[0].([]GroupedIpCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIpSumByCountryGrouped indicates an expected call of GetIpSumByCountryGrouped.
+func (mr *MockDaoMockRecorder) GetIpSumByCountryGrouped(ctx, countryName, groupBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIpSumByCountryGrouped", reflect.TypeOf((*MockDao)(nil).GetIpSumByCountryGrouped), ctx, countryName, groupBy)
+}
+
+// GetNextRanges mocks base method.
+func (m *MockDao) GetNextRanges(ctx context.Context, ip netip.Addr, limit int) ([]IpRange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNextRanges", ctx, ip, limit)
+	ret0, _ := ret[0].([]IpRange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNextRanges indicates an expected call of GetNextRanges.
+func (mr *MockDaoMockRecorder) GetNextRanges(ctx, ip, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNextRanges", reflect.TypeOf((*MockDao)(nil).GetNextRanges), ctx, ip, limit)
+}
+
+// GetPreviousRanges mocks base method.
+func (m *MockDao) GetPreviousRanges(ctx context.Context, ip netip.Addr, limit int) ([]IpRange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPreviousRanges", ctx, ip, limit)
+	ret0, _ := ret[0].([]IpRange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPreviousRanges indicates an expected call of GetPreviousRanges.
+func (mr *MockDaoMockRecorder) GetPreviousRanges(ctx, ip, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPreviousRanges", reflect.TypeOf((*MockDao)(nil).GetPreviousRanges), ctx, ip, limit)
+}
+
+// GetRangesByIsp mocks base method.
+func (m *MockDao) GetRangesByIsp(ctx context.Context, isp string, countryCode string, limit int, offset int) ([]IpRange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRangesByIsp", ctx, isp, countryCode, limit, offset)
+	ret0, _ := ret[0].([]IpRange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRangesByIsp indicates an expected call of GetRangesByIsp.
+func (mr *MockDaoMockRecorder) GetRangesByIsp(ctx, isp, countryCode, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRangesByIsp", reflect.TypeOf((*MockDao)(nil).GetRangesByIsp), ctx, isp, countryCode, limit, offset)
+}
+
+// GetTopCountries mocks base method.
+func (m *MockDao) GetTopCountries(ctx context.Context, proxyType string, limit int) ([]CountryIpCount, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTopCountries", ctx, proxyType, limit)
+	ret0, _ := ret[0].([]CountryIpCount)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetTopCountries indicates an expected call of GetTopCountries.
+func (mr *MockDaoMockRecorder) GetTopCountries(ctx, proxyType, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTopCountries", reflect.TypeOf((*MockDao)(nil).GetTopCountries), ctx, proxyType, limit)
+}
+
 // GetTopIspByCountryCode mocks base method.
 func (m *MockDao) GetTopIspByCountryCode(ctx context.Context, countryCode string, limit int) ([]IspIpCount, error) {
 	m.ctrl.T.Helper()
@@ -93,3 +214,48 @@ func (mr *MockDaoMockRecorder) GetTopIspByCountryCode(ctx, countryCode, limit in
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTopIspByCountryCode", reflect.TypeOf((*MockDao)(nil).GetTopIspByCountryCode), ctx, countryCode, limit)
 }
+
+// RunQuery mocks base method.
+func (m *MockDao) RunQuery(ctx context.Context, query Query) ([]GroupedIpCount, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunQuery", ctx, query)
+	ret0, _ := ret[0].([]GroupedIpCount)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RunQuery indicates an expected call of RunQuery.
+func (mr *MockDaoMockRecorder) RunQuery(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunQuery", reflect.TypeOf((*MockDao)(nil).RunQuery), ctx, query)
+}
+
+// SearchIsp mocks base method.
+func (m *MockDao) SearchIsp(ctx context.Context, text string, countryCode string, limit int) ([]IspMatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchIsp", ctx, text, countryCode, limit)
+	ret0, _ := ret[0].([]IspMatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchIsp indicates an expected call of SearchIsp.
+func (mr *MockDaoMockRecorder) SearchIsp(ctx, text, countryCode, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchIsp", reflect.TypeOf((*MockDao)(nil).SearchIsp), ctx, text, countryCode, limit)
+}
+
+// WalkRanges mocks base method.
+func (m *MockDao) WalkRanges(ctx context.Context, fn func(data IpData) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WalkRanges", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WalkRanges indicates an expected call of WalkRanges.
+func (mr *MockDaoMockRecorder) WalkRanges(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalkRanges", reflect.TypeOf((*MockDao)(nil).WalkRanges), ctx, fn)
+}
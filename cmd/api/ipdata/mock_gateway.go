@@ -6,6 +6,7 @@ package ipdata
 
 import (
 	context "context"
+	netip "net/netip"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -34,8 +35,112 @@ func (m *MockGateway) EXPECT() *MockGatewayMockRecorder {
 	return m.recorder
 }
 
+// Autocomplete mocks base method.
+func (m *MockGateway) Autocomplete(ctx context.Context, field string, prefix string, countryCode string, limit int) ([]Suggestion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Autocomplete", ctx, field, prefix, countryCode, limit)
+	ret0, _ := ret[0].([]Suggestion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Autocomplete indicates an expected call of Autocomplete.
+func (mr *MockGatewayMockRecorder) Autocomplete(ctx, field, prefix, countryCode, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Autocomplete", reflect.TypeOf((*MockGateway)(nil).Autocomplete), ctx, field, prefix, countryCode, limit)
+}
+
+// CreateOverride mocks base method.
+func (m *MockGateway) CreateOverride(ctx context.Context, override Override) (Override, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOverride", ctx, override)
+	ret0, _ := ret[0].(Override)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOverride indicates an expected call of CreateOverride.
+func (mr *MockGatewayMockRecorder) CreateOverride(ctx, override interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOverride", reflect.TypeOf((*MockGateway)(nil).CreateOverride), ctx, override)
+}
+
+// DeleteOverride mocks base method.
+func (m *MockGateway) DeleteOverride(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOverride", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOverride indicates an expected call of DeleteOverride.
+func (mr *MockGatewayMockRecorder) DeleteOverride(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOverride", reflect.TypeOf((*MockGateway)(nil).DeleteOverride), ctx, id)
+}
+
+// ExplainIP mocks base method.
+func (m *MockGateway) ExplainIP(ctx context.Context, ip netip.Addr) (Explanation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExplainIP", ctx, ip)
+	ret0, _ := ret[0].(Explanation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExplainIP indicates an expected call of ExplainIP.
+func (mr *MockGatewayMockRecorder) ExplainIP(ctx, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExplainIP", reflect.TypeOf((*MockGateway)(nil).ExplainIP), ctx, ip)
+}
+
+// ExtractIPs mocks base method.
+func (m *MockGateway) ExtractIPs(ctx context.Context, text string) ([]ExtractedIP, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExtractIPs", ctx, text)
+	ret0, _ := ret[0].([]ExtractedIP)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExtractIPs indicates an expected call of ExtractIPs.
+func (mr *MockGatewayMockRecorder) ExtractIPs(ctx, text interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExtractIPs", reflect.TypeOf((*MockGateway)(nil).ExtractIPs), ctx, text)
+}
+
+// GetBlocklist mocks base method.
+func (m *MockGateway) GetBlocklist(ctx context.Context, filter BlocklistFilter) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlocklist", ctx, filter)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlocklist indicates an expected call of GetBlocklist.
+func (mr *MockGatewayMockRecorder) GetBlocklist(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlocklist", reflect.TypeOf((*MockGateway)(nil).GetBlocklist), ctx, filter)
+}
+
+// GetCoverage mocks base method.
+func (m *MockGateway) GetCoverage(ctx context.Context, limit int) (Coverage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCoverage", ctx, limit)
+	ret0, _ := ret[0].(Coverage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCoverage indicates an expected call of GetCoverage.
+func (mr *MockGatewayMockRecorder) GetCoverage(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCoverage", reflect.TypeOf((*MockGateway)(nil).GetCoverage), ctx, limit)
+}
+
 // GetDataFromIP mocks base method.
-func (m *MockGateway) GetDataFromIP(ctx context.Context, ip string) (IpData, error) {
+func (m *MockGateway) GetDataFromIP(ctx context.Context, ip netip.Addr) (IpData, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetDataFromIP", ctx, ip)
 	ret0, _ := ret[0].(IpData)
@@ -64,6 +169,22 @@ func (mr *MockGatewayMockRecorder) GetIpCountByCountryName(ctx, countryName inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIpCountByCountryName", reflect.TypeOf((*MockGateway)(nil).GetIpCountByCountryName), ctx, countryName)
 }
 
+// GetIpCountByCountryNameGrouped mocks base method.
+func (m *MockGateway) GetIpCountByCountryNameGrouped(ctx context.Context, countryName string, groupBy []string) (int64, []GroupedIpCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIpCountByCountryNameGrouped", ctx, countryName, groupBy)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].([]GroupedIpCount)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetIpCountByCountryNameGrouped indicates an expected call of GetIpCountByCountryNameGrouped.
+func (mr *MockGatewayMockRecorder) GetIpCountByCountryNameGrouped(ctx, countryName, groupBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIpCountByCountryNameGrouped", reflect.TypeOf((*MockGateway)(nil).GetIpCountByCountryNameGrouped), ctx, countryName, groupBy)
+}
+
 // GetIspIpsByCountryCode mocks base method.
 func (m *MockGateway) GetIspIpsByCountryCode(ctx context.Context, countryCode string, limit int) ([]IspIpCount, error) {
 	m.ctrl.T.Helper()
@@ -79,6 +200,66 @@ func (mr *MockGatewayMockRecorder) GetIspIpsByCountryCode(ctx, countryCode, limi
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIspIpsByCountryCode", reflect.TypeOf((*MockGateway)(nil).GetIspIpsByCountryCode), ctx, countryCode, limit)
 }
 
+// GetNeighbors mocks base method.
+func (m *MockGateway) GetNeighbors(ctx context.Context, ip netip.Addr, n int) (Neighbors, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNeighbors", ctx, ip, n)
+	ret0, _ := ret[0].(Neighbors)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNeighbors indicates an expected call of GetNeighbors.
+func (mr *MockGatewayMockRecorder) GetNeighbors(ctx, ip, n interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNeighbors", reflect.TypeOf((*MockGateway)(nil).GetNeighbors), ctx, ip, n)
+}
+
+// GetOverride mocks base method.
+func (m *MockGateway) GetOverride(ctx context.Context, id string) (Override, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOverride", ctx, id)
+	ret0, _ := ret[0].(Override)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOverride indicates an expected call of GetOverride.
+func (mr *MockGatewayMockRecorder) GetOverride(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOverride", reflect.TypeOf((*MockGateway)(nil).GetOverride), ctx, id)
+}
+
+// GetRangesByIsp mocks base method.
+func (m *MockGateway) GetRangesByIsp(ctx context.Context, isp string, countryCode string, limit int, offset int) (IspRangesPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRangesByIsp", ctx, isp, countryCode, limit, offset)
+	ret0, _ := ret[0].(IspRangesPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRangesByIsp indicates an expected call of GetRangesByIsp.
+func (mr *MockGatewayMockRecorder) GetRangesByIsp(ctx, isp, countryCode, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRangesByIsp", reflect.TypeOf((*MockGateway)(nil).GetRangesByIsp), ctx, isp, countryCode, limit, offset)
+}
+
+// GetTopCountries mocks base method.
+func (m *MockGateway) GetTopCountries(ctx context.Context, proxyType string, limit int) ([]CountryIpCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTopCountries", ctx, proxyType, limit)
+	ret0, _ := ret[0].([]CountryIpCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTopCountries indicates an expected call of GetTopCountries.
+func (mr *MockGatewayMockRecorder) GetTopCountries(ctx, proxyType, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTopCountries", reflect.TypeOf((*MockGateway)(nil).GetTopCountries), ctx, proxyType, limit)
+}
+
 // GetTopISPFromSwitzerland mocks base method.
 func (m *MockGateway) GetTopISPFromSwitzerland(ctx context.Context) ([]IspIpCount, error) {
 	m.ctrl.T.Helper()
@@ -93,3 +274,78 @@ func (mr *MockGatewayMockRecorder) GetTopISPFromSwitzerland(ctx interface{}) *go
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTopISPFromSwitzerland", reflect.TypeOf((*MockGateway)(nil).GetTopISPFromSwitzerland), ctx)
 }
+
+// ListOverrides mocks base method.
+func (m *MockGateway) ListOverrides(ctx context.Context) ([]Override, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOverrides", ctx)
+	ret0, _ := ret[0].([]Override)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOverrides indicates an expected call of ListOverrides.
+func (mr *MockGatewayMockRecorder) ListOverrides(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOverrides", reflect.TypeOf((*MockGateway)(nil).ListOverrides), ctx)
+}
+
+// RunQuery mocks base method.
+func (m *MockGateway) RunQuery(ctx context.Context, query Query) (QueryResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunQuery", ctx, query)
+	ret0, _ := ret[0].(QueryResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunQuery indicates an expected call of RunQuery.
+func (mr *MockGatewayMockRecorder) RunQuery(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunQuery", reflect.TypeOf((*MockGateway)(nil).RunQuery), ctx, query)
+}
+
+// SearchIsp mocks base method.
+func (m *MockGateway) SearchIsp(ctx context.Context, text string, countryCode string, limit int) ([]IspMatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchIsp", ctx, text, countryCode, limit)
+	ret0, _ := ret[0].([]IspMatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchIsp indicates an expected call of SearchIsp.
+func (mr *MockGatewayMockRecorder) SearchIsp(ctx, text, countryCode, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchIsp", reflect.TypeOf((*MockGateway)(nil).SearchIsp), ctx, text, countryCode, limit)
+}
+
+// UpdateOverride mocks base method.
+func (m *MockGateway) UpdateOverride(ctx context.Context, id string, override Override) (Override, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOverride", ctx, id, override)
+	ret0, _ := ret[0].(Override)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateOverride indicates an expected call of UpdateOverride.
+func (mr *MockGatewayMockRecorder) UpdateOverride(ctx, id, override interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOverride", reflect.TypeOf((*MockGateway)(nil).UpdateOverride), ctx, id, override)
+}
+
+// ValidateDataset mocks base method.
+func (m *MockGateway) ValidateDataset(ctx context.Context, limit int) (ValidationReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateDataset", ctx, limit)
+	ret0, _ := ret[0].(ValidationReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateDataset indicates an expected call of ValidateDataset.
+func (mr *MockGatewayMockRecorder) ValidateDataset(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateDataset", reflect.TypeOf((*MockGateway)(nil).ValidateDataset), ctx, limit)
+}
@@ -0,0 +1,288 @@
+package ipdata
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Override replaces the dataset data of the ips of its range with what we know better than the vendor: our
+// office egress ranges, partner VPNs or known false positives
+type Override struct {
+	ID string `json:"id"`
+	// Range is a CIDR (10.0.0.0/24), a from-to range (10.0.0.1-10.0.0.9) or a single ipv4
+	Range  string `json:"range"`
+	IpFrom int64  `json:"ip_from"`
+	IpTo   int64  `json:"ip_to"`
+	// NotProxy removes the proxy type of the ips
+	NotProxy bool `json:"not_proxy"`
+	// the replacement fields, the empty ones keep the dataset values
+	ProxyType   string `json:"proxy_type,omitempty"`
+	CountryCode string `json:"country_code,omitempty"`
+	CountryName string `json:"country_name,omitempty"`
+	RegionName  string `json:"region_name,omitempty"`
+	CityName    string `json:"city_name,omitempty"`
+	ISP         string `json:"isp,omitempty"`
+	// Comment tells why the override exists
+	Comment   string    `json:"comment,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// OverrideSource is the override that replaced the dataset data of an ip
+type OverrideSource struct {
+	ID       string `json:"id"`
+	Range    string `json:"range"`
+	NotProxy bool   `json:"not_proxy"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// apply replaces the data fields set in the override
+func (o Override) apply(data IpData) IpData {
+	if o.NotProxy {
+		data.ProxyType = ""
+	}
+	for _, field := range []struct {
+		value       *string
+		replacement string
+	}{
+		{&data.ProxyType, o.ProxyType},
+		{&data.CountryCode, o.CountryCode},
+		{&data.CountryName, o.CountryName},
+		{&data.RegionName, o.RegionName},
+		{&data.CityName, o.CityName},
+		{&data.ISP, o.ISP},
+	} {
+		if field.replacement != "" {
+			*field.value = field.replacement
+		}
+	}
+	data.Override = &OverrideSource{ID: o.ID, Range: o.Range, NotProxy: o.NotProxy, Comment: o.Comment}
+	return data
+}
+
+// normalizeOverride validates the override and fills its ip_from and ip_to from its range. The country name is
+// taken from the registry when only the country code is replaced
+func normalizeOverride(override Override) (Override, error) {
+	ipFrom, ipTo, err := parseOverrideRange(override.Range)
+	if err != nil {
+		return Override{}, err
+	}
+	override.IpFrom, override.IpTo = ipFrom, ipTo
+	override.ProxyType = strings.ToUpper(strings.TrimSpace(override.ProxyType))
+	override.CountryCode = strings.ToUpper(strings.TrimSpace(override.CountryCode))
+
+	switch {
+	case override.NotProxy && override.ProxyType != "":
+		return Override{}, fmt.Errorf("a not_proxy override can't replace the proxy_type %w", common.ErrorBadRequest)
+	case override.ProxyType != "" && !IsValidProxyType(override.ProxyType):
+		return Override{}, fmt.Errorf("invalid proxy_type %s %w", override.ProxyType, common.ErrorBadRequest)
+	case override.CountryCode != "" && !IsValidCountryCode(override.CountryCode):
+		return Override{}, fmt.Errorf("invalid country_code %s %w", override.CountryCode, common.ErrorBadRequest)
+	case override.CountryName != "" && !isValidCountryName(override.CountryName):
+		return Override{}, fmt.Errorf("invalid country_name %s %w", override.CountryName, common.ErrorBadRequest)
+	case !override.NotProxy && override.ProxyType == "" && override.CountryCode == "" && override.CountryName == "" &&
+		override.RegionName == "" && override.CityName == "" && override.ISP == "":
+		return Override{}, fmt.Errorf("the override must be not_proxy or replace a field %w", common.ErrorBadRequest)
+	}
+	if override.CountryCode != "" && override.CountryName == "" {
+		override.CountryName = countryNamesByCode[override.CountryCode]
+	}
+	return override, nil
+}
+
+// parseOverrideRange returns the ip_from and ip_to of a CIDR, a from-to range or a single ipv4
+func parseOverrideRange(ipRange string) (int64, int64, error) {
+	invalid := fmt.Errorf("range %s must be an ipv4 CIDR, a from-to range or an ipv4 %w", ipRange, common.ErrorBadRequest)
+	if strings.Contains(ipRange, "/") {
+		prefix, err := netip.ParsePrefix(ipRange)
+		if err != nil || !prefix.Addr().Is4() || prefix.Masked() != prefix {
+			return 0, 0, invalid
+		}
+		ipFrom, _ := AddrToDecimal(prefix.Addr())
+		return ipFrom, ipFrom + 1<<(32-prefix.Bits()) - 1, nil
+	}
+
+	from, to, isRange := strings.Cut(ipRange, "-")
+	if !isRange {
+		to = from
+	}
+	fromAddr, err := netip.ParseAddr(strings.TrimSpace(from))
+	if err != nil || !fromAddr.Is4() {
+		return 0, 0, invalid
+	}
+	toAddr, err := netip.ParseAddr(strings.TrimSpace(to))
+	if err != nil || !toAddr.Is4() {
+		return 0, 0, invalid
+	}
+	ipFrom, _ := AddrToDecimal(fromAddr)
+	ipTo, _ := AddrToDecimal(toAddr)
+	if ipFrom > ipTo {
+		return 0, 0, invalid
+	}
+	return ipFrom, ipTo, nil
+}
+
+func newOverrideID() (string, error) {
+	id := make([]byte, 8)
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// OverrideStore keeps the overrides, Match is called on every lookup
+type OverrideStore interface {
+	// List returns the overrides ordered by ip_from
+	List() []Override
+	Get(id string) (Override, error)
+	// Save creates the override or replaces the one with its id
+	Save(override Override) error
+	Delete(id string) error
+	// Match returns the most specific override holding the ip
+	Match(ip int64) (Override, bool)
+}
+
+// overrideStore holds every override in memory and writes all of them to its file on every change. The
+// overrides are expected to be a few, so Match goes through all of them
+type overrideStore struct {
+	mu        sync.RWMutex
+	path      string
+	overrides map[string]Override
+}
+
+// NewOverrideStore loads the overrides of the json file at path, the file is created on the first change. With an
+// empty path the overrides are only kept in memory
+func NewOverrideStore(path string) (OverrideStore, error) {
+	store := &overrideStore{path: path, overrides: make(map[string]Override)}
+	if path == "" {
+		return store, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading overrides file %s. %w", path, err)
+	}
+	overrides := make([]Override, 0)
+	err = json.Unmarshal(data, &overrides)
+	if err != nil {
+		return nil, fmt.Errorf("error reading overrides file %s. %w", path, err)
+	}
+	for _, override := range overrides {
+		store.overrides[override.ID] = override
+	}
+	return store, nil
+}
+
+func (s *overrideStore) List() []Override {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sorted()
+}
+
+func (s *overrideStore) Get(id string) (Override, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	override, found := s.overrides[id]
+	if !found {
+		return Override{}, fmt.Errorf("override %s %w", id, common.ErrorNotFound)
+	}
+	return override, nil
+}
+
+func (s *overrideStore) Save(override Override) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, existed := s.overrides[override.ID]
+	s.overrides[override.ID] = override
+	err := s.persist()
+	if err != nil {
+		if existed {
+			s.overrides[override.ID] = previous
+		} else {
+			delete(s.overrides, override.ID)
+		}
+	}
+	return err
+}
+
+func (s *overrideStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, found := s.overrides[id]
+	if !found {
+		return fmt.Errorf("override %s %w", id, common.ErrorNotFound)
+	}
+	delete(s.overrides, id)
+	err := s.persist()
+	if err != nil {
+		s.overrides[id] = previous
+	}
+	return err
+}
+
+// Match picks the override with the smallest range holding the ip, the most recently updated on ties
+func (s *overrideStore) Match(ip int64) (Override, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var match Override
+	found := false
+	for _, override := range s.overrides {
+		if ip < override.IpFrom || ip > override.IpTo {
+			continue
+		}
+		size, matchSize := override.IpTo-override.IpFrom, match.IpTo-match.IpFrom
+		if !found || size < matchSize || (size == matchSize && override.UpdatedAt.After(match.UpdatedAt)) {
+			match = override
+			found = true
+		}
+	}
+	return match, found
+}
+
+func (s *overrideStore) sorted() []Override {
+	overrides := make([]Override, 0, len(s.overrides))
+	for _, override := range s.overrides {
+		overrides = append(overrides, override)
+	}
+	sort.Slice(overrides, func(i, j int) bool {
+		if overrides[i].IpFrom != overrides[j].IpFrom {
+			return overrides[i].IpFrom < overrides[j].IpFrom
+		}
+		return overrides[i].ID < overrides[j].ID
+	})
+	return overrides
+}
+
+// persist replaces the file atomically, the store must be locked
+func (s *overrideStore) persist() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.sorted(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("error writing overrides file. %s %w", err.Error(), common.ErrorInternalServer)
+	}
+	tmpPath := s.path + ".tmp"
+	err = os.WriteFile(tmpPath, data, 0o640)
+	if err == nil {
+		err = os.Rename(tmpPath, s.path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("error writing overrides file. %s %w", err.Error(), common.ErrorInternalServer)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package ipdata
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseOverrideRange(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "CIDR, from-to range and single ip", TestFn: testParseOverrideRangeNoError},
+		{Scenario: "Invalid ranges error", TestFn: testParseOverrideRangeError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestNormalizeOverride(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Codes upper cased and country name filled", TestFn: testNormalizeOverrideNoError},
+		{Scenario: "Invalid overrides error", TestFn: testNormalizeOverrideError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestOverrideStore(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Match the most specific override", TestFn: testOverrideStoreMatch},
+		{Scenario: "Overrides persisted to the file", TestFn: testOverrideStorePersisted},
+		{Scenario: "Not found error", TestFn: testOverrideStoreNotFoundError},
+		{Scenario: "Invalid file error", TestFn: testOverrideStoreInvalidFileError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func testParseOverrideRangeNoError(t *testing.T) {
+	type test struct {
+		ipRange string
+		ipFrom  int64
+		ipTo    int64
+	}
+	testData := []test{
+		{ipRange: "5.181.131.176/29", ipFrom: 95781808, ipTo: 95781815},
+		{ipRange: "5.181.131.178 - 5.181.131.185", ipFrom: 95781810, ipTo: 95781817},
+		{ipRange: "5.181.131.180", ipFrom: 95781812, ipTo: 95781812},
+	}
+
+	for _, data := range testData {
+		ipFrom, ipTo, err := parseOverrideRange(data.ipRange)
+
+		assert.Nil(t, err)
+		assert.Equal(t, data.ipFrom, ipFrom, data.ipRange)
+		assert.Equal(t, data.ipTo, ipTo, data.ipRange)
+	}
+}
+
+func testParseOverrideRangeError(t *testing.T) {
+	for _, ipRange := range []string{"", "5.181.131.180/29", "2001:db8::/32", "5.181.131.185-5.181.131.178", "5.181.131"} {
+		_, _, err := parseOverrideRange(ipRange)
+
+		assert.True(t, errors.Is(err, common.ErrorBadRequest), ipRange)
+	}
+}
+
+func testNormalizeOverrideNoError(t *testing.T) {
+	output, err := normalizeOverride(Override{Range: "5.181.131.176/29", ProxyType: " vpn", CountryCode: "ch"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, Override{Range: "5.181.131.176/29", IpFrom: 95781808, IpTo: 95781815, ProxyType: "VPN",
+		CountryCode: "CH", CountryName: "Switzerland"}, output)
+}
+
+func testNormalizeOverrideError(t *testing.T) {
+	type test struct {
+		override Override
+		err      string
+	}
+	testData := []test{
+		{override: Override{Range: "5.181.131.180", NotProxy: true, ProxyType: "VPN"}, err: "a not_proxy override can't replace the proxy_type bad request"},
+		{override: Override{Range: "5.181.131.180", ProxyType: "XXX"}, err: "invalid proxy_type XXX bad request"},
+		{override: Override{Range: "5.181.131.180", CountryCode: "ZZ"}, err: "invalid country_code ZZ bad request"},
+		{override: Override{Range: "5.181.131.180", Comment: "office"}, err: "the override must be not_proxy or replace a field bad request"},
+	}
+
+	for _, data := range testData {
+		_, err := normalizeOverride(data.override)
+
+		assert.EqualError(t, err, data.err)
+	}
+}
+
+func testOverrideStoreMatch(t *testing.T) {
+	now := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	store, _ := NewOverrideStore("")
+	store.Save(Override{ID: "wide", IpFrom: 95781808, IpTo: 95781815, NotProxy: true, UpdatedAt: now})
+	store.Save(Override{ID: "old", IpFrom: 95781812, IpTo: 95781813, ISP: "Old", UpdatedAt: now})
+	store.Save(Override{ID: "new", IpFrom: 95781812, IpTo: 95781813, ISP: "New", UpdatedAt: now.Add(time.Hour)})
+
+	match, found := store.Match(95781812)
+	assert.True(t, found)
+	assert.Equal(t, "new", match.ID)
+
+	match, found = store.Match(95781808)
+	assert.True(t, found)
+	assert.Equal(t, "wide", match.ID)
+
+	_, found = store.Match(95781816)
+	assert.False(t, found)
+}
+
+func testOverrideStorePersisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overrides.json")
+	override := Override{ID: "a1", Range: "5.181.131.180", IpFrom: 95781812, IpTo: 95781812, NotProxy: true,
+		UpdatedAt: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)}
+
+	store, err := NewOverrideStore(path)
+	assert.Nil(t, err)
+	assert.Nil(t, store.Save(override))
+	assert.Nil(t, store.Save(Override{ID: "b2", Range: "1.1.1.1", IpFrom: 16843009, IpTo: 16843009, ISP: "Cloudflare"}))
+	assert.Nil(t, store.Delete("b2"))
+
+	reloaded, err := NewOverrideStore(path)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []Override{override}, reloaded.List())
+}
+
+func testOverrideStoreNotFoundError(t *testing.T) {
+	store, _ := NewOverrideStore("")
+
+	_, err := store.Get("a1")
+	assert.True(t, errors.Is(err, common.ErrorNotFound))
+
+	err = store.Delete("a1")
+	assert.True(t, errors.Is(err, common.ErrorNotFound))
+}
+
+func testOverrideStoreInvalidFileError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overrides.json")
+	os.WriteFile(path, []byte("{"), 0o640)
+
+	_, err := NewOverrideStore(path)
+
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,74 @@
+package ipdata
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Policy decides if the traffic coming from an ip is allowed based on its IpData
+type Policy struct {
+	blockedProxyTypes   map[string]struct{}
+	blockedCountryCodes map[string]struct{}
+}
+
+// NewPolicy builds a Policy that blocks the given proxy types (VPN, TOR, PUB...) and country codes.
+// values are case-insensitive
+func NewPolicy(blockedProxyTypes []string, blockedCountryCodes []string) Policy {
+	return Policy{
+		blockedProxyTypes:   buildUpperSet(blockedProxyTypes),
+		blockedCountryCodes: buildUpperSet(blockedCountryCodes),
+	}
+}
+
+// Evaluate returns true if the given data is allowed, if not also returns the reason why it was blocked.
+// an empty IpData (ip not present in the dataset) is always allowed
+func (p Policy) Evaluate(data IpData) (bool, string) {
+	if _, blocked := p.blockedProxyTypes[strings.ToUpper(data.ProxyType)]; blocked && data.ProxyType != "" {
+		return false, fmt.Sprintf("proxy_type %s is blocked", data.ProxyType)
+	}
+	if _, blocked := p.blockedCountryCodes[strings.ToUpper(data.CountryCode)]; blocked && data.CountryCode != "" {
+		return false, fmt.Sprintf("country_code %s is blocked", data.CountryCode)
+	}
+
+	return true, ""
+}
+
+// PolicyExplanation is the decision of the Policy on an IpData with the rules it was made with
+type PolicyExplanation struct {
+	Allowed             bool     `json:"allowed"`
+	Reason              string   `json:"reason,omitempty"`
+	BlockedProxyTypes   []string `json:"blocked_proxy_types"`
+	BlockedCountryCodes []string `json:"blocked_country_codes"`
+}
+
+// Explain evaluates the data and returns the decision with the configured rules, sorted
+func (p Policy) Explain(data IpData) PolicyExplanation {
+	allowed, reason := p.Evaluate(data)
+	return PolicyExplanation{
+		Allowed:             allowed,
+		Reason:              reason,
+		BlockedProxyTypes:   sortedKeys(p.blockedProxyTypes),
+		BlockedCountryCodes: sortedKeys(p.blockedCountryCodes),
+	}
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func buildUpperSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, value := range values {
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if value != "" {
+			set[value] = struct{}{}
+		}
+	}
+	return set
+}
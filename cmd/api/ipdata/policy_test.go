@@ -0,0 +1,68 @@
+package ipdata
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPolicy_Evaluate(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Allowed data", TestFn: testPolicyEvaluateAllowed},
+		{Scenario: "Blocked proxy type", TestFn: testPolicyEvaluateBlockedProxyType},
+		{Scenario: "Blocked country code", TestFn: testPolicyEvaluateBlockedCountryCode},
+		{Scenario: "Empty data allowed", TestFn: testPolicyEvaluateEmptyData},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func testPolicyEvaluateAllowed(t *testing.T) {
+	policy := NewPolicy([]string{"TOR"}, []string{"RU"})
+
+	allowed, reason := policy.Evaluate(mockIpDataGateway)
+
+	assert.True(t, allowed)
+	assert.Equal(t, "", reason)
+}
+
+func testPolicyEvaluateBlockedProxyType(t *testing.T) {
+	policy := NewPolicy([]string{" pub "}, nil)
+
+	allowed, reason := policy.Evaluate(mockIpDataGateway)
+
+	assert.False(t, allowed)
+	assert.Equal(t, "proxy_type PUB is blocked", reason)
+}
+
+func testPolicyEvaluateBlockedCountryCode(t *testing.T) {
+	policy := NewPolicy(nil, []string{"es"})
+
+	allowed, reason := policy.Evaluate(mockIpDataGateway)
+
+	assert.False(t, allowed)
+	assert.Equal(t, "country_code ES is blocked", reason)
+}
+
+func testPolicyEvaluateEmptyData(t *testing.T) {
+	policy := NewPolicy([]string{"PUB"}, []string{"ES"})
+
+	allowed, _ := policy.Evaluate(IpData{})
+
+	assert.True(t, allowed)
+}
+
+func TestPolicy_Explain(t *testing.T) {
+	policy := NewPolicy([]string{"vpn", "TOR"}, []string{"ru"})
+
+	explanation := policy.Explain(IpData{ProxyType: "VPN", CountryCode: "GB"})
+
+	assert.Equal(t, PolicyExplanation{
+		Allowed:             false,
+		Reason:              "proxy_type VPN is blocked",
+		BlockedProxyTypes:   []string{"TOR", "VPN"},
+		BlockedCountryCodes: []string{"RU"},
+	}, explanation)
+}
@@ -0,0 +1,109 @@
+package ipdata
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"fmt"
+)
+
+// orderings of the query results
+const (
+	OrderIpCountDesc = "ip_count_desc"
+	OrderIpCountAsc  = "ip_count_asc"
+	OrderGroupAsc    = "group_asc"
+
+	defaultQueryLimit = 100
+	maxQueryLimit     = 1000
+)
+
+// queryColumns are the whitelisted fields usable as filters and group_by dimensions of a Query, mapped to their column
+var queryColumns = map[string]string{
+	"country_code": "country_code",
+	"country_name": "country_name",
+	"proxy_type":   "proxy_type",
+	"region_name":  "region_name",
+	"city_name":    "city_name",
+	"isp":          "isp",
+}
+
+var queryOrders = map[string]struct{}{
+	OrderIpCountDesc: {},
+	OrderIpCountAsc:  {},
+	OrderGroupAsc:    {},
+}
+
+// expensiveDimensions have too many distinct values to be grouped over the whole dataset,
+// they require any of the listed filters
+var expensiveDimensions = map[string][]string{
+	"city_name": {"country_code", "country_name", "region_name"},
+	"isp":       {"country_code", "country_name", "proxy_type"},
+}
+
+// Query is a filter-and-group question over the dataset, only whitelisted fields are accepted
+type Query struct {
+	// Filters are field equality conditions
+	Filters map[string]string
+	// GroupBy are the dimensions to break down the ip count, empty means a single total
+	GroupBy []string
+	// Order is one of OrderIpCountDesc, OrderIpCountAsc or OrderGroupAsc
+	Order string
+	// Limit is the max number of groups returned
+	Limit int
+}
+
+type QueryResult struct {
+	TotalIpCount int64            `json:"total_ip_count"`
+	Groups       []GroupedIpCount `json:"groups"`
+}
+
+// withDefaults fills the order and limit if not set
+func (q Query) withDefaults() Query {
+	if q.Order == "" {
+		q.Order = OrderIpCountDesc
+	}
+	if q.Limit == 0 {
+		q.Limit = defaultQueryLimit
+	}
+	return q
+}
+
+// validate checks the query only uses whitelisted fields and respects the cost limits
+func (q Query) validate() error {
+	for field, value := range q.Filters {
+		if _, found := queryColumns[field]; !found {
+			return fmt.Errorf("invalid filter field %s %w", field, common.ErrorBadRequest)
+		}
+		if value == "" {
+			return fmt.Errorf("empty filter value for %s %w", field, common.ErrorBadRequest)
+		}
+	}
+	if len(q.GroupBy) > maxGroupDimensions {
+		return fmt.Errorf("group_by must have at most %d dimensions %w", maxGroupDimensions, common.ErrorBadRequest)
+	}
+	for i, dimension := range q.GroupBy {
+		if _, found := queryColumns[dimension]; !found {
+			return fmt.Errorf("invalid group_by dimension %s %w", dimension, common.ErrorBadRequest)
+		}
+		if i > 0 && q.GroupBy[i-1] == dimension {
+			return fmt.Errorf("repeated group_by dimension %s %w", dimension, common.ErrorBadRequest)
+		}
+		if required, expensive := expensiveDimensions[dimension]; expensive && !q.hasAnyFilter(required) {
+			return fmt.Errorf("group_by %s requires a filter by any of %v %w", dimension, required, common.ErrorBadRequest)
+		}
+	}
+	if _, found := queryOrders[q.Order]; !found {
+		return fmt.Errorf("invalid order %s %w", q.Order, common.ErrorBadRequest)
+	}
+	if q.Limit < 1 || q.Limit > maxQueryLimit {
+		return fmt.Errorf("limit must be between 1 and %d %w", maxQueryLimit, common.ErrorBadRequest)
+	}
+	return nil
+}
+
+func (q Query) hasAnyFilter(fields []string) bool {
+	for _, field := range fields {
+		if _, found := q.Filters[field]; found {
+			return true
+		}
+	}
+	return false
+}
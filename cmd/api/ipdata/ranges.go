@@ -0,0 +1,76 @@
+package ipdata
+
+import (
+	"fmt"
+	"math/bits"
+	"net/netip"
+)
+
+type IpRange struct {
+	IpFrom       int64    `json:"ip_from"`
+	IpTo         int64    `json:"ip_to"`
+	IpFromString string   `json:"ip_from_string"`
+	IpToString   string   `json:"ip_to_string"`
+	CIDRs        []string `json:"cidrs"`
+	ProxyType    string   `json:"proxy_type"`
+	CountryCode  string   `json:"country_code"`
+	Isp          string   `json:"isp,omitempty"`
+}
+
+// IspRangesPage is a page of the ranges of an ISP, NextOffset is only present when there are more ranges
+type IspRangesPage struct {
+	Isp        string    `json:"isp"`
+	Ranges     []IpRange `json:"ranges"`
+	Offset     int       `json:"offset"`
+	Limit      int       `json:"limit"`
+	NextOffset *int      `json:"next_offset,omitempty"`
+}
+
+// Neighbors are the ranges around an ip ordered by ip_from, Match is the range holding it and Gap the span
+// without ranges it falls into when there is no Match
+type Neighbors struct {
+	Ip     string    `json:"ip"`
+	Match  *IpRange  `json:"match,omitempty"`
+	Gap    *IpRange  `json:"gap,omitempty"`
+	Before []IpRange `json:"before"`
+	After  []IpRange `json:"after"`
+}
+
+// rangeToCIDRs returns the minimal list of CIDR blocks covering exactly the ips between from and to (both included)
+func rangeToCIDRs(from int64, to int64) ([]string, error) {
+	if from < 0 || to > maxIPv4Decimal || from > to {
+		return []string{}, fmt.Errorf("invalid ipv4 range %d - %d", from, to)
+	}
+
+	cidrs := make([]string, 0)
+	for from <= to {
+		// the biggest block starting at from is limited by its alignment and by the remaining size of the range
+		hostBits := bits.TrailingZeros64(uint64(from))
+		if hostBits > 32 {
+			hostBits = 32
+		}
+		for int64(1)<<hostBits > to-from+1 {
+			hostBits--
+		}
+		addr, _ := DecimalToAddr(from)
+		cidrs = append(cidrs, netip.PrefixFrom(addr, 32-hostBits).String())
+		from += int64(1) << hostBits
+	}
+
+	return cidrs, nil
+}
+
+// EnrichRange fills the string and CIDR forms of the given range
+func EnrichRange(ipRange IpRange) IpRange {
+	cidrs, err := rangeToCIDRs(ipRange.IpFrom, ipRange.IpTo)
+	if err != nil {
+		ipRange.CIDRs = []string{}
+		return ipRange
+	}
+	from, _ := DecimalToAddr(ipRange.IpFrom)
+	to, _ := DecimalToAddr(ipRange.IpTo)
+	ipRange.IpFromString = from.String()
+	ipRange.IpToString = to.String()
+	ipRange.CIDRs = cidrs
+	return ipRange
+}
@@ -0,0 +1,58 @@
+package ipdata
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRanges_RangeToCIDRs(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Single ip", TestFn: testRangeToCIDRsSingleIp},
+		{Scenario: "Aligned block", TestFn: testRangeToCIDRsAlignedBlock},
+		{Scenario: "Unaligned range", TestFn: testRangeToCIDRsUnalignedRange},
+		{Scenario: "Whole ipv4 space", TestFn: testRangeToCIDRsWholeSpace},
+		{Scenario: "Inverted range error", TestFn: testRangeToCIDRsInvertedRangeError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func testRangeToCIDRsSingleIp(t *testing.T) {
+	cidrs, err := rangeToCIDRs(2130706433, 2130706433)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"127.0.0.1/32"}, cidrs)
+}
+
+func testRangeToCIDRsAlignedBlock(t *testing.T) {
+	// 10.0.0.0 - 10.0.255.255
+	cidrs, err := rangeToCIDRs(167772160, 167837695)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"10.0.0.0/16"}, cidrs)
+}
+
+func testRangeToCIDRsUnalignedRange(t *testing.T) {
+	// 5.181.131.178 - 5.181.131.185
+	cidrs, err := rangeToCIDRs(95781810, 95781817)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"5.181.131.178/31", "5.181.131.180/30", "5.181.131.184/31"}, cidrs)
+}
+
+func testRangeToCIDRsWholeSpace(t *testing.T) {
+	cidrs, err := rangeToCIDRs(0, maxIPv4Decimal)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"0.0.0.0/0"}, cidrs)
+}
+
+func testRangeToCIDRsInvertedRangeError(t *testing.T) {
+	cidrs, err := rangeToCIDRs(10, 5)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{}, cidrs)
+}
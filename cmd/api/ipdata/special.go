@@ -0,0 +1,84 @@
+package ipdata
+
+import (
+	"net/netip"
+	"sort"
+)
+
+// classifications of the special purpose addresses
+const (
+	SpecialPrivate       = "private"
+	SpecialLoopback      = "loopback"
+	SpecialLinkLocal     = "link-local"
+	SpecialCGNAT         = "cgnat"
+	SpecialDocumentation = "documentation"
+	SpecialMulticast     = "multicast"
+	SpecialUnspecified   = "unspecified"
+	SpecialReserved      = "reserved"
+)
+
+// SpecialPurpose is the registry entry of an address that is not publicly routable, the dataset never holds them
+type SpecialPurpose struct {
+	Classification string `json:"classification"`
+	Name           string `json:"name"`
+	Prefix         string `json:"prefix"`
+	RFC            string `json:"rfc"`
+}
+
+type specialPurposeBlock struct {
+	prefix netip.Prefix
+	SpecialPurpose
+}
+
+// specialPurposeRegistry holds the IANA IPv4 and IPv6 special-purpose address blocks (RFC 6890) that are not
+// globally reachable, ordered from the most to the least specific prefix
+var specialPurposeRegistry = buildSpecialPurposeRegistry([]SpecialPurpose{
+	// ipv4
+	{Classification: SpecialReserved, Name: "This network", Prefix: "0.0.0.0/8", RFC: "RFC 791"},
+	{Classification: SpecialPrivate, Name: "Private-Use", Prefix: "10.0.0.0/8", RFC: "RFC 1918"},
+	{Classification: SpecialCGNAT, Name: "Shared Address Space", Prefix: "100.64.0.0/10", RFC: "RFC 6598"},
+	{Classification: SpecialLoopback, Name: "Loopback", Prefix: "127.0.0.0/8", RFC: "RFC 1122"},
+	{Classification: SpecialLinkLocal, Name: "Link Local", Prefix: "169.254.0.0/16", RFC: "RFC 3927"},
+	{Classification: SpecialPrivate, Name: "Private-Use", Prefix: "172.16.0.0/12", RFC: "RFC 1918"},
+	{Classification: SpecialReserved, Name: "IETF Protocol Assignments", Prefix: "192.0.0.0/24", RFC: "RFC 6890"},
+	{Classification: SpecialDocumentation, Name: "Documentation (TEST-NET-1)", Prefix: "192.0.2.0/24", RFC: "RFC 5737"},
+	{Classification: SpecialReserved, Name: "6to4 Relay Anycast", Prefix: "192.88.99.0/24", RFC: "RFC 7526"},
+	{Classification: SpecialPrivate, Name: "Private-Use", Prefix: "192.168.0.0/16", RFC: "RFC 1918"},
+	{Classification: SpecialReserved, Name: "Benchmarking", Prefix: "198.18.0.0/15", RFC: "RFC 2544"},
+	{Classification: SpecialDocumentation, Name: "Documentation (TEST-NET-2)", Prefix: "198.51.100.0/24", RFC: "RFC 5737"},
+	{Classification: SpecialDocumentation, Name: "Documentation (TEST-NET-3)", Prefix: "203.0.113.0/24", RFC: "RFC 5737"},
+	{Classification: SpecialMulticast, Name: "Multicast", Prefix: "224.0.0.0/4", RFC: "RFC 5771"},
+	{Classification: SpecialReserved, Name: "Reserved", Prefix: "240.0.0.0/4", RFC: "RFC 1112"},
+	{Classification: SpecialReserved, Name: "Limited Broadcast", Prefix: "255.255.255.255/32", RFC: "RFC 919"},
+	// ipv6
+	{Classification: SpecialUnspecified, Name: "Unspecified Address", Prefix: "::/128", RFC: "RFC 4291"},
+	{Classification: SpecialLoopback, Name: "Loopback Address", Prefix: "::1/128", RFC: "RFC 4291"},
+	{Classification: SpecialReserved, Name: "Discard-Only Address Block", Prefix: "100::/64", RFC: "RFC 6666"},
+	{Classification: SpecialReserved, Name: "IETF Protocol Assignments", Prefix: "2001::/23", RFC: "RFC 2928"},
+	{Classification: SpecialDocumentation, Name: "Documentation", Prefix: "2001:db8::/32", RFC: "RFC 3849"},
+	{Classification: SpecialDocumentation, Name: "Documentation", Prefix: "3fff::/20", RFC: "RFC 9637"},
+	{Classification: SpecialPrivate, Name: "Unique-Local", Prefix: "fc00::/7", RFC: "RFC 4193"},
+	{Classification: SpecialLinkLocal, Name: "Link-Local Unicast", Prefix: "fe80::/10", RFC: "RFC 4291"},
+	{Classification: SpecialMulticast, Name: "Multicast", Prefix: "ff00::/8", RFC: "RFC 4291"},
+})
+
+func buildSpecialPurposeRegistry(entries []SpecialPurpose) []specialPurposeBlock {
+	blocks := make([]specialPurposeBlock, 0, len(entries))
+	for _, entry := range entries {
+		blocks = append(blocks, specialPurposeBlock{prefix: netip.MustParsePrefix(entry.Prefix), SpecialPurpose: entry})
+	}
+	sort.SliceStable(blocks, func(i, j int) bool { return blocks[i].prefix.Bits() > blocks[j].prefix.Bits() })
+	return blocks
+}
+
+// LookupSpecialPurpose returns the registry entry of the most specific special purpose block holding the addr,
+// false for the public addresses. ipv4 mapped ipv6 addresses are looked up as ipv4
+func LookupSpecialPurpose(addr netip.Addr) (SpecialPurpose, bool) {
+	addr = addr.Unmap()
+	for _, block := range specialPurposeRegistry {
+		if block.prefix.Contains(addr) {
+			return block.SpecialPurpose, true
+		}
+	}
+	return SpecialPurpose{}, false
+}
@@ -0,0 +1,54 @@
+package ipdata
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/netip"
+	"testing"
+)
+
+func TestSpecial_LookupSpecialPurpose(t *testing.T) {
+	tests := []struct {
+		ip             string
+		classification string
+		rfc            string
+	}{
+		{"10.0.0.1", SpecialPrivate, "RFC 1918"},
+		{"172.31.255.255", SpecialPrivate, "RFC 1918"},
+		{"192.168.1.1", SpecialPrivate, "RFC 1918"},
+		{"127.0.0.1", SpecialLoopback, "RFC 1122"},
+		{"100.127.255.254", SpecialCGNAT, "RFC 6598"},
+		{"169.254.169.254", SpecialLinkLocal, "RFC 3927"},
+		{"198.51.100.7", SpecialDocumentation, "RFC 5737"},
+		{"224.0.0.251", SpecialMulticast, "RFC 5771"},
+		{"0.1.2.3", SpecialReserved, "RFC 791"},
+		{"240.0.0.1", SpecialReserved, "RFC 1112"},
+		{"255.255.255.255", SpecialReserved, "RFC 919"},
+		{"::ffff:10.0.0.1", SpecialPrivate, "RFC 1918"},
+		{"::", SpecialUnspecified, "RFC 4291"},
+		{"::1", SpecialLoopback, "RFC 4291"},
+		{"fd12:3456::1", SpecialPrivate, "RFC 4193"},
+		{"fe80::1", SpecialLinkLocal, "RFC 4291"},
+		{"2001:db8::1", SpecialDocumentation, "RFC 3849"},
+		{"ff02::1", SpecialMulticast, "RFC 4291"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.ip, func(t *testing.T) {
+			specialPurpose, special := LookupSpecialPurpose(netip.MustParseAddr(test.ip))
+
+			assert.True(t, special)
+			assert.Equal(t, test.classification, specialPurpose.Classification)
+			assert.Equal(t, test.rfc, specialPurpose.RFC)
+		})
+	}
+}
+
+func TestSpecial_LookupSpecialPurposePublic(t *testing.T) {
+	for _, ip := range []string{"8.8.8.8", "100.63.255.255", "100.128.0.0", "172.32.0.1", "2a00:1450::1"} {
+		t.Run(ip, func(t *testing.T) {
+			_, special := LookupSpecialPurpose(netip.MustParseAddr(ip))
+
+			assert.False(t, special)
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package ipdata
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// severities of a ValidationIssue, only the errors make a dataset invalid
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// checks of the dataset validation
+const (
+	// CheckOverlap makes selectByIPQuery return an arbitrary row of the overlapping ones
+	CheckOverlap = "overlap"
+	// CheckInvertedRange and CheckOutOfRange corrupt every SUM(ip_to - ip_from + 1)
+	CheckInvertedRange = "inverted_range"
+	CheckOutOfRange    = "out_of_range"
+	// CheckUnknownCountryCode and CheckUnknownProxyType ranges are missed by the country and proxy_type filters
+	CheckUnknownCountryCode = "unknown_country_code"
+	CheckUnknownProxyType   = "unknown_proxy_type"
+	// CheckUnknownCountryName ranges are missed by the counts by country name
+	CheckUnknownCountryName = "unknown_country_name"
+	CheckEmptyIsp           = "empty_isp"
+)
+
+// checkSeverities is the severity of each check
+var checkSeverities = map[string]string{
+	CheckOverlap:            SeverityError,
+	CheckInvertedRange:      SeverityError,
+	CheckOutOfRange:         SeverityError,
+	CheckUnknownCountryCode: SeverityError,
+	CheckUnknownProxyType:   SeverityError,
+	CheckUnknownCountryName: SeverityWarning,
+	CheckEmptyIsp:           SeverityWarning,
+}
+
+// ValidationHeader is the header of the ValidationReport rows
+var ValidationHeader = []string{"severity", "check", "ip_from", "ip_to", "value"}
+
+// ValidationIssue is a check failed by a range, Value is the offending value (the other range for an overlap)
+type ValidationIssue struct {
+	Severity string `json:"severity"`
+	Check    string `json:"check"`
+	IpFrom   int64  `json:"ip_from"`
+	IpTo     int64  `json:"ip_to"`
+	Value    string `json:"value,omitempty"`
+}
+
+// ValidationCount is the number of ranges failing a check
+type ValidationCount struct {
+	Severity string `json:"severity"`
+	Check    string `json:"check"`
+	Count    int64  `json:"count"`
+}
+
+// ValidationReport is the result of the validation of every range of a dataset
+type ValidationReport struct {
+	Valid    bool  `json:"valid"`
+	Ranges   int64 `json:"ranges"`
+	Errors   int64 `json:"errors"`
+	Warnings int64 `json:"warnings"`
+	// Checks has the failed checks, errors first
+	Checks []ValidationCount `json:"checks"`
+	// Issues are the first issues, by ip_from
+	Issues []ValidationIssue `json:"issues"`
+}
+
+// Rows returns an issue per row, to be written as CSV or as a table
+func (r ValidationReport) Rows() [][]string {
+	rows := make([][]string, 0, len(r.Issues))
+	for _, issue := range r.Issues {
+		rows = append(rows, []string{issue.Severity, issue.Check, strconv.FormatInt(issue.IpFrom, 10), strconv.FormatInt(issue.IpTo, 10), issue.Value})
+	}
+	return rows
+}
+
+// ValidateRanges checks every range of the dataset as Gateway.ValidateDataset does, limit is the number of
+// issues listed
+func ValidateRanges(ctx context.Context, dataset RangeWalker, limit int) (ValidationReport, error) {
+	if limit < 0 || limit > maxRangesLimit {
+		return ValidationReport{}, fmt.Errorf("limit must be between 0 and %d %w", maxRangesLimit, common.ErrorBadRequest)
+	}
+
+	validator := newDatasetValidator(limit)
+	err := dataset.WalkRanges(ctx, validator.add)
+	if err != nil {
+		return ValidationReport{}, err
+	}
+	return validator.build(), nil
+}
+
+// ValidateBeforeLoad is the hook run before a dataset is loaded: the ranges are checked as ValidateRanges does and
+// a common.ErrorBadRequest naming the first error is returned when the dataset is not Valid
+func ValidateBeforeLoad(ctx context.Context, dataset RangeWalker) (ValidationReport, error) {
+	report, err := ValidateRanges(ctx, dataset, maxRangesLimit)
+	if err != nil {
+		return ValidationReport{}, err
+	}
+	if report.Valid {
+		return report, nil
+	}
+	for _, issue := range report.Issues {
+		if issue.Severity == SeverityError {
+			return report, fmt.Errorf("dataset has %d validation errors, first %s at %d-%d %w", report.Errors, issue.Check, issue.IpFrom, issue.IpTo, common.ErrorBadRequest)
+		}
+	}
+	return report, fmt.Errorf("dataset has %d validation errors %w", report.Errors, common.ErrorBadRequest)
+}
+
+// datasetValidator checks the ranges in ip_from order, an overlap is found against the range covering the
+// furthest ip seen so far
+type datasetValidator struct {
+	limit     int
+	report    ValidationReport
+	counts    map[string]int64
+	lastFrom  int64
+	furthest  IpData
+	hasRanges bool
+}
+
+// newDatasetValidator lists up to limit issues
+func newDatasetValidator(limit int) *datasetValidator {
+	return &datasetValidator{limit: limit, report: ValidationReport{Issues: []ValidationIssue{}}, counts: make(map[string]int64)}
+}
+
+// add must be called in ip_from order
+func (v *datasetValidator) add(data IpData) error {
+	v.report.Ranges++
+	switch {
+	case data.IpFrom > data.IpTo:
+		v.addIssue(CheckInvertedRange, data, "")
+	case data.IpFrom < 0 || data.IpTo > maxIPv4Decimal:
+		v.addIssue(CheckOutOfRange, data, "")
+	default:
+		if data.IpFrom < v.lastFrom {
+			return fmt.Errorf("ranges are not ordered by ip_from, %d after %d %w", data.IpFrom, v.lastFrom, common.ErrorInternalServer)
+		}
+		v.lastFrom = data.IpFrom
+		if v.hasRanges && data.IpFrom <= v.furthest.IpTo {
+			v.addIssue(CheckOverlap, data, fmt.Sprintf("%d-%d", v.furthest.IpFrom, v.furthest.IpTo))
+		}
+		if !v.hasRanges || data.IpTo > v.furthest.IpTo {
+			v.furthest = data
+			v.hasRanges = true
+		}
+	}
+
+	if !IsValidCountryCode(data.CountryCode) {
+		v.addIssue(CheckUnknownCountryCode, data, data.CountryCode)
+	}
+	if !isValidCountryName(data.CountryName) {
+		v.addIssue(CheckUnknownCountryName, data, data.CountryName)
+	}
+	if !IsValidProxyType(data.ProxyType) {
+		v.addIssue(CheckUnknownProxyType, data, data.ProxyType)
+	}
+	if strings.TrimSpace(data.ISP) == "" {
+		v.addIssue(CheckEmptyIsp, data, "")
+	}
+	return nil
+}
+
+func (v *datasetValidator) addIssue(check string, data IpData, value string) {
+	severity := checkSeverities[check]
+	v.counts[check]++
+	if severity == SeverityError {
+		v.report.Errors++
+	} else {
+		v.report.Warnings++
+	}
+	if len(v.report.Issues) < v.limit {
+		v.report.Issues = append(v.report.Issues, ValidationIssue{Severity: severity, Check: check, IpFrom: data.IpFrom, IpTo: data.IpTo, Value: value})
+	}
+}
+
+func (v *datasetValidator) build() ValidationReport {
+	report := v.report
+	report.Valid = report.Errors == 0
+	report.Checks = make([]ValidationCount, 0, len(v.counts))
+	for check, count := range v.counts {
+		report.Checks = append(report.Checks, ValidationCount{Severity: checkSeverities[check], Check: check, Count: count})
+	}
+	sort.Slice(report.Checks, func(i, j int) bool {
+		if report.Checks[i].Severity != report.Checks[j].Severity {
+			return report.Checks[i].Severity == SeverityError
+		}
+		return report.Checks[i].Check < report.Checks[j].Check
+	})
+	return report
+}
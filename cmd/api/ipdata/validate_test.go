@@ -0,0 +1,131 @@
+package ipdata
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestValidate_Validator(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Valid dataset", TestFn: testValidatorValid},
+		{Scenario: "Errors and warnings", TestFn: testValidatorIssues},
+		{Scenario: "Issues limit", TestFn: testValidatorIssuesLimit},
+		{Scenario: "Unordered ranges error", TestFn: testValidatorUnorderedError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func testValidatorValid(t *testing.T) {
+	validator := newDatasetValidator(10)
+	for _, data := range []IpData{
+		{IpFrom: 0, IpTo: 9, ProxyType: "VPN", CountryCode: "CH", CountryName: "Switzerland", ISP: "Swisscom"},
+		{IpFrom: 20, IpTo: 29, ProxyType: "TOR", CountryCode: "AR", CountryName: "Argentina", ISP: "Telecom Argentina"},
+	} {
+		assert.Nil(t, validator.add(data))
+	}
+
+	report := validator.build()
+
+	assert.Equal(t, ValidationReport{Valid: true, Ranges: 2, Checks: []ValidationCount{}, Issues: []ValidationIssue{}}, report)
+}
+
+func testValidatorIssues(t *testing.T) {
+	validator := newDatasetValidator(10)
+	for _, data := range mockInvalidRanges {
+		assert.Nil(t, validator.add(data))
+	}
+
+	report := validator.build()
+
+	assert.False(t, report.Valid)
+	assert.Equal(t, int64(5), report.Ranges)
+	assert.Equal(t, int64(4), report.Errors)
+	assert.Equal(t, int64(2), report.Warnings)
+	assert.Equal(t, []ValidationCount{
+		{Severity: SeverityError, Check: CheckInvertedRange, Count: 1},
+		{Severity: SeverityError, Check: CheckOverlap, Count: 2},
+		{Severity: SeverityError, Check: CheckUnknownProxyType, Count: 1},
+		{Severity: SeverityWarning, Check: CheckEmptyIsp, Count: 1},
+		{Severity: SeverityWarning, Check: CheckUnknownCountryName, Count: 1},
+	}, report.Checks)
+	assert.Equal(t, []ValidationIssue{
+		{Severity: SeverityError, Check: CheckOverlap, IpFrom: 5, IpTo: 7, Value: "0-19"},
+		{Severity: SeverityWarning, Check: CheckEmptyIsp, IpFrom: 5, IpTo: 7},
+		{Severity: SeverityError, Check: CheckOverlap, IpFrom: 15, IpTo: 25, Value: "0-19"},
+		{Severity: SeverityError, Check: CheckUnknownProxyType, IpFrom: 15, IpTo: 25, Value: "XXX"},
+		{Severity: SeverityError, Check: CheckInvertedRange, IpFrom: 40, IpTo: 30},
+		{Severity: SeverityWarning, Check: CheckUnknownCountryName, IpFrom: 40, IpTo: 30, Value: "Swiss"},
+	}, report.Issues)
+}
+
+func testValidatorIssuesLimit(t *testing.T) {
+	validator := newDatasetValidator(1)
+	for _, data := range mockInvalidRanges {
+		assert.Nil(t, validator.add(data))
+	}
+
+	report := validator.build()
+
+	assert.Equal(t, int64(4), report.Errors)
+	assert.Len(t, report.Issues, 1)
+	assert.Equal(t, [][]string{{"error", "overlap", "5", "7", "0-19"}}, report.Rows())
+}
+
+func testValidatorUnorderedError(t *testing.T) {
+	validator := newDatasetValidator(10)
+
+	assert.Nil(t, validator.add(IpData{IpFrom: 100, IpTo: 110, ProxyType: "VPN", CountryCode: "CH", CountryName: "Switzerland", ISP: "Init7"}))
+	err := validator.add(IpData{IpFrom: 50, IpTo: 60, ProxyType: "VPN", CountryCode: "CH", CountryName: "Switzerland", ISP: "Init7"})
+
+	assert.True(t, errors.Is(err, common.ErrorInternalServer))
+}
+
+func TestValidate_BeforeLoad(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Valid dataset is loaded", TestFn: testValidateBeforeLoadValid},
+		{Scenario: "Invalid dataset is rejected", TestFn: testValidateBeforeLoadInvalid},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func testValidateBeforeLoadValid(t *testing.T) {
+	dataset := NewMemoryDao([]IpData{
+		{IpFrom: 20, IpTo: 29, ProxyType: "TOR", CountryCode: "AR", CountryName: "Argentina", ISP: "Telecom Argentina"},
+		{IpFrom: 0, IpTo: 9, ProxyType: "VPN", CountryCode: "CH", CountryName: "Swiss", ISP: "Swisscom"},
+	})
+
+	report, err := ValidateBeforeLoad(context.Background(), dataset)
+
+	assert.Nil(t, err)
+	assert.True(t, report.Valid)
+	assert.Equal(t, int64(2), report.Ranges)
+	assert.Equal(t, int64(1), report.Warnings)
+}
+
+func testValidateBeforeLoadInvalid(t *testing.T) {
+	report, err := ValidateBeforeLoad(context.Background(), NewMemoryDao(mockInvalidRanges))
+
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+	assert.Equal(t, "dataset has 4 validation errors, first overlap at 5-7 bad request", err.Error())
+	assert.False(t, report.Valid)
+}
+
+// mock utils
+
+// mockInvalidRanges are ordered by ip_from: 5-7 and 15-25 overlap 0-19, 40-30 is inverted
+var mockInvalidRanges = []IpData{
+	{IpFrom: 0, IpTo: 19, ProxyType: "VPN", CountryCode: "CH", CountryName: "Switzerland", ISP: "Swisscom"},
+	{IpFrom: 5, IpTo: 7, ProxyType: "VPN", CountryCode: "CH", CountryName: "Switzerland", ISP: " "},
+	{IpFrom: 15, IpTo: 25, ProxyType: "XXX", CountryCode: "CH", CountryName: "Switzerland", ISP: "Init7"},
+	{IpFrom: 30, IpTo: 39, ProxyType: "TOR", CountryCode: "AR", CountryName: "Argentina", ISP: "Telecom Argentina"},
+	{IpFrom: 40, IpTo: 30, ProxyType: "TOR", CountryCode: "CH", CountryName: "Swiss", ISP: "Init7"},
+}
@@ -0,0 +1,232 @@
+package releases
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"net/netip"
+	"time"
+)
+
+const (
+	releasesSchemaTableName = "proxydata.releases"
+
+	getReleasesQuery   = "SELECT release_date, table_name FROM " + releasesSchemaTableName + " ORDER BY release_date DESC"
+	addReleaseQuery    = "INSERT INTO " + releasesSchemaTableName + " (release_date, table_name) VALUES ($1, $2)"
+	deleteReleaseQuery = "DELETE FROM " + releasesSchemaTableName + " WHERE release_date = $1"
+	// selectByIPQuery and dropTableQuery are only filled with validated table names, see ipdata.ValidateTableName
+	selectByIPQuery = "SELECT ip_from,ip_to,country_code,country_name,isp,region_name,city_name,proxy_type FROM %s WHERE $1 BETWEEN ip_from AND ip_to"
+	dropTableQuery  = "DROP TABLE IF EXISTS %s"
+	// getProxyTypeCountsQuery is only filled with a validated table name and one of the trendColumns
+	getProxyTypeCountsQuery = "SELECT proxy_type, SUM(ip_to - ip_from + 1) as ip_count FROM %s WHERE %s = $1 GROUP BY proxy_type"
+	// getIspIpCountsQuery an empty proxy type doesn't filter
+	getIspIpCountsQuery = "SELECT isp, SUM(ip_to - ip_from + 1) as ip_count FROM %s WHERE ($1 = '' OR proxy_type = $1) GROUP BY isp"
+
+	// uniqueViolation is the postgres error code of a duplicated key
+	uniqueViolation = "23505"
+	// tableNameConstraint is the unique constraint of the table_name column of the releases
+	tableNameConstraint = "releases_table_name_key"
+)
+
+//go:generate mockgen -destination=mock_dao.go -package=releases -source=dao.go Dao
+
+type Dao interface {
+	// GetReleases gets the registered releases ordered by date desc
+	GetReleases(ctx context.Context) ([]Release, error)
+	// GetByIp gets the range of the release holding the ip
+	GetByIp(ctx context.Context, release Release, ip netip.Addr) (ipdata.IpData, error)
+	// GetRangeWalker returns the walker of the ranges of the release table
+	GetRangeWalker(release Release) (ipdata.RangeWalker, error)
+	AddRelease(ctx context.Context, release Release) error
+	// DeleteRelease unregisters the release and drops its table
+	DeleteRelease(ctx context.Context, release Release) error
+	// GetProxyTypeCounts gets the ip count per proxy type of the ranges of the release whose trend dimension is value
+	GetProxyTypeCounts(ctx context.Context, release Release, dimension string, value string) (map[string]int64, error)
+	// GetIspIpCounts gets the ip count of every ISP of the release, only of the proxyType ranges if it is not empty
+	GetIspIpCounts(ctx context.Context, release Release, proxyType string) ([]ipdata.IspIpCount, error)
+}
+
+func NewDao(dbConnection *sql.DB) Dao {
+	return dao{db: dbConnection}
+}
+
+type dao struct {
+	db *sql.DB
+}
+
+// GetReleases gets the registered releases, newest first
+func (d dao) GetReleases(ctx context.Context) ([]Release, error) {
+	rows, err := d.db.QueryContext(ctx, getReleasesQuery)
+	if err != nil {
+		return []Release{}, err
+	}
+	defer rows.Close()
+
+	releases := make([]Release, 0)
+	for rows.Next() {
+		var date time.Time
+		release := Release{}
+		err := rows.Scan(&date, &release.Table)
+		if err != nil {
+			return []Release{}, err
+		}
+		release.Date = date.Format(DateLayout)
+		releases = append(releases, release)
+	}
+	err = rows.Err()
+	if err != nil {
+		err = fmt.Errorf("error with get query while scanning rows. %s %w", err.Error(), common.ErrorInternalServer)
+		return []Release{}, err
+	}
+
+	return releases, nil
+}
+
+// GetByIp gets the data of the ip from the table of the release
+func (d dao) GetByIp(ctx context.Context, release Release, ip netip.Addr) (ipdata.IpData, error) {
+	decimalIp, isIPv4 := ipdata.AddrToDecimal(ip)
+	if !isIPv4 {
+		return ipdata.IpData{}, fmt.Errorf("ip %s is not an ipv4 %w", ip, common.ErrorBadRequest)
+	}
+	err := ipdata.ValidateTableName(release.Table)
+	if err != nil {
+		return ipdata.IpData{}, err
+	}
+	row := d.db.QueryRowContext(ctx, fmt.Sprintf(selectByIPQuery, release.Table), decimalIp)
+
+	ipData := ipdata.IpData{}
+	err = row.Scan(&ipData.IpFrom,
+		&ipData.IpTo,
+		&ipData.CountryCode,
+		&ipData.CountryName,
+		&ipData.ISP,
+		&ipData.RegionName,
+		&ipData.CityName,
+		&ipData.ProxyType)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = fmt.Errorf("ip %s is not in the release %s %w", ip, release.Date, common.ErrorNotFound)
+			return ipdata.IpData{}, err
+		}
+		err = fmt.Errorf("error with get query while scanning rows. %s %w", err.Error(), common.ErrorInternalServer)
+		return ipdata.IpData{}, err
+	}
+
+	return ipData, nil
+}
+
+func (d dao) GetRangeWalker(release Release) (ipdata.RangeWalker, error) {
+	return ipdata.NewTableWalker(d.db, release.Table)
+}
+
+// AddRelease registers the release, a date or a table already registered is a conflict
+func (d dao) AddRelease(ctx context.Context, release Release) error {
+	_, err := d.db.ExecContext(ctx, addReleaseQuery, release.Date, release.Table)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation && pqErr.Constraint == tableNameConstraint {
+			return fmt.Errorf("table %s is already registered %w", release.Table, common.ErrorConflict)
+		}
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return fmt.Errorf("release %s is already registered %w", release.Date, common.ErrorConflict)
+		}
+		return fmt.Errorf("error adding release %s. %s %w", release.Date, err.Error(), common.ErrorInternalServer)
+	}
+	return nil
+}
+
+// DeleteRelease unregisters the release and drops its table in a single transaction
+func (d dao) DeleteRelease(ctx context.Context, release Release) error {
+	err := ipdata.ValidateTableName(release.Table)
+	if err != nil {
+		return err
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error deleting release %s. %s %w", release.Date, err.Error(), common.ErrorInternalServer)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, deleteReleaseQuery, release.Date)
+	if err != nil {
+		return fmt.Errorf("error deleting release %s. %s %w", release.Date, err.Error(), common.ErrorInternalServer)
+	}
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(dropTableQuery, release.Table))
+	if err != nil {
+		return fmt.Errorf("error dropping table %s. %s %w", release.Table, err.Error(), common.ErrorInternalServer)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error deleting release %s. %s %w", release.Date, err.Error(), common.ErrorInternalServer)
+	}
+	return nil
+}
+
+func (d dao) GetProxyTypeCounts(ctx context.Context, release Release, dimension string, value string) (map[string]int64, error) {
+	column, found := trendColumns[dimension]
+	if !found {
+		return map[string]int64{}, fmt.Errorf("invalid trend dimension %s %w", dimension, common.ErrorBadRequest)
+	}
+	err := ipdata.ValidateTableName(release.Table)
+	if err != nil {
+		return map[string]int64{}, err
+	}
+	rows, err := d.db.QueryContext(ctx, fmt.Sprintf(getProxyTypeCountsQuery, release.Table, column), value)
+	if err != nil {
+		return map[string]int64{}, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var proxyType string
+		var ipCount int64
+		err := rows.Scan(&proxyType, &ipCount)
+		if err != nil {
+			return map[string]int64{}, err
+		}
+		counts[proxyType] = ipCount
+	}
+	err = rows.Err()
+	if err != nil {
+		err = fmt.Errorf("error with get query while scanning rows. %s %w", err.Error(), common.ErrorInternalServer)
+		return map[string]int64{}, err
+	}
+
+	return counts, nil
+}
+
+func (d dao) GetIspIpCounts(ctx context.Context, release Release, proxyType string) ([]ipdata.IspIpCount, error) {
+	err := ipdata.ValidateTableName(release.Table)
+	if err != nil {
+		return []ipdata.IspIpCount{}, err
+	}
+	rows, err := d.db.QueryContext(ctx, fmt.Sprintf(getIspIpCountsQuery, release.Table), proxyType)
+	if err != nil {
+		return []ipdata.IspIpCount{}, err
+	}
+	defer rows.Close()
+
+	counts := make([]ipdata.IspIpCount, 0)
+	for rows.Next() {
+		count := ipdata.IspIpCount{}
+		err := rows.Scan(&count.Isp, &count.IpCount)
+		if err != nil {
+			return []ipdata.IspIpCount{}, err
+		}
+		counts = append(counts, count)
+	}
+	err = rows.Err()
+	if err != nil {
+		err = fmt.Errorf("error with get query while scanning rows. %s %w", err.Error(), common.ErrorInternalServer)
+		return []ipdata.IspIpCount{}, err
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,276 @@
+package releases
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"DreamLabChallenge/cmd/services"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"net/netip"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestDao_GetReleases(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testDaoGetReleasesNoError},
+		{Scenario: "Connection error", TestFn: testDaoGetReleasesConnectionError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestDao_GetByIp(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testDaoGetByIpNoError},
+		{Scenario: "Not found error", TestFn: testDaoGetByIpNotFoundError},
+		{Scenario: "Invalid table error", TestFn: testDaoGetByIpInvalidTableError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestDao_AddRelease(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testDaoAddReleaseNoError},
+		{Scenario: "Duplicated date error", TestFn: testDaoAddReleaseConflictError},
+		{Scenario: "Duplicated table error", TestFn: testDaoAddReleaseTableConflictError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestDao_DeleteRelease(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testDaoDeleteReleaseNoError},
+		{Scenario: "Drop error rolls back", TestFn: testDaoDeleteReleaseDropError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestDao_GetProxyTypeCounts(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testDaoGetProxyTypeCountsNoError},
+		{Scenario: "Invalid dimension error", TestFn: testDaoGetProxyTypeCountsInvalidDimensionError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestDao_GetIspIpCounts(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testDaoGetIspIpCountsNoError},
+		{Scenario: "Connection error", TestFn: testDaoGetIspIpCountsConnectionError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+// GetReleases
+
+func testDaoGetReleasesNoError(t *testing.T) {
+	rows := sqlmock.NewRows([]string{"release_date", "table_name"}).
+		AddRow(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), "proxydata.ip2location_20260401").
+		AddRow(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), "proxydata.ip2location_20260301")
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta(getReleasesQuery)).WillReturnRows(rows)
+
+	output, err := mockDao.GetReleases(context.Background())
+	assert.Equal(t, mockReleases, output)
+	assert.Nil(t, err)
+}
+
+func testDaoGetReleasesConnectionError(t *testing.T) {
+	rowsWithError := sqlmock.NewRows([]string{"release_date", "table_name"}).
+		AddRow(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), "proxydata.ip2location_20260401").
+		RowError(0, errors.New("connection error"))
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta(getReleasesQuery)).WillReturnRows(rowsWithError)
+
+	output, err := mockDao.GetReleases(context.Background())
+	assert.Equal(t, []Release{}, output)
+	assert.True(t, errors.Is(err, common.ErrorInternalServer))
+}
+
+// GetByIp
+
+func testDaoGetByIpNoError(t *testing.T) {
+	rows := sqlmock.NewRows([]string{"ip_from", "ip_to", "country_code", "country_name", "isp", "region_name", "city_name", "proxy_type"}).
+		AddRow(95781810, 95781817, "GB", "United Kingdom of Great Britain and Northern Ireland (the)", "IPXO Limited", "England", "Saint Albans", "VPN")
+	expected := ipdata.IpData{IpFrom: 95781810, IpTo: 95781817, ProxyType: "VPN", CountryCode: "GB",
+		CountryName: "United Kingdom of Great Britain and Northern Ireland (the)", RegionName: "England", CityName: "Saint Albans", ISP: "IPXO Limited"}
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta(fmt.Sprintf(selectByIPQuery, mockReleases[1].Table))).WithArgs(int64(95781812)).WillReturnRows(rows)
+
+	output, err := mockDao.GetByIp(context.Background(), mockReleases[1], netip.MustParseAddr("5.181.131.180"))
+	assert.Equal(t, expected, output)
+	assert.Nil(t, err)
+}
+
+func testDaoGetByIpNotFoundError(t *testing.T) {
+	rows := sqlmock.NewRows([]string{"ip_from", "ip_to", "country_code", "country_name", "isp", "region_name", "city_name", "proxy_type"})
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta(fmt.Sprintf(selectByIPQuery, mockReleases[0].Table))).WithArgs(int64(95781812)).WillReturnRows(rows)
+
+	output, err := mockDao.GetByIp(context.Background(), mockReleases[0], netip.MustParseAddr("5.181.131.180"))
+	assert.Equal(t, ipdata.IpData{}, output)
+	assert.Equal(t, "ip 5.181.131.180 is not in the release 2026-04-01 not found", err.Error())
+}
+
+func testDaoGetByIpInvalidTableError(t *testing.T) {
+	mockDB, _ := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	_, err := mockDao.GetByIp(context.Background(), Release{Date: "2026-03-01", Table: "ip2location; DROP TABLE x"}, netip.MustParseAddr("5.181.131.180"))
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+}
+
+// AddRelease
+
+func testDaoAddReleaseNoError(t *testing.T) {
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectExec(regexp.QuoteMeta(addReleaseQuery)).WithArgs("2026-03-01", "proxydata.ip2location_20260301").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := mockDao.AddRelease(context.Background(), mockReleases[1])
+	assert.Nil(t, err)
+	assert.Nil(t, mockHandler.ExpectationsWereMet())
+}
+
+func testDaoAddReleaseConflictError(t *testing.T) {
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectExec(regexp.QuoteMeta(addReleaseQuery)).WithArgs("2026-03-01", "proxydata.ip2location_20260301").
+		WillReturnError(&pq.Error{Code: uniqueViolation})
+
+	err := mockDao.AddRelease(context.Background(), mockReleases[1])
+	assert.True(t, errors.Is(err, common.ErrorConflict))
+}
+
+func testDaoAddReleaseTableConflictError(t *testing.T) {
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectExec(regexp.QuoteMeta(addReleaseQuery)).WithArgs("2026-03-01", "proxydata.ip2location_20260301").
+		WillReturnError(&pq.Error{Code: uniqueViolation, Constraint: tableNameConstraint})
+
+	err := mockDao.AddRelease(context.Background(), mockReleases[1])
+	assert.True(t, errors.Is(err, common.ErrorConflict))
+	assert.Equal(t, "table proxydata.ip2location_20260301 is already registered conflict", err.Error())
+}
+
+// DeleteRelease
+
+func testDaoDeleteReleaseNoError(t *testing.T) {
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectBegin()
+	mockHandler.ExpectExec(regexp.QuoteMeta(deleteReleaseQuery)).WithArgs("2026-03-01").WillReturnResult(sqlmock.NewResult(0, 1))
+	mockHandler.ExpectExec(regexp.QuoteMeta("DROP TABLE IF EXISTS proxydata.ip2location_20260301")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mockHandler.ExpectCommit()
+
+	err := mockDao.DeleteRelease(context.Background(), mockReleases[1])
+	assert.Nil(t, err)
+	assert.Nil(t, mockHandler.ExpectationsWereMet())
+}
+
+func testDaoDeleteReleaseDropError(t *testing.T) {
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectBegin()
+	mockHandler.ExpectExec(regexp.QuoteMeta(deleteReleaseQuery)).WithArgs("2026-03-01").WillReturnResult(sqlmock.NewResult(0, 1))
+	mockHandler.ExpectExec(regexp.QuoteMeta("DROP TABLE IF EXISTS proxydata.ip2location_20260301")).WillReturnError(errors.New("permission denied"))
+	mockHandler.ExpectRollback()
+
+	err := mockDao.DeleteRelease(context.Background(), mockReleases[1])
+	assert.True(t, errors.Is(err, common.ErrorInternalServer))
+	assert.Nil(t, mockHandler.ExpectationsWereMet())
+}
+
+// GetProxyTypeCounts
+
+func testDaoGetProxyTypeCountsNoError(t *testing.T) {
+	rows := sqlmock.NewRows([]string{"proxy_type", "ip_count"}).AddRow("VPN", 120).AddRow("TOR", 8)
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	query := "SELECT proxy_type, SUM(ip_to - ip_from + 1) as ip_count FROM proxydata.ip2location_20260301 WHERE country_code = $1 GROUP BY proxy_type"
+	mockHandler.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("CH").WillReturnRows(rows)
+
+	output, err := mockDao.GetProxyTypeCounts(context.Background(), mockReleases[1], TrendCountry, "CH")
+	assert.Equal(t, map[string]int64{"VPN": 120, "TOR": 8}, output)
+	assert.Nil(t, err)
+}
+
+func testDaoGetProxyTypeCountsInvalidDimensionError(t *testing.T) {
+	mockDB, _ := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	_, err := mockDao.GetProxyTypeCounts(context.Background(), mockReleases[1], "region_name; --", "Zurich")
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+}
+
+// GetIspIpCounts
+
+func testDaoGetIspIpCountsNoError(t *testing.T) {
+	rows := sqlmock.NewRows([]string{"isp", "ip_count"}).AddRow("Init7", 100).AddRow("Swisscom", 200)
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta(fmt.Sprintf(getIspIpCountsQuery, mockReleases[0].Table))).WithArgs("VPN").WillReturnRows(rows)
+
+	output, err := mockDao.GetIspIpCounts(context.Background(), mockReleases[0], "VPN")
+	assert.Equal(t, []ipdata.IspIpCount{{Isp: "Init7", IpCount: 100}, {Isp: "Swisscom", IpCount: 200}}, output)
+	assert.Nil(t, err)
+}
+
+func testDaoGetIspIpCountsConnectionError(t *testing.T) {
+	rowsWithError := sqlmock.NewRows([]string{"isp", "ip_count"}).AddRow("Init7", 100).RowError(0, errors.New("connection error"))
+	mockDB, mockHandler := services.ConnectToSQLDB(services.MockDB)
+	mockDao := NewDao(mockDB)
+
+	mockHandler.ExpectQuery(regexp.QuoteMeta(fmt.Sprintf(getIspIpCountsQuery, mockReleases[0].Table))).WithArgs("").WillReturnRows(rowsWithError)
+
+	output, err := mockDao.GetIspIpCounts(context.Background(), mockReleases[0], "")
+	assert.Equal(t, []ipdata.IspIpCount{}, output)
+	assert.True(t, errors.Is(err, common.ErrorInternalServer))
+}
+
+// mock utils
+
+// mockReleases are newest first, as GetReleases returns them
+var mockReleases = []Release{
+	{Date: "2026-04-01", Table: "proxydata.ip2location_20260401"},
+	{Date: "2026-03-01", Table: "proxydata.ip2location_20260301"},
+}
@@ -0,0 +1,242 @@
+package releases
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+// maxMoversLimit is the max number of ISPs listed as grown and as shrunk
+const maxMoversLimit = 100
+
+//go:generate mockgen -destination=mock_gateway.go -package=releases -source=gateway.go Gateway
+
+type Gateway interface {
+	// GetReleases returns the retained releases, newest first
+	GetReleases(ctx context.Context) ([]Release, error)
+	// GetDataAsOf returns the data of the ip in the newest release dated on or before asOf (YYYY-MM-DD)
+	GetDataAsOf(ctx context.Context, ip netip.Addr, asOf string) (ReleaseData, error)
+	// GetHistory returns the classification of the ip in every retained release
+	GetHistory(ctx context.Context, ip netip.Addr) (History, error)
+	// AddRelease validates and registers the table of a release, then removes the oldest releases over keep.
+	// It returns the removed releases
+	AddRelease(ctx context.Context, release Release, keep int) ([]Release, error)
+	// GetTrend returns the ip count per proxy type of the country code or ISP in every retained release
+	GetTrend(ctx context.Context, dimension string, value string) (Trend, error)
+	// GetMovers returns the ISPs whose ip count grew and shrank the most between the last two releases
+	GetMovers(ctx context.Context, proxyType string, limit int) (Movers, error)
+}
+
+type gateway struct {
+	dao Dao
+}
+
+func NewGateway(dao Dao) Gateway {
+	return gateway{dao: dao}
+}
+
+func (g gateway) GetReleases(ctx context.Context) ([]Release, error) {
+	return g.dao.GetReleases(ctx)
+}
+
+// GetDataAsOf looks up the ip in the release that was current at asOf, special purpose addresses are answered
+// without a release as GetDataFromIP does
+func (g gateway) GetDataAsOf(ctx context.Context, ip netip.Addr, asOf string) (ReleaseData, error) {
+	err := validateDate(asOf)
+	if err != nil {
+		return ReleaseData{}, fmt.Errorf("param: as_of %w", err)
+	}
+	ip = ip.Unmap()
+	if specialPurpose, special := ipdata.LookupSpecialPurpose(ip); special {
+		return ReleaseData{IpData: ipdata.IpData{IpString: ip.String(), SpecialPurpose: &specialPurpose}}, nil
+	}
+	if !ip.Is4() {
+		return ReleaseData{}, fmt.Errorf("ip %s is not an ipv4, the dataset only holds ipv4 %w", ip, common.ErrorBadRequest)
+	}
+
+	releases, err := g.dao.GetReleases(ctx)
+	if err != nil {
+		return ReleaseData{}, err
+	}
+	// the releases are newest first and the YYYY-MM-DD dates sort as strings
+	for _, release := range releases {
+		if release.Date > asOf {
+			continue
+		}
+		ipData, err := g.dao.GetByIp(ctx, release, ip)
+		if err != nil {
+			return ReleaseData{}, err
+		}
+		ipData.IpString = ip.String()
+		return ReleaseData{Release: release.Date, IpData: ipData}, nil
+	}
+	return ReleaseData{}, fmt.Errorf("no release retained on or before %s %w", asOf, common.ErrorNotFound)
+}
+
+// GetHistory looks up the ip in every retained release, newest first. A release without the ip is listed as not
+// found
+func (g gateway) GetHistory(ctx context.Context, ip netip.Addr) (History, error) {
+	ip = ip.Unmap()
+	history := History{Ip: ip.String(), Releases: []HistoryEntry{}}
+	if specialPurpose, special := ipdata.LookupSpecialPurpose(ip); special {
+		history.SpecialPurpose = &specialPurpose
+		return history, nil
+	}
+	if !ip.Is4() {
+		return History{}, fmt.Errorf("ip %s is not an ipv4, the dataset only holds ipv4 %w", ip, common.ErrorBadRequest)
+	}
+
+	releases, err := g.dao.GetReleases(ctx)
+	if err != nil {
+		return History{}, err
+	}
+	for _, release := range releases {
+		ipData, err := g.dao.GetByIp(ctx, release, ip)
+		if err != nil && !errors.Is(err, common.ErrorNotFound) {
+			return History{}, err
+		}
+		history.Releases = append(history.Releases, HistoryEntry{Release: release.Date, Found: err == nil, IpData: ipData})
+	}
+	return history, nil
+}
+
+// AddRelease registers the release when its table has no validation errors. The retention keeps the newest keep
+// releases, the tables of the older ones are dropped: a release older than the kept ones is rejected instead of
+// registered and dropped. The table of the current dataset or of another release can't be a release
+func (g gateway) AddRelease(ctx context.Context, release Release, keep int) ([]Release, error) {
+	err := validateDate(release.Date)
+	if err != nil {
+		return []Release{}, err
+	}
+	if keep < 1 {
+		return []Release{}, fmt.Errorf("at least 1 release must be kept %w", common.ErrorBadRequest)
+	}
+	if release.Table == ipdata.DatasetTable {
+		return []Release{}, fmt.Errorf("the current dataset table %s can't be a release, it would be dropped by the retention %w",
+			release.Table, common.ErrorBadRequest)
+	}
+	registered, err := g.dao.GetReleases(ctx)
+	if err != nil {
+		return []Release{}, err
+	}
+	err = checkRetained(release, registered, keep)
+	if err != nil {
+		return []Release{}, err
+	}
+
+	walker, err := g.dao.GetRangeWalker(release)
+	if err != nil {
+		return []Release{}, err
+	}
+	report, err := ipdata.ValidateRanges(ctx, walker, 0)
+	if err != nil {
+		return []Release{}, err
+	}
+	if !report.Valid {
+		return []Release{}, fmt.Errorf("release table %s has %d validation errors %w", release.Table, report.Errors, common.ErrorBadRequest)
+	}
+
+	err = g.dao.AddRelease(ctx, release)
+	if err != nil {
+		return []Release{}, err
+	}
+
+	releases, err := g.dao.GetReleases(ctx)
+	if err != nil {
+		return []Release{}, err
+	}
+	removed := make([]Release, 0)
+	for i := keep; i < len(releases); i++ {
+		err = g.dao.DeleteRelease(ctx, releases[i])
+		if err != nil {
+			return removed, err
+		}
+		removed = append(removed, releases[i])
+	}
+	return removed, nil
+}
+
+// checkRetained rejects a release whose table is already registered, the retention of one would drop the table of
+// both, and a release that would be dropped by the retention right after being registered
+func checkRetained(release Release, registered []Release, keep int) error {
+	newer := 0
+	for _, other := range registered {
+		if other.Table == release.Table {
+			return fmt.Errorf("table %s is already registered as the release %s %w", release.Table, other.Date, common.ErrorConflict)
+		}
+		// the YYYY-MM-DD dates sort as strings
+		if other.Date > release.Date {
+			newer++
+		}
+	}
+	if newer >= keep {
+		return fmt.Errorf("release %s is older than the %d kept releases, the retention would drop it %w", release.Date, keep,
+			common.ErrorBadRequest)
+	}
+	return nil
+}
+
+// GetTrend returns a point per retained release, oldest first. dimension is TrendCountry with a country code or
+// TrendIsp with the exact ISP name
+func (g gateway) GetTrend(ctx context.Context, dimension string, value string) (Trend, error) {
+	switch dimension {
+	case TrendCountry:
+		if !ipdata.IsValidCountryCode(value) {
+			return Trend{}, fmt.Errorf("invalid country_code  %w", common.ErrorBadRequest)
+		}
+	case TrendIsp:
+		if value == "" {
+			return Trend{}, fmt.Errorf("isp can't be empty %w", common.ErrorBadRequest)
+		}
+	default:
+		return Trend{}, fmt.Errorf("invalid trend dimension %s %w", dimension, common.ErrorBadRequest)
+	}
+
+	releases, err := g.dao.GetReleases(ctx)
+	if err != nil {
+		return Trend{}, err
+	}
+	trend := Trend{Dimension: dimension, Value: value, Releases: make([]TrendPoint, 0, len(releases))}
+	for i := len(releases) - 1; i >= 0; i-- {
+		byProxyType, err := g.dao.GetProxyTypeCounts(ctx, releases[i], dimension, value)
+		if err != nil {
+			return Trend{}, err
+		}
+		trend.Releases = append(trend.Releases, newTrendPoint(releases[i], byProxyType))
+	}
+	return trend, nil
+}
+
+// GetMovers compares the ip count of every ISP in the newest release with the previous one, only of the
+// proxyType ranges if it is not empty. limit is the number of ISPs listed as grown and as shrunk
+func (g gateway) GetMovers(ctx context.Context, proxyType string, limit int) (Movers, error) {
+	if proxyType != "" && !ipdata.IsValidProxyType(proxyType) {
+		return Movers{}, fmt.Errorf("invalid proxy_type %s %w", proxyType, common.ErrorBadRequest)
+	}
+	if limit < 1 || limit > maxMoversLimit {
+		return Movers{}, fmt.Errorf("limit must be between 1 and %d %w", maxMoversLimit, common.ErrorBadRequest)
+	}
+
+	releases, err := g.dao.GetReleases(ctx)
+	if err != nil {
+		return Movers{}, err
+	}
+	if len(releases) < 2 {
+		return Movers{}, fmt.Errorf("the movers need 2 retained releases, %d found %w", len(releases), common.ErrorNotFound)
+	}
+	newCounts, err := g.dao.GetIspIpCounts(ctx, releases[0], proxyType)
+	if err != nil {
+		return Movers{}, err
+	}
+	oldCounts, err := g.dao.GetIspIpCounts(ctx, releases[1], proxyType)
+	if err != nil {
+		return Movers{}, err
+	}
+
+	movers := Movers{OldRelease: releases[1].Date, NewRelease: releases[0].Date, ProxyType: proxyType}
+	movers.Grew, movers.Shrank = ispMoves(oldCounts, newCounts, limit)
+	return movers, nil
+}
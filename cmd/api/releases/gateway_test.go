@@ -0,0 +1,328 @@
+package releases
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/netip"
+	"testing"
+)
+
+func TestGateway_GetDataAsOf(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Release current at the date", TestFn: testGtwGetDataAsOfNoError},
+		{Scenario: "No release before the date error", TestFn: testGtwGetDataAsOfNoReleaseError},
+		{Scenario: "Invalid date error", TestFn: testGtwGetDataAsOfInvalidDateError},
+		{Scenario: "Special purpose ip", TestFn: testGtwGetDataAsOfSpecialPurpose},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestGateway_GetHistory(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Found and not found releases", TestFn: testGtwGetHistoryNoError},
+		{Scenario: "Dao thrown error", TestFn: testGtwGetHistoryDBError},
+		{Scenario: "Ipv6 error", TestFn: testGtwGetHistoryIpv6Error},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestGateway_AddRelease(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Oldest releases removed", TestFn: testGtwAddReleaseRetention},
+		{Scenario: "Invalid release table error", TestFn: testGtwAddReleaseInvalidRangesError},
+		{Scenario: "Current dataset table error", TestFn: testGtwAddReleaseCurrentTableError},
+		{Scenario: "Release older than the kept ones error", TestFn: testGtwAddReleaseOlderThanKeptError},
+		{Scenario: "Table of another release error", TestFn: testGtwAddReleaseRegisteredTableError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestGateway_GetTrend(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Points oldest first", TestFn: testGtwGetTrendNoError},
+		{Scenario: "Invalid country code error", TestFn: testGtwGetTrendInvalidCountryError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestGateway_GetMovers(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Last two releases compared", TestFn: testGtwGetMoversNoError},
+		{Scenario: "Single release error", TestFn: testGtwGetMoversSingleReleaseError},
+		{Scenario: "Invalid proxy type error", TestFn: testGtwGetMoversInvalidProxyTypeError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+// GetDataAsOf
+
+func testGtwGetDataAsOfNoError(t *testing.T) {
+	ip := netip.MustParseAddr("5.181.131.180")
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	testGateway := NewGateway(mockDao)
+
+	mockDao.EXPECT().GetReleases(gomock.Any()).Return(mockReleases, nil)
+	mockDao.EXPECT().GetByIp(gomock.Any(), mockReleases[1], ip).Return(ipdata.IpData{IpFrom: 95781810, IpTo: 95781817, ProxyType: "VPN"}, nil)
+
+	output, err := testGateway.GetDataAsOf(context.Background(), ip, "2026-03-31")
+
+	assert.Nil(t, err)
+	assert.Equal(t, ReleaseData{Release: "2026-03-01",
+		IpData: ipdata.IpData{IpFrom: 95781810, IpTo: 95781817, ProxyType: "VPN", IpString: "5.181.131.180"}}, output)
+}
+
+func testGtwGetDataAsOfNoReleaseError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	testGateway := NewGateway(mockDao)
+
+	mockDao.EXPECT().GetReleases(gomock.Any()).Return(mockReleases, nil)
+
+	_, err := testGateway.GetDataAsOf(context.Background(), netip.MustParseAddr("5.181.131.180"), "2026-02-28")
+
+	assert.Equal(t, "no release retained on or before 2026-02-28 not found", err.Error())
+}
+
+func testGtwGetDataAsOfInvalidDateError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	testGateway := NewGateway(mockDao)
+
+	_, err := testGateway.GetDataAsOf(context.Background(), netip.MustParseAddr("5.181.131.180"), "March")
+
+	assert.Equal(t, "param: as_of date March must be a YYYY-MM-DD date bad request", err.Error())
+}
+
+func testGtwGetDataAsOfSpecialPurpose(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	testGateway := NewGateway(mockDao)
+
+	output, err := testGateway.GetDataAsOf(context.Background(), netip.MustParseAddr("10.1.2.3"), "2026-03-01")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "", output.Release)
+	assert.Equal(t, ipdata.SpecialPrivate, output.SpecialPurpose.Classification)
+}
+
+// GetHistory
+
+func testGtwGetHistoryNoError(t *testing.T) {
+	ip := netip.MustParseAddr("5.181.131.180")
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	testGateway := NewGateway(mockDao)
+
+	mockDao.EXPECT().GetReleases(gomock.Any()).Return(mockReleases, nil)
+	mockDao.EXPECT().GetByIp(gomock.Any(), mockReleases[0], ip).Return(ipdata.IpData{}, fmt.Errorf("not in release %w", common.ErrorNotFound))
+	mockDao.EXPECT().GetByIp(gomock.Any(), mockReleases[1], ip).Return(ipdata.IpData{IpFrom: 95781810, IpTo: 95781817, ProxyType: "VPN"}, nil)
+
+	output, err := testGateway.GetHistory(context.Background(), ip)
+
+	assert.Nil(t, err)
+	assert.Equal(t, History{Ip: "5.181.131.180", Releases: []HistoryEntry{
+		{Release: "2026-04-01", Found: false},
+		{Release: "2026-03-01", Found: true, IpData: ipdata.IpData{IpFrom: 95781810, IpTo: 95781817, ProxyType: "VPN"}},
+	}}, output)
+}
+
+func testGtwGetHistoryDBError(t *testing.T) {
+	ip := netip.MustParseAddr("5.181.131.180")
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	testGateway := NewGateway(mockDao)
+
+	mockDao.EXPECT().GetReleases(gomock.Any()).Return(mockReleases, nil)
+	mockDao.EXPECT().GetByIp(gomock.Any(), mockReleases[0], ip).Return(ipdata.IpData{}, fmt.Errorf("connection error %w", common.ErrorInternalServer))
+
+	_, err := testGateway.GetHistory(context.Background(), ip)
+
+	assert.True(t, errors.Is(err, common.ErrorInternalServer))
+}
+
+func testGtwGetHistoryIpv6Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	testGateway := NewGateway(mockDao)
+
+	_, err := testGateway.GetHistory(context.Background(), netip.MustParseAddr("2a00:1450::1"))
+
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+}
+
+// AddRelease
+
+func testGtwAddReleaseRetention(t *testing.T) {
+	release := Release{Date: "2026-05-01", Table: "proxydata.ip2location_20260501"}
+	retained := append([]Release{release}, mockReleases...)
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	testGateway := NewGateway(mockDao)
+
+	mockDao.EXPECT().GetReleases(gomock.Any()).Return(mockReleases, nil)
+	mockDao.EXPECT().GetRangeWalker(release).Return(ipdata.NewMemoryDao(mockReleaseRanges), nil)
+	mockDao.EXPECT().AddRelease(gomock.Any(), release).Return(nil)
+	mockDao.EXPECT().GetReleases(gomock.Any()).Return(retained, nil)
+	mockDao.EXPECT().DeleteRelease(gomock.Any(), mockReleases[1]).Return(nil)
+
+	removed, err := testGateway.AddRelease(context.Background(), release, 2)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []Release{mockReleases[1]}, removed)
+}
+
+func testGtwAddReleaseInvalidRangesError(t *testing.T) {
+	release := Release{Date: "2026-05-01", Table: "proxydata.ip2location_20260501"}
+	invalidRanges := append([]ipdata.IpData{{IpFrom: 95781812, IpTo: 95781820, ProxyType: "VPN", CountryCode: "GB",
+		CountryName: "United Kingdom of Great Britain and Northern Ireland (the)", ISP: "IPXO Limited"}}, mockReleaseRanges...)
+
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	testGateway := NewGateway(mockDao)
+
+	mockDao.EXPECT().GetReleases(gomock.Any()).Return(mockReleases, nil)
+	mockDao.EXPECT().GetRangeWalker(release).Return(ipdata.NewMemoryDao(invalidRanges), nil)
+
+	_, err := testGateway.AddRelease(context.Background(), release, 2)
+
+	assert.Equal(t, "release table proxydata.ip2location_20260501 has 1 validation errors bad request", err.Error())
+}
+
+func testGtwAddReleaseCurrentTableError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	testGateway := NewGateway(mockDao)
+
+	_, err := testGateway.AddRelease(context.Background(), Release{Date: "2026-05-01", Table: ipdata.DatasetTable}, 2)
+
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+}
+
+func testGtwAddReleaseOlderThanKeptError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	testGateway := NewGateway(mockDao)
+
+	mockDao.EXPECT().GetReleases(gomock.Any()).Return(mockReleases, nil)
+
+	_, err := testGateway.AddRelease(context.Background(), Release{Date: "2026-02-01", Table: "proxydata.ip2location_20260201"}, 2)
+
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+	assert.Equal(t, "release 2026-02-01 is older than the 2 kept releases, the retention would drop it bad request", err.Error())
+}
+
+func testGtwAddReleaseRegisteredTableError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	testGateway := NewGateway(mockDao)
+
+	mockDao.EXPECT().GetReleases(gomock.Any()).Return(mockReleases, nil)
+
+	_, err := testGateway.AddRelease(context.Background(), Release{Date: "2026-05-01", Table: mockReleases[1].Table}, 2)
+
+	assert.True(t, errors.Is(err, common.ErrorConflict))
+}
+
+// GetTrend
+
+func testGtwGetTrendNoError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	testGateway := NewGateway(mockDao)
+
+	mockDao.EXPECT().GetReleases(gomock.Any()).Return(mockReleases, nil)
+	mockDao.EXPECT().GetProxyTypeCounts(gomock.Any(), mockReleases[1], TrendIsp, "Init7").Return(map[string]int64{"VPN": 100}, nil)
+	mockDao.EXPECT().GetProxyTypeCounts(gomock.Any(), mockReleases[0], TrendIsp, "Init7").Return(map[string]int64{"VPN": 120, "TOR": 8}, nil)
+
+	output, err := testGateway.GetTrend(context.Background(), TrendIsp, "Init7")
+
+	assert.Nil(t, err)
+	assert.Equal(t, Trend{Dimension: TrendIsp, Value: "Init7", Releases: []TrendPoint{
+		{Release: "2026-03-01", IpCount: 100, ByProxyType: map[string]int64{"VPN": 100}},
+		{Release: "2026-04-01", IpCount: 128, ByProxyType: map[string]int64{"VPN": 120, "TOR": 8}},
+	}}, output)
+}
+
+func testGtwGetTrendInvalidCountryError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	testGateway := NewGateway(mockDao)
+
+	_, err := testGateway.GetTrend(context.Background(), TrendCountry, "XX")
+
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+}
+
+// GetMovers
+
+func testGtwGetMoversNoError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	testGateway := NewGateway(mockDao)
+
+	mockDao.EXPECT().GetReleases(gomock.Any()).Return(mockReleases, nil)
+	mockDao.EXPECT().GetIspIpCounts(gomock.Any(), mockReleases[0], "VPN").Return(mockNewIspCounts, nil)
+	mockDao.EXPECT().GetIspIpCounts(gomock.Any(), mockReleases[1], "VPN").Return(mockOldIspCounts, nil)
+
+	output, err := testGateway.GetMovers(context.Background(), "VPN", 1)
+
+	assert.Nil(t, err)
+	assert.Equal(t, Movers{OldRelease: "2026-03-01", NewRelease: "2026-04-01", ProxyType: "VPN",
+		Grew:   []ipdata.CountChange{{Key: "M247", NewIpCount: 300, Delta: 300}},
+		Shrank: []ipdata.CountChange{{Key: "Leaseweb", OldIpCount: 400, Delta: -400}},
+	}, output)
+}
+
+func testGtwGetMoversSingleReleaseError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	testGateway := NewGateway(mockDao)
+
+	mockDao.EXPECT().GetReleases(gomock.Any()).Return(mockReleases[:1], nil)
+
+	_, err := testGateway.GetMovers(context.Background(), "", 10)
+
+	assert.Equal(t, "the movers need 2 retained releases, 1 found not found", err.Error())
+}
+
+func testGtwGetMoversInvalidProxyTypeError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDao := NewMockDao(ctrl)
+	testGateway := NewGateway(mockDao)
+
+	_, err := testGateway.GetMovers(context.Background(), "PROXY", 10)
+
+	assert.True(t, errors.Is(err, common.ErrorBadRequest))
+}
+
+// mock utils
+
+var mockReleaseRanges = []ipdata.IpData{
+	{IpFrom: 95781810, IpTo: 95781817, ProxyType: "VPN", CountryCode: "GB",
+		CountryName: "United Kingdom of Great Britain and Northern Ireland (the)", ISP: "IPXO Limited"},
+}
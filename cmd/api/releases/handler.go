@@ -0,0 +1,157 @@
+package releases
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type Handler interface {
+	// GetReleases returns the retained releases
+	GetReleases(w http.ResponseWriter, r *http.Request)
+	// GetDataAsOf returns the data of the ip in the release current at the as_of date
+	GetDataAsOf(w http.ResponseWriter, r *http.Request)
+	// GetHistory returns the classification of the ip in every retained release
+	GetHistory(w http.ResponseWriter, r *http.Request)
+	// GetCountryTrend returns the ip count of a country code in every retained release
+	GetCountryTrend(w http.ResponseWriter, r *http.Request)
+	// GetIspTrend returns the ip count of an ISP in every retained release
+	GetIspTrend(w http.ResponseWriter, r *http.Request)
+	// GetMovers returns the ISPs whose ip count changed the most between the last two releases
+	GetMovers(w http.ResponseWriter, r *http.Request)
+}
+
+const defaultMoversLimit = 10
+
+type handler struct {
+	gtw Gateway
+}
+
+func NewHandler(gtw Gateway) Handler {
+	return handler{gtw: gtw}
+}
+
+func (h handler) GetReleases(w http.ResponseWriter, r *http.Request) {
+	releases, err := h.gtw.GetReleases(r.Context())
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	writeJSON(w, releases)
+}
+
+// GetDataAsOf answers /ipdata/{ip}?as_of=YYYY-MM-DD, the response is the one of /ipdata/{ip} with the release
+// the ip was looked up in
+func (h handler) GetDataAsOf(w http.ResponseWriter, r *http.Request) {
+	ip, err := common.GetParamFromRequest(r, "ip")
+	if err != nil {
+		err = fmt.Errorf("param: ip %w", err)
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+	addr, err := ipdata.ParseIP(ip)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	data, err := h.gtw.GetDataAsOf(r.Context(), addr, r.URL.Query().Get("as_of"))
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	writeJSON(w, data)
+}
+
+func (h handler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	ip, err := common.GetParamFromRequest(r, "ip")
+	if err != nil {
+		err = fmt.Errorf("param: ip %w", err)
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+	addr, err := ipdata.ParseIP(ip)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	history, err := h.gtw.GetHistory(r.Context(), addr)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	writeJSON(w, history)
+}
+
+func (h handler) GetCountryTrend(w http.ResponseWriter, r *http.Request) {
+	country, err := common.GetParamFromRequest(r, "country")
+	if err != nil {
+		err = fmt.Errorf("param: country %w", err)
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	h.writeTrend(w, r, TrendCountry, strings.ToUpper(country))
+}
+
+func (h handler) GetIspTrend(w http.ResponseWriter, r *http.Request) {
+	isp, err := common.GetParamFromRequest(r, "isp")
+	if err != nil {
+		err = fmt.Errorf("param: isp %w", err)
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	h.writeTrend(w, r, TrendIsp, isp)
+}
+
+// GetMovers proxy_type is an optional filter of the ranges counted, limit the number of ISPs listed as grown and
+// as shrunk
+func (h handler) GetMovers(w http.ResponseWriter, r *http.Request) {
+	limit := defaultMoversLimit
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		var err error
+		limit, err = strconv.Atoi(limitParam)
+		if err != nil {
+			err = fmt.Errorf("param: limit must be a number %w", common.ErrorBadRequest)
+			common.HandlerErrorResponse(w, err)
+			return
+		}
+	}
+
+	movers, err := h.gtw.GetMovers(r.Context(), strings.ToUpper(r.URL.Query().Get("proxy_type")), limit)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	writeJSON(w, movers)
+}
+
+func (h handler) writeTrend(w http.ResponseWriter, r *http.Request, dimension string, value string) {
+	trend, err := h.gtw.GetTrend(r.Context(), dimension, value)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	writeJSON(w, trend)
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	response, err := json.Marshal(value)
+	if err != nil {
+		common.HandlerErrorResponse(w, err)
+		return
+	}
+
+	w.Write(response)
+}
@@ -0,0 +1,193 @@
+package releases
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestHandler_GetReleases(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testHandlerGetReleasesNoError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestHandler_GetDataAsOf(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testHandlerGetDataAsOfNoError},
+		{Scenario: "Gateway not found error", TestFn: testHandlerGetDataAsOfNotFoundError},
+		{Scenario: "Invalid ip error", TestFn: testHandlerGetDataAsOfInvalidIpError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestHandler_GetHistory(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "No error", TestFn: testHandlerGetHistoryNoError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func TestHandler_GetTrends(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Country code upper cased", TestFn: testHandlerGetCountryTrendNoError},
+		{Scenario: "Movers default limit", TestFn: testHandlerGetMoversNoError},
+		{Scenario: "Movers invalid limit error", TestFn: testHandlerGetMoversInvalidLimitError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func testHandlerGetReleasesNoError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw)
+
+	mockGtw.EXPECT().GetReleases(gomock.Any()).Return(mockReleases, nil)
+
+	rr := serveHandler(t, testHandler.GetReleases, "/ipdata/releases", nil)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `[{"date":"2026-04-01","table":"proxydata.ip2location_20260401"},`+
+		`{"date":"2026-03-01","table":"proxydata.ip2location_20260301"}]`, rr.Body.String())
+}
+
+func testHandlerGetDataAsOfNoError(t *testing.T) {
+	data := ReleaseData{Release: "2026-03-01", IpData: ipdata.IpData{IpFrom: 95781810, IpTo: 95781817, ProxyType: "VPN", IpString: "5.181.131.180"}}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw)
+
+	mockGtw.EXPECT().GetDataAsOf(gomock.Any(), netip.MustParseAddr("5.181.131.180"), "2026-03-31").Return(data, nil)
+
+	rr := serveHandler(t, testHandler.GetDataAsOf, "/ipdata/{ip}?as_of=2026-03-31", map[string]string{"ip": "5.181.131.180"})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"release":"2026-03-01","ip_from":95781810,"ip_to":95781817,"proxy_type":"VPN","ip_string":"5.181.131.180"}`, rr.Body.String())
+}
+
+func testHandlerGetDataAsOfNotFoundError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw)
+
+	mockGtw.EXPECT().GetDataAsOf(gomock.Any(), netip.MustParseAddr("5.181.131.180"), "2020-01-01").
+		Return(ReleaseData{}, fmt.Errorf("no release retained on or before 2020-01-01 %w", common.ErrorNotFound))
+
+	rr := serveHandler(t, testHandler.GetDataAsOf, "/ipdata/{ip}?as_of=2020-01-01", map[string]string{"ip": "5.181.131.180"})
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, "no release retained on or before 2020-01-01 not found\n", rr.Body.String())
+}
+
+func testHandlerGetDataAsOfInvalidIpError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw)
+
+	rr := serveHandler(t, testHandler.GetDataAsOf, "/ipdata/{ip}?as_of=2026-03-01", map[string]string{"ip": "5.181.131"})
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "5.181.131 is not a valid ip bad request\n", rr.Body.String())
+}
+
+func testHandlerGetHistoryNoError(t *testing.T) {
+	history := History{Ip: "5.181.131.180", Releases: []HistoryEntry{
+		{Release: "2026-04-01", Found: false},
+		{Release: "2026-03-01", Found: true, IpData: ipdata.IpData{IpFrom: 95781810, IpTo: 95781817, ProxyType: "VPN"}},
+	}}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw)
+
+	mockGtw.EXPECT().GetHistory(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(history, nil)
+
+	rr := serveHandler(t, testHandler.GetHistory, "/ipdata/{ip}/history", map[string]string{"ip": "5.181.131.180"})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"ip":"5.181.131.180","releases":[{"release":"2026-04-01","found":false},`+
+		`{"release":"2026-03-01","found":true,"ip_from":95781810,"ip_to":95781817,"proxy_type":"VPN"}]}`, rr.Body.String())
+}
+
+func testHandlerGetCountryTrendNoError(t *testing.T) {
+	trend := Trend{Dimension: TrendCountry, Value: "CH", Releases: []TrendPoint{
+		{Release: "2026-03-01", IpCount: 18, ByProxyType: map[string]int64{"VPN": 8, "TOR": 10}},
+	}}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw)
+
+	mockGtw.EXPECT().GetTrend(gomock.Any(), TrendCountry, "CH").Return(trend, nil)
+
+	rr := serveHandler(t, testHandler.GetCountryTrend, "/ipdata/trends/country/{country}", map[string]string{"country": "ch"})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"dimension":"country","value":"CH","releases":[{"release":"2026-03-01","ip_count":18,"by_proxy_type":{"TOR":10,"VPN":8}}]}`,
+		rr.Body.String())
+}
+
+func testHandlerGetMoversNoError(t *testing.T) {
+	movers := Movers{OldRelease: "2026-03-01", NewRelease: "2026-04-01",
+		Grew:   []ipdata.CountChange{{Key: "M247", NewIpCount: 300, Delta: 300}},
+		Shrank: []ipdata.CountChange{},
+	}
+
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw)
+
+	mockGtw.EXPECT().GetMovers(gomock.Any(), "", defaultMoversLimit).Return(movers, nil)
+
+	rr := serveHandler(t, testHandler.GetMovers, "/ipdata/trends/movers", nil)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"old_release":"2026-03-01","new_release":"2026-04-01",`+
+		`"grew":[{"key":"M247","old_ip_count":0,"new_ip_count":300,"delta":300}],"shrank":[]}`, rr.Body.String())
+}
+
+func testHandlerGetMoversInvalidLimitError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := NewMockGateway(ctrl)
+	testHandler := NewHandler(mockGtw)
+
+	rr := serveHandler(t, testHandler.GetMovers, "/ipdata/trends/movers?limit=ten", nil)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "param: limit must be a number bad request\n", rr.Body.String())
+}
+
+// mock utils
+
+func serveHandler(t *testing.T, handlerFn http.HandlerFunc, url string, muxVars map[string]string) *httptest.ResponseRecorder {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = mux.SetURLVars(req, muxVars)
+
+	rr := httptest.NewRecorder()
+	handlerFn.ServeHTTP(rr, req)
+	return rr
+}
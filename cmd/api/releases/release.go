@@ -0,0 +1,47 @@
+package releases
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"fmt"
+	"time"
+)
+
+// DateLayout is the layout of the release dates and of the as_of param
+const DateLayout = "2006-01-02"
+
+// Release is a dataset imported in its own table, tagged by the date it was published
+type Release struct {
+	Date  string `json:"date"`
+	Table string `json:"table"`
+}
+
+// ReleaseData is the data of an ip in a release
+type ReleaseData struct {
+	Release string `json:"release,omitempty"`
+	ipdata.IpData
+}
+
+// HistoryEntry is the classification of an ip in a release, Found is false when no range of the release holds it
+type HistoryEntry struct {
+	Release string `json:"release"`
+	Found   bool   `json:"found"`
+	ipdata.IpData
+}
+
+// History is the classification of an ip in every retained release
+type History struct {
+	Ip string `json:"ip"`
+	// SpecialPurpose is set instead of the releases for the addresses of the special purpose registry
+	SpecialPurpose *ipdata.SpecialPurpose `json:"special_purpose,omitempty"`
+	// Releases are ordered by date desc
+	Releases []HistoryEntry `json:"releases"`
+}
+
+func validateDate(date string) error {
+	_, err := time.Parse(DateLayout, date)
+	if err != nil {
+		return fmt.Errorf("date %s must be a YYYY-MM-DD date %w", date, common.ErrorBadRequest)
+	}
+	return nil
+}
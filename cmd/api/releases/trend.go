@@ -0,0 +1,98 @@
+package releases
+
+import (
+	"DreamLabChallenge/cmd/api/ipdata"
+	"sort"
+)
+
+// dimensions of a Trend
+const (
+	TrendCountry = "country"
+	TrendIsp     = "isp"
+)
+
+// trendColumns is the column filtered by each trend dimension, the only ones placed in the trend query
+var trendColumns = map[string]string{
+	TrendCountry: "country_code",
+	TrendIsp:     "isp",
+}
+
+// TrendPoint is the ip count of a release, in total and per proxy type
+type TrendPoint struct {
+	Release     string           `json:"release"`
+	IpCount     int64            `json:"ip_count"`
+	ByProxyType map[string]int64 `json:"by_proxy_type"`
+}
+
+// Trend is the time series of the ip count of a country code or an ISP over the retained releases
+type Trend struct {
+	Dimension string `json:"dimension"`
+	Value     string `json:"value"`
+	// Releases are ordered by date asc
+	Releases []TrendPoint `json:"releases"`
+}
+
+// Movers are the ISPs whose ip count changed the most from the previous release to the newest one
+type Movers struct {
+	OldRelease string `json:"old_release"`
+	NewRelease string `json:"new_release"`
+	ProxyType  string `json:"proxy_type,omitempty"`
+	// Grew is ordered by delta desc and Shrank by delta asc
+	Grew   []ipdata.CountChange `json:"grew"`
+	Shrank []ipdata.CountChange `json:"shrank"`
+}
+
+func newTrendPoint(release Release, byProxyType map[string]int64) TrendPoint {
+	point := TrendPoint{Release: release.Date, ByProxyType: byProxyType}
+	for _, ipCount := range byProxyType {
+		point.IpCount += ipCount
+	}
+	return point
+}
+
+// ispMoves splits the ISPs whose ip count changed into the ones that grew and the ones that shrank, up to limit
+// each. An ISP missing from a release has no ips in it
+func ispMoves(oldCounts []ipdata.IspIpCount, newCounts []ipdata.IspIpCount, limit int) ([]ipdata.CountChange, []ipdata.CountChange) {
+	changes := make(map[string]*ipdata.CountChange)
+	for _, count := range oldCounts {
+		changes[count.Isp] = &ipdata.CountChange{Key: count.Isp, OldIpCount: count.IpCount}
+	}
+	for _, count := range newCounts {
+		change, found := changes[count.Isp]
+		if !found {
+			change = &ipdata.CountChange{Key: count.Isp}
+			changes[count.Isp] = change
+		}
+		change.NewIpCount = count.IpCount
+	}
+
+	grew := make([]ipdata.CountChange, 0)
+	shrank := make([]ipdata.CountChange, 0)
+	for _, change := range changes {
+		change.Delta = change.NewIpCount - change.OldIpCount
+		if change.Delta > 0 {
+			grew = append(grew, *change)
+		} else if change.Delta < 0 {
+			shrank = append(shrank, *change)
+		}
+	}
+	sortByDelta(grew, func(a, b int64) bool { return a > b })
+	sortByDelta(shrank, func(a, b int64) bool { return a < b })
+	if len(grew) > limit {
+		grew = grew[:limit]
+	}
+	if len(shrank) > limit {
+		shrank = shrank[:limit]
+	}
+	return grew, shrank
+}
+
+// sortByDelta orders the changes by delta with before, ties by key asc
+func sortByDelta(changes []ipdata.CountChange, before func(a, b int64) bool) {
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Delta != changes[j].Delta {
+			return before(changes[i].Delta, changes[j].Delta)
+		}
+		return changes[i].Key < changes[j].Key
+	})
+}
@@ -0,0 +1,55 @@
+package releases
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTrend_IspMoves(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Grew and shrank", TestFn: testIspMovesGrewAndShrank},
+		{Scenario: "Limit", TestFn: testIspMovesLimit},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func testIspMovesGrewAndShrank(t *testing.T) {
+	grew, shrank := ispMoves(mockOldIspCounts, mockNewIspCounts, 10)
+
+	assert.Equal(t, []ipdata.CountChange{
+		{Key: "M247", OldIpCount: 0, NewIpCount: 300, Delta: 300},
+		{Key: "Init7", OldIpCount: 100, NewIpCount: 150, Delta: 50},
+	}, grew)
+	assert.Equal(t, []ipdata.CountChange{
+		{Key: "Leaseweb", OldIpCount: 400, NewIpCount: 0, Delta: -400},
+		{Key: "Swisscom", OldIpCount: 200, NewIpCount: 190, Delta: -10},
+	}, shrank)
+}
+
+func testIspMovesLimit(t *testing.T) {
+	grew, shrank := ispMoves(mockOldIspCounts, mockNewIspCounts, 1)
+
+	assert.Equal(t, []ipdata.CountChange{{Key: "M247", OldIpCount: 0, NewIpCount: 300, Delta: 300}}, grew)
+	assert.Equal(t, []ipdata.CountChange{{Key: "Leaseweb", OldIpCount: 400, NewIpCount: 0, Delta: -400}}, shrank)
+}
+
+// mock utils
+
+var mockOldIspCounts = []ipdata.IspIpCount{
+	{Isp: "Init7", IpCount: 100},
+	{Isp: "Swisscom", IpCount: 200},
+	{Isp: "Leaseweb", IpCount: 400},
+	{Isp: "OVH", IpCount: 50},
+}
+
+var mockNewIspCounts = []ipdata.IspIpCount{
+	{Isp: "Init7", IpCount: 150},
+	{Isp: "Swisscom", IpCount: 190},
+	{Isp: "M247", IpCount: 300},
+	{Isp: "OVH", IpCount: 50},
+}
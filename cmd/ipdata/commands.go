@@ -0,0 +1,339 @@
+package main
+
+import (
+	"DreamLabChallenge/cmd/api/accesslog"
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"DreamLabChallenge/cmd/api/releases"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultTopLimit = 10
+	// defaultKeptReleases is the retention of the release command
+	defaultKeptReleases = 12
+	// defaultOverridesFile is the default of the -overrides-file flag of the API
+	defaultOverridesFile = "overrides.json"
+)
+
+var errUsage = errors.New("invalid arguments")
+
+// errInvalidDataset is returned by validate when the dataset has errors, after the report is printed
+var errInvalidDataset = errors.New("invalid dataset")
+
+// options are the flags shared by every command
+type options struct {
+	dataset   string
+	overrides string
+	output    string
+	topN      int
+	logFormat string
+	jsonField string
+	annotate  bool
+	keep      int
+}
+
+// releaseChange is a release added or removed by the release command
+type releaseChange struct {
+	releases.Release
+	Status string `json:"status"`
+}
+
+// lookupResult is an ip with its data, or the reason why there is no data
+type lookupResult struct {
+	ipdata.IpData
+	Error string `json:"error,omitempty"`
+}
+
+func (c cli) run(command string, args []string) error {
+	opts := options{}
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringVar(&opts.dataset, "dataset", "", "IP2Proxy CSV or BIN file")
+	flags.StringVar(&opts.overrides, "overrides-file", defaultOverridesFile, "json file of the local overrides")
+	flags.StringVar(&opts.output, "o", formatTable, "output format")
+	if command == "top" || command == "logs" || command == "coverage" || command == "validate" || command == "diff" {
+		flags.IntVar(&opts.topN, "n", defaultTopLimit, "number of ISPs")
+	}
+	if command == "logs" {
+		flags.StringVar(&opts.logFormat, "format", accesslog.FormatAuto, "access log format")
+		flags.StringVar(&opts.jsonField, "json-field", accesslog.DefaultJSONField, "client ip field of json logs")
+		flags.BoolVar(&opts.annotate, "annotate", false, "write every line with its data instead of the report")
+	}
+	if command == "release" {
+		flags.IntVar(&opts.keep, "keep", defaultKeptReleases, "number of releases kept")
+	}
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return fmt.Errorf("%s: %w", err.Error(), errUsage)
+	}
+	if !isValidFormat(opts.output) {
+		return fmt.Errorf("unknown output format %s: %w", opts.output, errUsage)
+	}
+
+	var runCommand func(ctx context.Context, gtw ipdata.Gateway, opts options, args []string) error
+	switch command {
+	case "lookup":
+		runCommand = c.lookup
+		if len(positional) == 0 {
+			return errUsage
+		}
+	case "count":
+		runCommand = c.count
+		if len(positional) == 0 {
+			return errUsage
+		}
+	case "top":
+		runCommand = c.top
+		if len(positional) != 1 || opts.topN < 1 {
+			return errUsage
+		}
+	case "stream":
+		runCommand = c.stream
+	case "logs":
+		runCommand = c.logs
+		if opts.topN < 1 {
+			return errUsage
+		}
+	case "coverage":
+		runCommand = c.coverage
+		if opts.topN < 0 {
+			return errUsage
+		}
+	case "validate":
+		runCommand = c.validate
+		if opts.topN < 0 {
+			return errUsage
+		}
+	case "diff":
+		// the datasets are the args, not the -dataset one
+		if len(positional) != 2 || opts.topN < 0 {
+			return errUsage
+		}
+		return c.diff(context.Background(), opts, positional)
+	case "release":
+		// the release is a table of the configured database, not the -dataset one
+		if len(positional) != 2 || opts.keep < 1 {
+			return errUsage
+		}
+		return c.release(context.Background(), opts, positional)
+	default:
+		return fmt.Errorf("unknown command %s: %w", command, errUsage)
+	}
+
+	gtw, err := c.loadGateway(opts.dataset, opts.overrides)
+	if err != nil {
+		return err
+	}
+	return runCommand(context.Background(), gtw, opts, positional)
+}
+
+// lookup prints the data of every ip, the ips not in the dataset are printed with a not found error
+func (c cli) lookup(ctx context.Context, gtw ipdata.Gateway, opts options, ips []string) error {
+	results := make([]lookupResult, 0, len(ips))
+	for _, ip := range ips {
+		addr, err := parseIPv4(ip)
+		if err != nil {
+			return err
+		}
+		result, err := lookupIP(ctx, gtw, addr)
+		if err != nil {
+			return err
+		}
+		results = append(results, result)
+	}
+
+	rows := make([][]string, 0, len(results))
+	for _, result := range results {
+		rows = append(rows, []string{result.IpString, result.ProxyType, result.CountryCode, result.CountryName,
+			result.RegionName, result.CityName, result.ISP, result.Error})
+	}
+	header := []string{"ip", "proxy_type", "country_code", "country_name", "region_name", "city_name", "isp", "error"}
+	return render(c.stdout, opts.output, header, rows, results)
+}
+
+// count prints the ip count of the country, the args are joined so the name doesn't need quotes
+func (c cli) count(ctx context.Context, gtw ipdata.Gateway, opts options, args []string) error {
+	countryName := strings.Join(args, " ")
+	ipCount, err := gtw.GetIpCountByCountryName(ctx, countryName)
+	if err != nil {
+		return err
+	}
+
+	result := struct {
+		CountryName string `json:"country_name"`
+		IpCount     int64  `json:"ip_count"`
+	}{countryName, ipCount}
+	rows := [][]string{{countryName, strconv.FormatInt(ipCount, 10)}}
+	return render(c.stdout, opts.output, []string{"country_name", "ip_count"}, rows, result)
+}
+
+// top prints the top ISPs of the country code
+func (c cli) top(ctx context.Context, gtw ipdata.Gateway, opts options, args []string) error {
+	isps, err := gtw.GetIspIpsByCountryCode(ctx, strings.ToUpper(args[0]), opts.topN)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(isps))
+	for _, isp := range isps {
+		rows = append(rows, []string{isp.Isp, strconv.FormatInt(isp.IpCount, 10)})
+	}
+	return render(c.stdout, opts.output, []string{"isp", "ip_count"}, rows, isps)
+}
+
+// coverage prints the parts of the ipv4 space covered by the dataset, -n sets how many gaps and overlaps are listed
+func (c cli) coverage(ctx context.Context, gtw ipdata.Gateway, opts options, _ []string) error {
+	coverage, err := gtw.GetCoverage(ctx, opts.topN)
+	if err != nil {
+		return err
+	}
+	return render(c.stdout, opts.output, ipdata.CoverageHeader, coverage.Rows(), coverage)
+}
+
+// validate prints the issues of the dataset, -n sets how many are listed. It fails with errInvalidDataset when
+// the dataset has errors so it can be run before loading a new release
+func (c cli) validate(ctx context.Context, gtw ipdata.Gateway, opts options, _ []string) error {
+	report, err := gtw.ValidateDataset(ctx, opts.topN)
+	if err != nil {
+		return err
+	}
+	err = render(c.stdout, opts.output, ipdata.ValidationHeader, report.Rows(), report)
+	if err != nil {
+		return err
+	}
+	if !report.Valid {
+		return fmt.Errorf("%d errors and %d warnings: %w", report.Errors, report.Warnings, errInvalidDataset)
+	}
+	return nil
+}
+
+// diff prints what changed from the old to the new dataset, as a summary, as json or the changed ranges as csv
+func (c cli) diff(ctx context.Context, opts options, sources []string) error {
+	datasets := make([]ipdata.RangeWalker, 0, len(sources))
+	for _, source := range sources {
+		dataset, err := c.loadRanges(source)
+		if err != nil {
+			return err
+		}
+		datasets = append(datasets, dataset)
+	}
+
+	diff, err := ipdata.DiffDatasets(ctx, datasets[0], datasets[1], opts.topN)
+	if err != nil {
+		return err
+	}
+	if opts.output == formatTable {
+		return diff.WriteSummary(c.stdout)
+	}
+	return render(c.stdout, opts.output, ipdata.DiffHeader, diff.Rows(), diff)
+}
+
+// release registers the table of a release and prints it with the releases removed by the retention
+func (c cli) release(ctx context.Context, opts options, args []string) error {
+	gtw, err := c.loadReleases()
+	if err != nil {
+		return err
+	}
+	release := releases.Release{Date: args[0], Table: args[1]}
+	removed, err := gtw.AddRelease(ctx, release, opts.keep)
+	if err != nil {
+		return err
+	}
+
+	changes := []releaseChange{{Release: release, Status: "added"}}
+	for _, old := range removed {
+		changes = append(changes, releaseChange{Release: old, Status: "removed"})
+	}
+	rows := make([][]string, 0, len(changes))
+	for _, change := range changes {
+		rows = append(rows, []string{change.Date, change.Table, change.Status})
+	}
+	return render(c.stdout, opts.output, []string{"date", "table", "status"}, rows, changes)
+}
+
+// stream writes a json line for every non empty stdin line. Invalid ips and failed lookups are written with
+// their error and don't stop the stream
+func (c cli) stream(ctx context.Context, gtw ipdata.Gateway, _ options, _ []string) error {
+	scanner := bufio.NewScanner(c.stdin)
+	out := bufio.NewWriter(c.stdout)
+	defer out.Flush()
+	encoder := json.NewEncoder(out)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var result lookupResult
+		addr, err := parseIPv4(line)
+		if err == nil {
+			result, err = lookupIP(ctx, gtw, addr)
+		}
+		if err != nil {
+			result = lookupResult{IpData: ipdata.IpData{IpString: line}, Error: err.Error()}
+		}
+
+		err = encoder.Encode(result)
+		if err != nil {
+			return err
+		}
+		// each line is flushed so the next command of the pipeline gets it right away
+		err = out.Flush()
+		if err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// lookupIP returns the data of the ip, an ip not present in the dataset is not an error
+func lookupIP(ctx context.Context, gtw ipdata.Gateway, addr netip.Addr) (lookupResult, error) {
+	data, err := gtw.GetDataFromIP(ctx, addr)
+	if errors.Is(err, common.ErrorNotFound) {
+		return lookupResult{IpData: ipdata.IpData{IpString: addr.String()}, Error: common.ErrorNotFound.Error()}, nil
+	}
+	if err != nil {
+		return lookupResult{}, err
+	}
+	if data.SpecialPurpose != nil {
+		reason := fmt.Sprintf("%s address (%s)", data.SpecialPurpose.Classification, data.SpecialPurpose.RFC)
+		return lookupResult{IpData: data, Error: reason}, nil
+	}
+	return lookupResult{IpData: data}, nil
+}
+
+func parseIPv4(ip string) (netip.Addr, error) {
+	addr, err := ipdata.ParseIP(ip)
+	if err != nil || !addr.Is4() {
+		return netip.Addr{}, fmt.Errorf("%s is not a valid ipv4", ip)
+	}
+	return addr, nil
+}
+
+// parseInterspersed parses the flags placed before, between or after the positional args
+func parseInterspersed(flags *flag.FlagSet, args []string) ([]string, error) {
+	positional := make([]string, 0)
+	for {
+		err := flags.Parse(args)
+		if err != nil {
+			return []string{}, err
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"DreamLabChallenge/cmd/api/ipdata"
+	"DreamLabChallenge/cmd/api/releases"
+	"DreamLabChallenge/cmd/services"
+	"strings"
+)
+
+// tableSourcePrefix marks a diff source as a table of the configured database instead of a file
+const tableSourcePrefix = "db:"
+
+// gatewayLoader builds the gateway over the dataset file, or over the configured database if it is empty, with the
+// overrides of the json file as the API does
+type gatewayLoader func(dataset string, overridesFile string) (ipdata.Gateway, error)
+
+func loadGateway(dataset string, overridesFile string) (ipdata.Gateway, error) {
+	overrides, err := ipdata.NewOverrideStore(overridesFile)
+	if err != nil {
+		return nil, err
+	}
+	if dataset == "" {
+		db, _ := services.ConnectToSQLDB(services.Ipv4ProxyDB)
+		return ipdata.NewGateway(ipdata.NewDao(db), overrides), nil
+	}
+
+	dao, err := ipdata.NewDatasetFileDao(dataset)
+	if err != nil {
+		return nil, err
+	}
+	return ipdata.NewGateway(dao, overrides), nil
+}
+
+// rangesLoader builds the walker of the ranges of a diff source, a dataset file or db:<schema.table>
+type rangesLoader func(source string) (ipdata.RangeWalker, error)
+
+func loadRanges(source string) (ipdata.RangeWalker, error) {
+	if strings.HasPrefix(source, tableSourcePrefix) {
+		db, _ := services.ConnectToSQLDB(services.Ipv4ProxyDB)
+		return ipdata.NewTableWalker(db, strings.TrimPrefix(source, tableSourcePrefix))
+	}
+
+	ranges, err := ipdata.LoadDatasetFile(source)
+	if err != nil {
+		return nil, err
+	}
+	return ipdata.NewMemoryDao(ranges), nil
+}
+
+// releasesLoader builds the gateway of the releases registered in the configured database
+type releasesLoader func() (releases.Gateway, error)
+
+func loadReleases() (releases.Gateway, error) {
+	db, _ := services.ConnectToSQLDB(services.Ipv4ProxyDB)
+	return releases.NewGateway(releases.NewDao(db)), nil
+}
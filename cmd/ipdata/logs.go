@@ -0,0 +1,70 @@
+package main
+
+import (
+	"DreamLabChallenge/cmd/api/accesslog"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// logs prints the proxy traffic report of the access log files, or of stdin if there are none.
+// with -annotate every line is written as a json line with the data of its client ip
+func (c cli) logs(ctx context.Context, gtw ipdata.Gateway, opts options, files []string) error {
+	cfg := accesslog.Config{
+		ParserConfig: accesslog.ParserConfig{Format: opts.logFormat, JSONField: opts.jsonField},
+		TopLimit:     opts.topN,
+	}
+
+	readers := make([]io.Reader, 0, len(files))
+	for _, path := range files {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		readers = append(readers, file)
+	}
+	log := c.stdin
+	if len(readers) > 0 {
+		log = io.MultiReader(readers...)
+	}
+
+	if opts.annotate {
+		return accesslog.Annotate(ctx, gtw, log, c.stdout, cfg)
+	}
+	report, err := accesslog.BuildReport(ctx, gtw, log, cfg)
+	if err != nil {
+		return err
+	}
+
+	header := []string{"section", "key", "name", "requests", "share"}
+	rows := [][]string{
+		{"total", "requests", "", strconv.FormatInt(report.Requests, 10), ""},
+		{"total", "proxied", "", strconv.FormatInt(report.ProxiedRequests, 10), formatShare(report.ProxiedShare)},
+		{"total", "skipped_lines", "", strconv.FormatInt(report.SkippedLines, 10), ""},
+	}
+	rows = appendShares(rows, "proxy_type", report.ByProxyType)
+	rows = appendShares(rows, "country", report.TopCountries)
+	rows = appendShares(rows, "isp", report.TopIsps)
+	for _, ipRange := range report.TopRanges {
+		rows = append(rows, []string{"range", ipRange.IpFromString + "-" + ipRange.IpToString,
+			strings.Join([]string{ipRange.ProxyType, ipRange.CountryCode, ipRange.Isp}, " "),
+			strconv.FormatInt(ipRange.Requests, 10), ""})
+	}
+	return render(c.stdout, opts.output, header, rows, report)
+}
+
+func appendShares(rows [][]string, section string, shares []accesslog.RequestShare) [][]string {
+	for _, share := range shares {
+		rows = append(rows, []string{section, share.Key, share.Name, strconv.FormatInt(share.Requests, 10), formatShare(share.Share)})
+	}
+	return rows
+}
+
+func formatShare(share float64) string {
+	return fmt.Sprintf("%.2f%%", share)
+}
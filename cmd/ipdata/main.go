@@ -0,0 +1,74 @@
+// Command ipdata looks up the IP2Proxy dataset from the terminal, using the same Gateway as the API.
+//
+//	ipdata lookup 1.2.3.4
+//	ipdata count Argentina
+//	ipdata top CH -n 20
+//	cat ips.txt | ipdata stream -dataset IP2PROXY-LITE-PX7.CSV
+//	ipdata logs /var/log/nginx/access.log
+//	ipdata coverage -o csv > coverage.csv
+//	ipdata validate -dataset IP2PROXY-LITE-PX11.CSV
+//	ipdata diff IP2PROXY-LITE-PX11-202603.CSV IP2PROXY-LITE-PX11-202604.CSV
+//	ipdata release 2026-04-01 proxydata.ip2location_20260401 -keep 6
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `usage: ipdata <command> [flags] [args]
+
+commands:
+  lookup <ip>...          data of the given ips
+  count <country name>    number of ips of a country
+  top <country code>      top ISPs of a country by ip count, -n sets how many (default 10)
+  stream                  reads ips from stdin, one per line, and writes the enriched ips as NDJSON
+  logs [file]...          proxy traffic report of nginx/Apache combined or json access logs (stdin if no files)
+                          -format auto | combined | json, -json-field (default remote_addr), -n top entries,
+                          -annotate writes every line as json with the data of its client ip
+  coverage                gaps, overlaps and covered ips of the dataset in total, per /8, country and proxy type,
+                          -n sets how many gaps and overlaps are listed (default 10)
+  validate                checks the dataset for overlapping, inverted and out of range ranges, unknown country
+                          codes, country names and proxy types and empty ISPs. exits with 1 when an error is
+                          found, -n sets how many issues are listed (default 10)
+  diff <old> <new>        added, removed and reclassified ranges, ip count changes per country and ISP and proxy
+                          type transitions from the old to the new dataset. each one is a dataset file or
+                          db:<schema.table>, -n sets how many ranges, countries and ISPs are listed (default 10)
+  release <date> <table>  validates and registers the schema.table of the release published at the YYYY-MM-DD date,
+                          then drops the oldest releases, -keep sets how many are kept (default 12)
+
+flags of every command:
+  -dataset string          IP2Proxy CSV or BIN file to read instead of the configured database
+  -overrides-file string   json file of the local overrides applied to the lookups, the one of the API (default "overrides.json")
+  -o string                output format of lookup, count, top, logs, coverage, validate, diff and release: table | json | csv (default "table")
+                           the table of diff is a summary and its csv has the changed ranges
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	app := cli{stdin: os.Stdin, stdout: os.Stdout, loadGateway: loadGateway, loadRanges: loadRanges, loadReleases: loadReleases}
+	err := app.run(os.Args[1], os.Args[2:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ipdata:", err)
+		if errors.Is(err, errUsage) {
+			fmt.Fprint(os.Stderr, usage)
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+}
+
+// cli holds the io of the commands so they can be run from tests
+type cli struct {
+	stdin        io.Reader
+	stdout       io.Writer
+	loadGateway  gatewayLoader
+	loadRanges   rangesLoader
+	loadReleases releasesLoader
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"DreamLabChallenge/cmd/api/releases"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCli_Run(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "Lookup table", TestFn: testCliLookupTable},
+		{Scenario: "Lookup with the overrides file", TestFn: testCliLookupOverridesFile},
+		{Scenario: "Count json", TestFn: testCliCountJSON},
+		{Scenario: "Top with flag after the country code", TestFn: testCliTopCSV},
+		{Scenario: "Stream NDJSON", TestFn: testCliStream},
+		{Scenario: "Access log report csv", TestFn: testCliLogsCSV},
+		{Scenario: "Coverage csv", TestFn: testCliCoverageCSV},
+		{Scenario: "Validate valid dataset", TestFn: testCliValidateValid},
+		{Scenario: "Validate invalid dataset error", TestFn: testCliValidateInvalidError},
+		{Scenario: "Diff json", TestFn: testCliDiffJSON},
+		{Scenario: "Diff wrong number of datasets error", TestFn: testCliDiffUsageError},
+		{Scenario: "Release removes the oldest releases", TestFn: testCliReleaseCSV},
+		{Scenario: "Release without keep error", TestFn: testCliReleaseUsageError},
+		{Scenario: "Invalid ip error", TestFn: testCliLookupInvalidIpError},
+		{Scenario: "Unknown command error", TestFn: testCliUnknownCommandError},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func testCliLookupTable(t *testing.T) {
+	out, err := runCli("", "lookup", "5.181.131.180", "1.1.1.1")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "IP             PROXY_TYPE  COUNTRY_CODE  COUNTRY_NAME  REGION_NAME  CITY_NAME  ISP       ERROR\n"+
+		"5.181.131.180  VPN         CH            Switzerland   Zurich       Zurich     Swisscom  \n"+
+		"1.1.1.1                                                                                  not found\n", out)
+}
+
+func testCliLookupOverridesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overrides.json")
+	store, _ := ipdata.NewOverrideStore(path)
+	assert.Nil(t, store.Save(ipdata.Override{ID: "a1", Range: "5.181.131.180", IpFrom: 95781812, IpTo: 95781812, NotProxy: true, ISP: "Our Office"}))
+	var stdout bytes.Buffer
+	overridesFiles := []string{}
+	app := cli{
+		stdout: &stdout,
+		loadGateway: func(_ string, overridesFile string) (ipdata.Gateway, error) {
+			overridesFiles = append(overridesFiles, overridesFile)
+			overrides, err := ipdata.NewOverrideStore(overridesFile)
+			if err != nil {
+				return nil, err
+			}
+			return ipdata.NewGateway(ipdata.NewMemoryDao(mockCliRanges), overrides), nil
+		},
+	}
+
+	err := app.run("lookup", []string{"-overrides-file", path, "-o", "csv", "5.181.131.180", "5.181.131.181"})
+	assert.Nil(t, err)
+	err = app.run("count", []string{"Switzerland"})
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{path, "overrides.json"}, overridesFiles)
+	assert.True(t, strings.HasPrefix(stdout.String(), "ip,proxy_type,country_code,country_name,region_name,city_name,isp,error\n"+
+		"5.181.131.180,,CH,Switzerland,Zurich,Zurich,Our Office,\n"+
+		"5.181.131.181,VPN,CH,Switzerland,Zurich,Zurich,Swisscom,\n"))
+}
+
+func testCliCountJSON(t *testing.T) {
+	out, err := runCli("", "count", "-o", "json", "Switzerland")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "{\n  \"country_name\": \"Switzerland\",\n  \"ip_count\": 18\n}\n", out)
+}
+
+func testCliTopCSV(t *testing.T) {
+	out, err := runCli("", "top", "ch", "-n", "1", "-o", "csv")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "isp,ip_count\nInit7,10\n", out)
+}
+
+func testCliStream(t *testing.T) {
+	out, err := runCli("5.181.131.180\n\nnot an ip\n", "stream")
+
+	assert.Nil(t, err)
+	assert.Equal(t, `{"ip_from":95781810,"ip_to":95781817,"proxy_type":"VPN","country_code":"CH","county_name":"Switzerland","region_name":"Zurich","city_name":"Zurich","isp":"Swisscom","ip_string":"5.181.131.180"}`+"\n"+
+		`{"ip_string":"not an ip","error":"not an ip is not a valid ipv4"}`+"\n", out)
+}
+
+func testCliLogsCSV(t *testing.T) {
+	log := "5.181.131.180 - - [19/Oct/2026:10:00:00 +0000] \"GET / HTTP/1.1\" 200 612\n" +
+		"1.1.1.1 - - [19/Oct/2026:10:00:01 +0000] \"GET / HTTP/1.1\" 200 612\n"
+	out, err := runCli(log, "logs", "-o", "csv", "-n", "1")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "section,key,name,requests,share\n"+
+		"total,requests,,2,\n"+
+		"total,proxied,,1,50.00%\n"+
+		"total,skipped_lines,,0,\n"+
+		"proxy_type,VPN,,1,50.00%\n"+
+		"proxy_type,none,,1,50.00%\n"+
+		"country,CH,Switzerland,1,100.00%\n"+
+		"isp,Swisscom,,1,100.00%\n"+
+		"range,5.181.131.178-5.181.131.185,VPN CH Swisscom,1,\n", out)
+}
+
+func testCliCoverageCSV(t *testing.T) {
+	out, err := runCli("", "coverage", "-o", "csv", "-n", "1")
+
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(out, "section,key,ip_count,percentage\n"+
+		"total,ranges,2,\n"+
+		"total,invalid_ranges,0,\n"+
+		"total,covered,18,0.00\n"+
+		"total,gaps,4294967278,100.00\n"+
+		"total,overlaps,0,\n"+
+		"slash8,0.0.0.0/8,0,0.00\n"))
+	assert.Contains(t, out, "slash8,5.0.0.0/8,18,0.00\n")
+	assert.True(t, strings.HasSuffix(out, "country,CH,18,100.00\n"+
+		"proxy_type,TOR,10,55.56\n"+
+		"proxy_type,VPN,8,44.44\n"+
+		"gap,5.181.131.196-255.255.255.255,4199185468,\n"))
+}
+
+func testCliValidateValid(t *testing.T) {
+	out, err := runCli("", "validate", "-o", "csv")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "severity,check,ip_from,ip_to,value\n", out)
+}
+
+func testCliValidateInvalidError(t *testing.T) {
+	var stdout bytes.Buffer
+	app := cli{
+		stdout: &stdout,
+		loadGateway: func(string, string) (ipdata.Gateway, error) {
+			return ipdata.NewGateway(ipdata.NewMemoryDao([]ipdata.IpData{
+				mockCliRanges[0],
+				{IpFrom: 95781815, IpTo: 95781820, ProxyType: "VPN", CountryCode: "CH", CountryName: "Switzerland", ISP: "Init7"},
+			}), nil), nil
+		},
+	}
+
+	err := app.run("validate", []string{"-o", "csv"})
+
+	assert.True(t, errors.Is(err, errInvalidDataset))
+	assert.Equal(t, "1 errors and 0 warnings: invalid dataset", err.Error())
+	assert.Equal(t, "severity,check,ip_from,ip_to,value\nerror,overlap,95781815,95781820,95781810-95781817\n", stdout.String())
+}
+
+func testCliDiffJSON(t *testing.T) {
+	var stdout bytes.Buffer
+	app := cli{
+		stdout: &stdout,
+		loadRanges: func(source string) (ipdata.RangeWalker, error) {
+			if source == "old.csv" {
+				return ipdata.NewMemoryDao(mockCliRanges[:1]), nil
+			}
+			return ipdata.NewMemoryDao(mockCliRanges), nil
+		},
+	}
+
+	err := app.run("diff", []string{"old.csv", "new.csv", "-o", "json"})
+
+	assert.Nil(t, err)
+	diff := ipdata.DatasetDiff{}
+	assert.Nil(t, json.Unmarshal(stdout.Bytes(), &diff))
+	assert.Equal(t, int64(1), diff.Added)
+	assert.Equal(t, int64(1), diff.Unchanged)
+	assert.Equal(t, []ipdata.ProxyTypeTransition{{From: ipdata.ProxyTypeNone, To: "TOR", IpCount: 10}}, diff.ProxyTypeTransitions)
+}
+
+func testCliDiffUsageError(t *testing.T) {
+	_, err := runCli("", "diff", "old.csv")
+
+	assert.True(t, errors.Is(err, errUsage))
+}
+
+func testCliReleaseCSV(t *testing.T) {
+	release := releases.Release{Date: "2026-04-01", Table: "proxydata.ip2location_20260401"}
+	ctrl := gomock.NewController(t)
+	mockGtw := releases.NewMockGateway(ctrl)
+	var stdout bytes.Buffer
+	app := cli{
+		stdout: &stdout,
+		loadReleases: func() (releases.Gateway, error) {
+			return mockGtw, nil
+		},
+	}
+
+	mockGtw.EXPECT().AddRelease(gomock.Any(), release, 2).
+		Return([]releases.Release{{Date: "2026-02-01", Table: "proxydata.ip2location_20260201"}}, nil)
+
+	err := app.run("release", []string{"2026-04-01", "proxydata.ip2location_20260401", "-keep", "2", "-o", "csv"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "date,table,status\n2026-04-01,proxydata.ip2location_20260401,added\n"+
+		"2026-02-01,proxydata.ip2location_20260201,removed\n", stdout.String())
+}
+
+func testCliReleaseUsageError(t *testing.T) {
+	_, err := runCli("", "release", "2026-04-01", "proxydata.ip2location_20260401", "-keep", "0")
+
+	assert.True(t, errors.Is(err, errUsage))
+}
+
+func testCliLookupInvalidIpError(t *testing.T) {
+	_, err := runCli("", "lookup", "300.1.1.1")
+
+	assert.Equal(t, "300.1.1.1 is not a valid ipv4", err.Error())
+}
+
+func testCliUnknownCommandError(t *testing.T) {
+	_, err := runCli("", "export")
+
+	assert.True(t, errors.Is(err, errUsage))
+}
+
+// mock utils
+
+var mockCliRanges = []ipdata.IpData{
+	{IpFrom: 95781810, IpTo: 95781817, ProxyType: "VPN", CountryCode: "CH", CountryName: "Switzerland", RegionName: "Zurich", CityName: "Zurich", ISP: "Swisscom"},
+	{IpFrom: 95781818, IpTo: 95781827, ProxyType: "TOR", CountryCode: "CH", CountryName: "Switzerland", RegionName: "Bern", CityName: "Bern", ISP: "Init7"},
+}
+
+func runCli(stdin string, command string, args ...string) (string, error) {
+	var stdout bytes.Buffer
+	app := cli{
+		stdin:  strings.NewReader(stdin),
+		stdout: &stdout,
+		loadGateway: func(string, string) (ipdata.Gateway, error) {
+			return ipdata.NewGateway(ipdata.NewMemoryDao(mockCliRanges), nil), nil
+		},
+	}
+	err := app.run(command, args)
+	return stdout.String(), err
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// output formats
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+func isValidFormat(format string) bool {
+	return format == formatTable || format == formatJSON || format == formatCSV
+}
+
+// render writes the rows as an aligned table or csv, or the value as indented json
+func render(w io.Writer, format string, header []string, rows [][]string, value interface{}) error {
+	switch format {
+	case formatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case formatCSV:
+		writer := csv.NewWriter(w)
+		err := writer.Write(header)
+		if err != nil {
+			return err
+		}
+		return writer.WriteAll(rows)
+	default:
+		table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		io.WriteString(table, strings.ToUpper(strings.Join(header, "\t"))+"\n")
+		for _, row := range rows {
+			io.WriteString(table, strings.Join(row, "\t")+"\n")
+		}
+		return table.Flush()
+	}
+}
@@ -1,6 +1,6 @@
 module DreamLabChallenge
 
-go 1.19
+go 1.20
 
 require (
 	github.com/DATA-DOG/go-sqlmock v1.5.0
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"DreamLabChallenge/cmd/api/ipdata"
+	"context"
+	"flag"
+	"log"
+	"os"
+	"strings"
+)
+
+var (
+	exportFormat = flag.String("format", ipdata.BlocklistFormatPlain, "export mode: blocklist format: plain | nftables | iptables | nginx | haproxy")
+	exportName   = flag.String("blocklist-name", ipdata.DefaultBlocklistName, "export mode: nftables set, iptables chain or haproxy map value")
+	exportIsp    = flag.String("block-isp", "", "export mode: isp whose ranges are exported")
+	exportOutput = flag.String("output", "", "export mode: file where the blocklist is written, stdout if empty")
+)
+
+// ExportBlocklist writes the blocklist of the ranges selected by -block-proxy-types, -block-countries and -block-isp
+func (d *Application) ExportBlocklist() {
+	opts := ipdata.BlocklistOptions{Format: *exportFormat, Name: *exportName}.WithDefaults()
+	err := opts.Validate()
+	if err != nil {
+		log.Fatal(err)
+	}
+	filter := ipdata.BlocklistFilter{
+		ProxyTypes:   upperFlagList(*blockedProxyTypes),
+		CountryCodes: upperFlagList(*blockedCountryCodes),
+		Isp:          *exportIsp,
+	}
+
+	cidrs, err := loadIpDataGateway().GetBlocklist(context.Background(), filter)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = writeBlocklist(*exportOutput, opts, cidrs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("exported %d cidrs", len(cidrs))
+}
+
+// writeBlocklist renders the blocklist to the file at path, or to stdout if it is empty. The file is closed
+// before returning so a failed write is not reported as exported
+func writeBlocklist(path string, opts ipdata.BlocklistOptions, cidrs []string) error {
+	if path == "" {
+		return ipdata.RenderBlocklist(os.Stdout, opts, cidrs)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = ipdata.RenderBlocklist(file, opts, cidrs)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// upperFlagList returns the upper-cased values of a comma separated flag, empty values are skipped
+func upperFlagList(value string) []string {
+	values := make([]string, 0)
+	for _, v := range splitFlagList(value) {
+		v = strings.ToUpper(strings.TrimSpace(v))
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
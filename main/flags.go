@@ -0,0 +1,39 @@
+package main
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"flag"
+	"log"
+	"strings"
+)
+
+var (
+	blockedProxyTypes   = flag.String("block-proxy-types", "", "comma separated proxy types to reject (VPN,TOR,...)")
+	blockedCountryCodes = flag.String("block-countries", "", "comma separated country codes to reject")
+	trustedProxies      = flag.String("trusted-proxies", "", "comma separated CIDRs allowed to set the client ip through X-Forwarded-For")
+	clientIPHeader      = flag.String("client-ip-header", "X-Forwarded-For", "header where the trusted proxies write the client ip: X-Forwarded-For, Forwarded or another ip list header")
+	failOpen            = flag.Bool("fail-open", false, "let requests through when the lookup fails")
+	overridesFile       = flag.String("overrides-file", "overrides.json", "json file where the local overrides of the dataset are persisted")
+	validateDataset     = flag.Bool("validate-dataset", false, "check every range of the dataset before serving it, exit when an error is found")
+)
+
+// policyFromFlags builds the ipdata.Policy shared by the proxy mode, the authz endpoints and the lookup explanations
+func policyFromFlags() ipdata.Policy {
+	return ipdata.NewPolicy(splitFlagList(*blockedProxyTypes), splitFlagList(*blockedCountryCodes))
+}
+
+func trustedProxiesFromFlags() common.TrustedProxies {
+	trusted, err := common.ParseTrustedProxies(splitFlagList(*trustedProxies))
+	if err != nil {
+		log.Fatal(err)
+	}
+	return trusted
+}
+
+func splitFlagList(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+	return strings.Split(value, ",")
+}
@@ -1,12 +1,33 @@
 package main
 
 import (
+	"flag"
 	"log"
 )
 
+const (
+	modeAPI    = "api"
+	modeProxy  = "proxy"
+	modeExport = "export"
+)
+
+var mode = flag.String("mode", modeAPI, "running mode of the app: api | proxy | export")
+
 func main() {
+	flag.Parse()
+
 	app := Application{}
-	app.LoadAndRoute()
+	switch *mode {
+	case modeAPI:
+		app.LoadAndRoute()
+	case modeProxy:
+		app.LoadAndProxy()
+	case modeExport:
+		app.ExportBlocklist()
+		return
+	default:
+		log.Fatalf("unknown mode %s", *mode)
+	}
 
 	log.Fatal(app.server.ListenAndServe())
 }
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"DreamLabChallenge/cmd/api/filterproxy"
+	"flag"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	proxyPort = "8080"
+)
+
+var proxyUpstream = flag.String("upstream", "", "proxy mode: url of the app where allowed requests are forwarded")
+
+// LoadAndProxy loads all the dependencies and prepares the filtering reverse proxy
+func (d *Application) LoadAndProxy() {
+	upstream, err := url.Parse(*proxyUpstream)
+	if err != nil || upstream.Scheme == "" || upstream.Host == "" {
+		log.Fatalf("invalid upstream %q, an absolute url is expected", *proxyUpstream)
+	}
+
+	cfg := filterproxy.Config{
+		Upstream:       upstream,
+		Policy:         policyFromFlags(),
+		TrustedProxies: trustedProxiesFromFlags(),
+		ClientIPHeader: *clientIPHeader,
+		FailOpen:       *failOpen,
+	}
+
+	srv := &http.Server{
+		Handler:      filterproxy.NewFilterProxy(loadIpDataGateway(), cfg),
+		Addr:         "127.0.0.1:" + proxyPort,
+		WriteTimeout: 15 * time.Second,
+		ReadTimeout:  15 * time.Second,
+	}
+
+	d.server = srv
+}
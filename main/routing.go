@@ -1,9 +1,17 @@
 package main
 
 import (
+	"DreamLabChallenge/cmd/api/accesslog"
+	"DreamLabChallenge/cmd/api/authz"
+	"DreamLabChallenge/cmd/api/enrich"
+	"DreamLabChallenge/cmd/api/forwarded"
 	"DreamLabChallenge/cmd/api/ipdata"
+	"DreamLabChallenge/cmd/api/releases"
 	"DreamLabChallenge/cmd/services"
+	"context"
+	"flag"
 	"github.com/gorilla/mux"
+	"log"
 	"net/http"
 	"time"
 )
@@ -12,19 +20,47 @@ const (
 	appPort = "8000"
 )
 
+var (
+	authzClientIPHeaders = flag.String("authz-ip-headers", "", "comma separated headers where the authz endpoints read the client ip")
+	jobsDir              = flag.String("jobs-dir", "jobs", "directory where the enrich jobs are persisted")
+	jobsConcurrency      = flag.Int("jobs-concurrency", 8, "max concurrent lookups of an enrich job")
+)
+
 type Application struct {
 	server *http.Server
 }
 
 // LoadAndRoute loads all the dependencies, prepare handlers and initialize routes
 func (d *Application) LoadAndRoute() {
-	// dataStorage
-	ipv4ProxyDB, _ := services.ConnectToSQLDB(services.Ipv4ProxyDB)
-
 	// ipData
-	ipDataDao := ipdata.NewDao(ipv4ProxyDB)
-	ipDataGateway := ipdata.NewGateway(ipDataDao)
-	ipDataHandler := ipdata.NewHandler(ipDataGateway)
+	ipDataGateway := loadIpDataGateway()
+	trusted := trustedProxiesFromFlags()
+	policy := policyFromFlags()
+	ipDataHandler := ipdata.NewHandler(ipDataGateway, trusted, *clientIPHeader, policy)
+
+	// authz
+	authzHandler := authz.NewHandler(ipDataGateway, authz.Config{
+		ClientIPHeaders: splitFlagList(*authzClientIPHeaders),
+		TrustedProxies:  trusted,
+		Policy:          policy,
+		FailOpen:        *failOpen,
+	})
+
+	// enrich jobs
+	enrichManager, err := enrich.NewManager(ipDataGateway, enrich.Config{Dir: *jobsDir, Concurrency: *jobsConcurrency})
+	if err != nil {
+		log.Fatal(err)
+	}
+	enrichHandler := enrich.NewHandler(enrichManager)
+
+	// access log reports
+	accessLogHandler := accesslog.NewHandler(ipDataGateway)
+
+	// forwarded chains
+	forwardedHandler := forwarded.NewHandler(ipDataGateway, trusted)
+
+	// dataset releases
+	releasesHandler := releases.NewHandler(loadReleasesGateway())
 
 	// Routes --------------------------
 
@@ -32,9 +68,41 @@ func (d *Application) LoadAndRoute() {
 	r := mux.NewRouter()
 
 	r.HandleFunc("/ipdata/count/ip/{country_name}", ipDataHandler.GetIPCountByCountryName).Methods("GET")
+	r.HandleFunc("/ipdata/count/{country}", ipDataHandler.GetIPCountByCountry).Methods("GET")
+	r.HandleFunc("/ipdata/countries/top", ipDataHandler.GetTopCountries).Methods("GET")
+	r.HandleFunc("/ipdata/query", ipDataHandler.RunQuery).Methods("GET")
+	r.HandleFunc("/ipdata/isp/search", ipDataHandler.SearchIsp).Methods("GET")
+	r.HandleFunc("/ipdata/isp/{isp}/ranges", ipDataHandler.GetRangesByIsp).Methods("GET")
+	r.HandleFunc("/ipdata/export/blocklist", ipDataHandler.ExportBlocklist).Methods("GET")
+	r.HandleFunc("/ipdata/autocomplete/{field}", ipDataHandler.Autocomplete).Methods("GET")
+	r.HandleFunc("/ipdata/jobs/enrich", enrichHandler.SubmitJob).Methods("POST")
+	r.HandleFunc("/ipdata/jobs/{id}", enrichHandler.GetJob).Methods("GET")
+	r.HandleFunc("/ipdata/jobs/{id}/result", enrichHandler.GetJobResult).Methods("GET")
+	r.HandleFunc("/ipdata/reports/accesslog", accessLogHandler.GetReport).Methods("POST")
+	r.HandleFunc("/ipdata/extract", ipDataHandler.ExtractIPs).Methods("POST")
+	r.HandleFunc("/ipdata/forwarded", forwardedHandler.AnalyzeChain).Methods("POST")
+	r.HandleFunc("/ipdata/coverage", ipDataHandler.GetCoverage).Methods("GET")
+	r.HandleFunc("/ipdata/overrides", ipDataHandler.ListOverrides).Methods("GET")
+	r.HandleFunc("/ipdata/overrides", ipDataHandler.CreateOverride).Methods("POST")
+	r.HandleFunc("/ipdata/overrides/{id}", ipDataHandler.GetOverride).Methods("GET")
+	r.HandleFunc("/ipdata/overrides/{id}", ipDataHandler.UpdateOverride).Methods("PUT")
+	r.HandleFunc("/ipdata/overrides/{id}", ipDataHandler.DeleteOverride).Methods("DELETE")
+	r.HandleFunc("/ipdata/releases", releasesHandler.GetReleases).Methods("GET")
+	r.HandleFunc("/ipdata/trends/country/{country}", releasesHandler.GetCountryTrend).Methods("GET")
+	r.HandleFunc("/ipdata/trends/isp/{isp}", releasesHandler.GetIspTrend).Methods("GET")
+	r.HandleFunc("/ipdata/trends/movers", releasesHandler.GetMovers).Methods("GET")
+	r.HandleFunc("/ipdata/me", ipDataHandler.GetDataFromCaller).Methods("GET")
+	r.HandleFunc("/ipdata/{ip}", releasesHandler.GetDataAsOf).Queries("as_of", "{as_of}").Methods("GET")
 	r.HandleFunc("/ipdata/{ip}", ipDataHandler.GetDataFromIP).Methods("GET")
+	r.HandleFunc("/ipdata/{ip}/explain", ipDataHandler.ExplainIP).Methods("GET")
+	r.HandleFunc("/ipdata/{ip}/neighbors", ipDataHandler.GetNeighbors).Methods("GET")
+	r.HandleFunc("/ipdata/{ip}/history", releasesHandler.GetHistory).Methods("GET")
 	r.HandleFunc("/ipdata/top10/Switzerland", ipDataHandler.GetTopISPsFromSwitzerland).Methods("GET")
 
+	//authz
+	r.HandleFunc("/authz/nginx", authzHandler.NginxAuthRequest)
+	r.PathPrefix("/authz/envoy").HandlerFunc(authzHandler.EnvoyExtAuthz)
+
 	srv := &http.Server{
 		Handler:      r,
 		Addr:         "127.0.0.1:" + appPort,
@@ -44,3 +112,30 @@ func (d *Application) LoadAndRoute() {
 
 	d.server = srv
 }
+
+// loadIpDataGateway connects to the dataStorage and builds the ipData gateway
+func loadIpDataGateway() ipdata.Gateway {
+	// dataStorage
+	ipv4ProxyDB, _ := services.ConnectToSQLDB(services.Ipv4ProxyDB)
+
+	ipDataDao := ipdata.NewDao(ipv4ProxyDB)
+	if *validateDataset {
+		report, err := ipdata.ValidateBeforeLoad(context.Background(), ipDataDao)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("dataset validated: %d ranges, %d warnings", report.Ranges, report.Warnings)
+	}
+	overrides, err := ipdata.NewOverrideStore(*overridesFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return ipdata.NewGateway(ipDataDao, overrides)
+}
+
+// loadReleasesGateway connects to the dataStorage and builds the gateway of the retained releases
+func loadReleasesGateway() releases.Gateway {
+	ipv4ProxyDB, _ := services.ConnectToSQLDB(services.Ipv4ProxyDB)
+
+	return releases.NewGateway(releases.NewDao(ipv4ProxyDB))
+}
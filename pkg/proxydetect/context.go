@@ -0,0 +1,21 @@
+package proxydetect
+
+import (
+	"DreamLabChallenge/cmd/api/ipdata"
+	"context"
+)
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the given IpData
+func NewContext(ctx context.Context, data ipdata.IpData) context.Context {
+	return context.WithValue(ctx, contextKey{}, data)
+}
+
+// FromContext returns the IpData attached by the Middleware.
+// found is false when the middleware did not run or the lookup failed, an ip not present in the
+// dataset (not a proxy) is found with an empty ProxyType
+func FromContext(ctx context.Context) (data ipdata.IpData, found bool) {
+	data, found = ctx.Value(contextKey{}).(ipdata.IpData)
+	return data, found
+}
@@ -0,0 +1,70 @@
+// Package proxydetect provides a net/http middleware that resolves the caller ip against the ipdata
+// dataset, so other services can embed the lookup in-process instead of calling the http API.
+package proxydetect
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+)
+
+// Middleware resolves the caller ip, looks it up through the given Gateway and attaches the IpData to the
+// request context (see FromContext). Requests blocked by the configured options are answered with a 403.
+func Middleware(g ipdata.Gateway, opts ...Option) func(http.Handler) http.Handler {
+	cfg := config{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	policy := cfg.policy()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			clientIP, err := common.GetClientIP(r, cfg.trustedProxies, cfg.clientIPHeader)
+			if err != nil {
+				common.HandlerErrorResponse(w, err)
+				return
+			}
+
+			ipData, err := Lookup(ctx, g, clientIP)
+			if err != nil {
+				if !cfg.failOpen {
+					common.HandlerErrorResponse(w, err)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			allowed, reason := policy.Evaluate(ipData)
+			if !allowed {
+				common.HandlerErrorResponse(w, fmt.Errorf("%s %w", reason, common.ErrorForbidden))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(NewContext(ctx, ipData)))
+		})
+	}
+}
+
+// Lookup returns the data of the given ip. An ip not present in the dataset is not a proxy, in that case
+// (and for non ipv4 addresses, which the dataset doesn't hold) an empty IpData is returned with no error
+func Lookup(ctx context.Context, g ipdata.Gateway, ip netip.Addr) (ipdata.IpData, error) {
+	if !ip.Is4() {
+		return ipdata.IpData{}, nil
+	}
+	ipData, err := g.GetDataFromIP(ctx, ip)
+	if err != nil {
+		if errors.Is(err, common.ErrorNotFound) {
+			return ipdata.IpData{}, nil
+		}
+		return ipdata.IpData{}, err
+	}
+
+	return ipData, nil
+}
@@ -0,0 +1,162 @@
+package proxydetect
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []common.TestCase{
+		{Scenario: "IpData attached to context", TestFn: testMiddlewareAttachesIpData},
+		{Scenario: "Ip not in dataset attached empty", TestFn: testMiddlewareNotFoundAttachesEmpty},
+		{Scenario: "Ipv6 client not looked up", TestFn: testMiddlewareIpv6NotLookedUp},
+		{Scenario: "Blocked proxy type", TestFn: testMiddlewareBlockedProxyType},
+		{Scenario: "Blocked country", TestFn: testMiddlewareBlockedCountry},
+		{Scenario: "Trusted proxy forwarded for", TestFn: testMiddlewareTrustedProxy},
+		{Scenario: "Trusted proxy Forwarded header", TestFn: testMiddlewareTrustedProxyForwardedHeader},
+		{Scenario: "Gateway error", TestFn: testMiddlewareGtwError},
+		{Scenario: "Gateway error fail open", TestFn: testMiddlewareGtwErrorFailOpen},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Scenario, testCase.TestFn)
+	}
+}
+
+func testMiddlewareAttachesIpData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(mockMiddlewareIpData, nil)
+
+	rr, data, found := serveMiddleware(Middleware(mockGtw, WithBlockedProxyTypes("TOR")), "5.181.131.180:443", nil)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, found)
+	assert.Equal(t, mockMiddlewareIpData, data)
+}
+
+func testMiddlewareNotFoundAttachesEmpty(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(ipdata.IpData{}, common.ErrorNotFound)
+
+	rr, data, found := serveMiddleware(Middleware(mockGtw), "5.181.131.180:443", nil)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, found)
+	assert.Equal(t, ipdata.IpData{}, data)
+}
+
+func testMiddlewareIpv6NotLookedUp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+
+	rr, _, found := serveMiddleware(Middleware(mockGtw), "[2001:db8::1]:443", nil)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, found)
+}
+
+func testMiddlewareBlockedProxyType(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(mockMiddlewareIpData, nil)
+
+	rr, _, _ := serveMiddleware(Middleware(mockGtw, WithBlockedProxyTypes("VPN")), "5.181.131.180:443", nil)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Equal(t, "proxy_type VPN is blocked forbidden\n", rr.Body.String())
+}
+
+func testMiddlewareBlockedCountry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(mockMiddlewareIpData, nil)
+
+	rr, _, _ := serveMiddleware(Middleware(mockGtw, WithBlockedCountries("CH", "GB")), "5.181.131.180:443", nil)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Equal(t, "country_code GB is blocked forbidden\n", rr.Body.String())
+}
+
+func testMiddlewareTrustedProxy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(mockMiddlewareIpData, nil)
+
+	middleware := Middleware(mockGtw, WithTrustedProxies(netip.MustParsePrefix("192.168.0.0/16")))
+	rr, data, _ := serveMiddleware(middleware, "192.168.1.1:443", map[string]string{"X-Forwarded-For": "5.181.131.180"})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, mockMiddlewareIpData, data)
+}
+
+func testMiddlewareTrustedProxyForwardedHeader(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(mockMiddlewareIpData, nil)
+
+	middleware := Middleware(mockGtw, WithTrustedProxies(netip.MustParsePrefix("192.168.0.0/16")), WithClientIPHeader("Forwarded"))
+	rr, data, _ := serveMiddleware(middleware, "192.168.1.1:443", map[string]string{"Forwarded": "for=5.181.131.180", "X-Forwarded-For": "8.8.8.8"})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, mockMiddlewareIpData, data)
+}
+
+func testMiddlewareGtwError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(ipdata.IpData{}, common.ErrorInternalServer)
+
+	rr, _, _ := serveMiddleware(Middleware(mockGtw), "5.181.131.180:443", nil)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
+func testMiddlewareGtwErrorFailOpen(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockGtw := ipdata.NewMockGateway(ctrl)
+	mockGtw.EXPECT().GetDataFromIP(gomock.Any(), netip.MustParseAddr("5.181.131.180")).Return(ipdata.IpData{}, errors.New("connection error"))
+
+	rr, _, found := serveMiddleware(Middleware(mockGtw, WithFailOpen()), "5.181.131.180:443", nil)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.False(t, found)
+}
+
+// mock utils
+
+var mockMiddlewareIpData = ipdata.IpData{
+	IpFrom:      95781810,
+	IpTo:        95781817,
+	ProxyType:   "VPN",
+	CountryCode: "GB",
+	ISP:         "IPXO Limited",
+	IpString:    "5.181.131.180",
+}
+
+// serveMiddleware runs a request through the middleware and returns what the wrapped handler found in the context
+func serveMiddleware(middleware func(http.Handler) http.Handler, remoteAddr string, headers map[string]string) (*httptest.ResponseRecorder, ipdata.IpData, bool) {
+	var data ipdata.IpData
+	var found bool
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, found = FromContext(r.Context())
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = remoteAddr
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	rr := httptest.NewRecorder()
+	middleware(next).ServeHTTP(rr, req)
+
+	return rr, data, found
+}
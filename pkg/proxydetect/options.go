@@ -0,0 +1,60 @@
+package proxydetect
+
+import (
+	"DreamLabChallenge/cmd/api/common"
+	"DreamLabChallenge/cmd/api/ipdata"
+	"net/netip"
+)
+
+type config struct {
+	trustedProxies      common.TrustedProxies
+	clientIPHeader      string
+	blockedProxyTypes   []string
+	blockedCountryCodes []string
+	failOpen            bool
+}
+
+// Option configures the Middleware
+type Option func(cfg *config)
+
+// WithTrustedProxies sets the networks allowed to set the client ip through X-Forwarded-For.
+// by default X-Forwarded-For is ignored and the client ip is taken from RemoteAddr
+func WithTrustedProxies(prefixes ...netip.Prefix) Option {
+	return func(cfg *config) {
+		cfg.trustedProxies = append(cfg.trustedProxies, prefixes...)
+	}
+}
+
+// WithClientIPHeader sets the header where the trusted proxies write the client ip, Forwarded (RFC 7239) or a
+// X-Forwarded-For like list. by default X-Forwarded-For is read, no other header is
+func WithClientIPHeader(header string) Option {
+	return func(cfg *config) {
+		cfg.clientIPHeader = header
+	}
+}
+
+// WithBlockedProxyTypes rejects the requests coming from the given proxy types (VPN, TOR, PUB...)
+func WithBlockedProxyTypes(proxyTypes ...string) Option {
+	return func(cfg *config) {
+		cfg.blockedProxyTypes = append(cfg.blockedProxyTypes, proxyTypes...)
+	}
+}
+
+// WithBlockedCountries rejects the requests coming from the given country codes
+func WithBlockedCountries(countryCodes ...string) Option {
+	return func(cfg *config) {
+		cfg.blockedCountryCodes = append(cfg.blockedCountryCodes, countryCodes...)
+	}
+}
+
+// WithFailOpen lets the request through (with no IpData attached) when the lookup fails,
+// by default the middleware answers with an error
+func WithFailOpen() Option {
+	return func(cfg *config) {
+		cfg.failOpen = true
+	}
+}
+
+func (cfg config) policy() ipdata.Policy {
+	return ipdata.NewPolicy(cfg.blockedProxyTypes, cfg.blockedCountryCodes)
+}